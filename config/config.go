@@ -0,0 +1,341 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinJWTSecretLength is the shortest accepted JWT_SECRET, in bytes. HS256 keys
+// shorter than the hash output can be brute forced offline from a single token
+const MinJWTSecretLength = 32
+
+// Config is the validated configuration of the server. Every setting has an env
+// tag naming its environment variable, a yaml tag for config files and can be
+// given as a flag named after the variable, e.g. -server-port for SERVER_PORT.
+// Settings tagged secret are redacted when the config is printed
+type Config struct {
+	Server      Server      `yaml:"server"`
+	Mongo       Mongo       `yaml:"mongo"`
+	Auth        Auth        `yaml:"auth"`
+	Password    Password    `yaml:"password"`
+	OIDC        OIDC        `yaml:"oidc"`
+	Email       Email       `yaml:"email"`
+	SMTP        SMTP        `yaml:"smtp"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Log         Log         `yaml:"log"`
+	Trash       Trash       `yaml:"trash"`
+	Idempotency Idempotency `yaml:"idempotency"`
+}
+
+type Server struct {
+	// an empty host listens on all interfaces
+	Host              string        `yaml:"host" env:"SERVER_HOST"`
+	Port              int           `yaml:"port" env:"SERVER_PORT" default:"8080"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"15s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"60s"`
+	// how long in-flight requests get to finish on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"20s"`
+	// how long the checks of /readyz get
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"READINESS_TIMEOUT" default:"2s"`
+	// comma separated IPs or CIDRs of the proxies whose X-Forwarded-For is believed,
+	// without any the client IP is the address of the connection
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type Mongo struct {
+	// the password in the URI is redacted
+	URI          string        `yaml:"uri" env:"MONGODB_URI" secret:"url"`
+	Database     string        `yaml:"database" env:"MONGODB_DATABASE" default:"task-management"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"DB_READ_TIMEOUT" default:"10s"`
+	ListTimeout  time.Duration `yaml:"list_timeout" env:"DB_LIST_TIMEOUT" default:"30s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"DB_WRITE_TIMEOUT" default:"10s"`
+}
+
+type Auth struct {
+	JWTSecret             string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	RegistrationMode      string `yaml:"registration_mode" env:"REGISTRATION_MODE" default:"open"`
+	RequireAdminTwoFactor bool   `yaml:"require_admin_2fa" env:"REQUIRE_ADMIN_2FA"`
+	DisablePasswordLogin  bool   `yaml:"disable_password_login" env:"DISABLE_PASSWORD_LOGIN"`
+	// "mongo" or "memory"
+	LoginAttemptStore    string `yaml:"login_attempt_store" env:"LOGIN_ATTEMPT_STORE" default:"mongo"`
+	InitialAdminUsername string `yaml:"initial_admin_username" env:"INITIAL_ADMIN_USERNAME"`
+	InitialAdminPassword string `yaml:"initial_admin_password" env:"INITIAL_ADMIN_PASSWORD" secret:"true"`
+}
+
+type Password struct {
+	// "bcrypt" or "argon2id"
+	Hasher            string `yaml:"hasher" env:"PASSWORD_HASHER" default:"bcrypt"`
+	BcryptCost        int    `yaml:"bcrypt_cost" env:"BCRYPT_COST" default:"10"`
+	Argon2Memory      int    `yaml:"argon2_memory" env:"ARGON2_MEMORY" default:"65536"`
+	Argon2Iterations  int    `yaml:"argon2_iterations" env:"ARGON2_ITERATIONS" default:"3"`
+	Argon2Parallelism int    `yaml:"argon2_parallelism" env:"ARGON2_PARALLELISM" default:"2"`
+}
+
+type OIDC struct {
+	// enables OIDC login when set
+	IssuerURL     string `yaml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID      string `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret  string `yaml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL   string `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	AutoProvision bool   `yaml:"auto_provision" env:"OIDC_AUTO_PROVISION"`
+	// link identities to existing users with the same verified email
+	LinkByEmail bool `yaml:"link_by_email" env:"OIDC_LINK_BY_EMAIL"`
+}
+
+type Email struct {
+	RequireVerification bool `yaml:"require_verification" env:"REQUIRE_EMAIL_VERIFICATION"`
+	// defaults to http://localhost:SERVER_PORT/verify-email
+	VerificationURL string `yaml:"verification_url" env:"EMAIL_VERIFICATION_URL"`
+}
+
+type SMTP struct {
+	// emails are only logged when no host is set
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+type Tracing struct {
+	// "none", "otlp" or "stdout"
+	Exporter    string `yaml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"task-manager"`
+	// e.g. http://localhost:4318, defaults to the collector on localhost
+	OTLPEndpoint string `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+}
+
+type Log struct {
+	// "debug", "info", "warn" or "error"
+	Level string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	// "json" or "text"
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+type Trash struct {
+	// how long deleted tasks can be restored, they are kept forever when 0
+	Retention time.Duration `yaml:"retention" env:"TRASH_RETENTION" default:"720h"`
+	// how often tasks past the retention are purged
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" default:"1h"`
+}
+
+type Idempotency struct {
+	// how long responses to requests with an Idempotency-Key are replayed, keys are ignored when 0
+	Window time.Duration `yaml:"window" env:"IDEMPOTENCY_WINDOW" default:"24h"`
+	// how long a key stays reserved for a request that is still being handled,
+	// after that a retry is handled as a new request
+	Lease time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE" default:"1m"`
+}
+
+// Addr is the host:port the server listens on
+func (s Server) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// SlogLevel is the validated LOG_LEVEL as a slog level
+func (l Log) SlogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(l.Level))
+	return level
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Auth.JWTSecret == "" {
+		invalid("JWT_SECRET is required")
+	} else if len(c.Auth.JWTSecret) < MinJWTSecretLength {
+		invalid("JWT_SECRET must be at least %d bytes long, it has %d", MinJWTSecretLength, len(c.Auth.JWTSecret))
+	}
+	if c.Mongo.URI == "" {
+		invalid("MONGODB_URI is required")
+	}
+	if c.Mongo.Database == "" {
+		invalid("MONGODB_DATABASE must not be empty")
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("SERVER_PORT must be between 1 and 65535")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			invalid("TRUSTED_PROXIES must list IPs or CIDRs, not %q", proxy)
+		}
+	}
+
+	forEachSetting(reflect.ValueOf(c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if value.Kind() == reflect.Int64 && value.Int() < 0 {
+			invalid("%s must not be negative", field.Tag.Get("env"))
+		}
+	})
+	for name, value := range map[string]int{
+		"BCRYPT_COST":        c.Password.BcryptCost,
+		"ARGON2_MEMORY":      c.Password.Argon2Memory,
+		"ARGON2_ITERATIONS":  c.Password.Argon2Iterations,
+		"ARGON2_PARALLELISM": c.Password.Argon2Parallelism,
+		"SMTP_PORT":          c.SMTP.Port,
+	} {
+		if value <= 0 {
+			invalid("%s must be a positive number", name)
+		}
+	}
+	if c.Password.Argon2Parallelism > 255 {
+		invalid("ARGON2_PARALLELISM must be at most 255")
+	}
+
+	oneOf := func(name string, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		invalid("%s must be one of %s, not %q", name, strings.Join(allowed, ", "), value)
+	}
+	oneOf("REGISTRATION_MODE", c.Auth.RegistrationMode, "open", "invite", "closed")
+	oneOf("LOGIN_ATTEMPT_STORE", c.Auth.LoginAttemptStore, "mongo", "memory")
+	oneOf("PASSWORD_HASHER", c.Password.Hasher, "bcrypt", "argon2id")
+	oneOf("TRACING_EXPORTER", c.Tracing.Exporter, "none", "otlp", "stdout")
+	oneOf("LOG_LEVEL", c.Log.Level, "debug", "info", "warn", "error")
+	oneOf("LOG_FORMAT", c.Log.Format, "json", "text")
+
+	if c.Auth.InitialAdminUsername != "" && c.Auth.InitialAdminPassword == "" {
+		invalid("INITIAL_ADMIN_PASSWORD is required with INITIAL_ADMIN_USERNAME")
+	}
+	if c.OIDC.IssuerURL != "" {
+		if c.OIDC.ClientID == "" {
+			invalid("OIDC_CLIENT_ID is required with OIDC_ISSUER_URL")
+		}
+		if c.OIDC.RedirectURL == "" {
+			invalid("OIDC_REDIRECT_URL is required with OIDC_ISSUER_URL")
+		}
+	} else if c.Auth.DisablePasswordLogin {
+		invalid("DISABLE_PASSWORD_LOGIN requires OIDC_ISSUER_URL")
+	}
+	if c.Trash.Retention > 0 && c.Trash.PurgeInterval <= 0 {
+		invalid("TRASH_PURGE_INTERVAL must be positive with TRASH_RETENTION")
+	}
+	if c.Idempotency.Window > 0 && c.Idempotency.Lease <= 0 {
+		invalid("IDEMPOTENCY_LEASE must be positive with IDEMPOTENCY_WINDOW")
+	}
+	if c.SMTP.Host != "" && c.SMTP.From == "" {
+		invalid("SMTP_FROM is required with SMTP_HOST")
+	}
+	// without SMTP the verification links would only be written to the logs, where anyone reading them could use them
+	if c.Email.RequireVerification && c.SMTP.Host == "" {
+		invalid("REQUIRE_EMAIL_VERIFICATION requires SMTP_HOST")
+	}
+
+	return errors.Join(errs...)
+}
+
+// String lists every setting by its environment variable with secrets redacted,
+// so the config can be logged
+func (c Config) String() string {
+	var b strings.Builder
+	forEachSetting(reflect.ValueOf(&c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		fmt.Fprintf(&b, "%s=%s\n", field.Tag.Get("env"), redact(field, formatValue(value)))
+	})
+	return b.String()
+}
+
+// GoString keeps secrets out of %#v too
+func (c Config) GoString() string {
+	return c.String()
+}
+
+// LogValue logs the settings as a group keyed by environment variable, with secrets redacted
+func (c Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	forEachSetting(reflect.ValueOf(&c).Elem(), func(field reflect.StructField, value reflect.Value) {
+		attrs = append(attrs, slog.String(field.Tag.Get("env"), redact(field, formatValue(value))))
+	})
+	return slog.GroupValue(attrs...)
+}
+
+func redact(field reflect.StructField, value string) string {
+	if value == "" {
+		return value
+	}
+	switch field.Tag.Get("secret") {
+	case "true":
+		return "[redacted]"
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil {
+			return "[redacted]"
+		}
+		return u.Redacted()
+	}
+	return value
+}
+
+func formatValue(value reflect.Value) string {
+	switch v := value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// call fn for every setting of the config struct v, in declaration order
+func forEachSetting(v reflect.Value, fn func(field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			forEachSetting(v.Field(i), fn)
+		} else if field.Tag.Get("env") != "" {
+			fn(field, v.Field(i))
+		}
+	}
+}
+
+// parse a setting from its string form
+func setValue(value reflect.Value, raw string) error {
+	switch value.Interface().(type) {
+	case string:
+		value.SetString(raw)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		value.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s", raw)
+		}
+		value.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+	return nil
+}
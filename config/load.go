@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvFile is read from the working directory when it exists
+const DefaultEnvFile = ".env"
+
+// Load builds the config from, lowest precedence first, the defaults, a YAML file
+// given with -config or CONFIG_FILE, a .env file given with -env-file or ENV_FILE,
+// the environment and the flags in args. The result is validated
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	var cfg Config
+	var errs []error
+
+	// parse the flags first for -config and -env-file, their settings are applied last
+	flags := flag.NewFlagSet("task_manager", flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML config file")
+	envFile := flags.String("env-file", "", "file with environment variables, defaults to "+DefaultEnvFile)
+	flagValues := make(map[string]string)
+	forEachSetting(reflect.ValueOf(&cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		flags.Func(flagName(name), "overrides "+name, func(raw string) error {
+			flagValues[name] = raw
+			return nil
+		})
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	forEachSetting(reflect.ValueOf(&cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if raw, ok := field.Tag.Lookup("default"); ok {
+			if err := setValue(value, raw); err != nil {
+				panic(fmt.Sprintf("default of %s: %v", field.Tag.Get("env"), err))
+			}
+		}
+	})
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		if err := loadYAML(*configFile, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	dotenv, err := loadEnvFile(*envFile, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	forEachSetting(reflect.ValueOf(&cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		raw, ok := lookupEnv(name)
+		if !ok {
+			raw, ok = dotenv[name]
+		}
+		if flagValue, isFlag := flagValues[name]; isFlag {
+			raw, ok = flagValue, true
+		}
+		if !ok {
+			return
+		}
+		if err := setValue(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if cfg.Email.VerificationURL == "" {
+		cfg.Email.VerificationURL = "http://localhost:" + strconv.Itoa(cfg.Server.Port) + "/verify-email"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// SERVER_PORT is given as -server-port
+func flagName(envName string) string {
+	return strings.ReplaceAll(strings.ToLower(envName), "_", "-")
+}
+
+func loadYAML(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// typos in the file would otherwise be silently ignored
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// read the variables of the .env file, a missing default file is fine
+func loadEnvFile(path string, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	if path == "" {
+		path, _ = lookupEnv("ENV_FILE")
+	}
+	explicit := path != ""
+	if !explicit {
+		path = DefaultEnvFile
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("env file %s: %w", path, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyController struct {
+	Service usecases.APIKeyServiceInterface
+}
+
+// credentials can only be managed with a login token, so a leaked api key can't mint new ones
+func rejectAPIKeyCaller(c *gin.Context) bool {
+	if _, ok := c.Get("api_key_id"); ok {
+		c.Error(domain.ErrAPIKeyNotAllowed)
+		return true
+	}
+	return false
+}
+
+func (con *APIKeyController) CreateAPIKey(c *gin.Context) {
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	var apiKey domain.APIKey
+	if !bindJSON(c, &apiKey) {
+		return
+	}
+
+	key, newKey, err := con.Service.CreateAPIKey(c.Request.Context(), c.GetString("username"), apiKey)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, gin.H{"key": key, "api_key": newKey})
+}
+
+func (con *APIKeyController) GetAPIKeys(c *gin.Context) {
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	apiKeys, err := con.Service.GetAPIKeys(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, apiKeys)
+}
+
+func (con *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(invalidIDError("invalid API key ID"))
+		return
+	}
+
+	err = con.Service.RevokeAPIKey(c.Request.Context(), c.GetString("username"), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController only reads the audit log, entries are added by the services
+type AuditController struct {
+	Service usecases.AuditServiceInterface
+}
+
+func (con *AuditController) GetEntries(c *gin.Context) {
+	var filter domain.AuditFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	entries, err := con.Service.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, entries)
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bind the JSON body into obj, reporting a validation error when it doesn't fit
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.Error(validationError(err))
+		return false
+	}
+	return true
+}
+
+// bind the query string into obj, reporting a validation error when it doesn't fit
+func bindQuery(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			c.Error(&domain.ValidationError{Detail: "query has invalid parameters", Cause: err})
+		} else {
+			c.Error(&domain.ValidationError{Detail: "invalid query: " + err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// keep the binding error as the cause, the error middleware describes its fields
+// in the client's language
+func validationError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &fieldErrs) || (errors.As(err, &typeErr) && typeErr.Field != "") {
+		return &domain.ValidationError{Detail: "request body has invalid fields", Cause: err}
+	}
+	return &domain.ValidationError{Detail: "invalid JSON: " + err.Error()}
+}
+
+// an id path parameter that isn't a uuid
+func invalidIDError(detail string) error {
+	return &domain.ValidationError{Detail: detail, Params: []domain.InvalidParam{{Name: "id", Reason: "must be a UUID", Rule: "uuid"}}}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthCheck is a dependency that has to work for the server to be ready
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthController struct {
+	Checks []HealthCheck
+	// how long the checks get, 0 for no limit
+	Timeout time.Duration
+	// where failed checks are logged, slog.Default() when nil.
+	// The probes need no credentials so the errors are never part of the response
+	Logger *slog.Logger
+}
+
+type healthStatus struct {
+	Status string                  `json:"status"`
+	Checks map[string]healthStatus `json:"checks,omitempty"`
+}
+
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+// Live answers as long as the process serves requests
+func (con *HealthController) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, healthStatus{Status: healthOK})
+}
+
+// Ready runs every check concurrently, the server is ready when all of them pass
+func (con *HealthController) Ready(c *gin.Context) {
+	ctx := c.Request.Context()
+	if con.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, con.Timeout)
+		defer cancel()
+	}
+
+	results := make([]error, len(con.Checks))
+	var wg sync.WaitGroup
+	for i, check := range con.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check.Check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	status := healthStatus{Status: healthOK, Checks: make(map[string]healthStatus, len(con.Checks))}
+	for i, check := range con.Checks {
+		if err := results[i]; err != nil {
+			con.logger().WarnContext(ctx, "readiness check failed", "check", check.Name, "error", err)
+			status.Status = healthUnavailable
+			status.Checks[check.Name] = healthStatus{Status: healthUnavailable}
+		} else {
+			status.Checks[check.Name] = healthStatus{Status: healthOK}
+		}
+	}
+
+	code := http.StatusOK
+	if status.Status != healthOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, status)
+}
+
+func (con *HealthController) logger() *slog.Logger {
+	if con.Logger == nil {
+		return slog.Default()
+	}
+	return con.Logger
+}
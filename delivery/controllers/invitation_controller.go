@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvitationController struct {
+	Service usecases.InvitationServiceInterface
+}
+
+func (con *InvitationController) CreateInvitation(c *gin.Context) {
+	var invitation domain.Invitation
+	if !bindJSON(c, &invitation) {
+		return
+	}
+
+	token, newInvitation, err := con.Service.CreateInvitation(c.Request.Context(), c.GetString("username"), invitation)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, gin.H{"token": token, "invitation": newInvitation})
+}
+
+func (con *InvitationController) GetInvitations(c *gin.Context) {
+	invitations, err := con.Service.GetInvitations(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, invitations)
+}
+
+func (con *InvitationController) DeleteInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(invalidIDError("invalid invitation ID"))
+		return
+	}
+
+	err = con.Service.DeleteInvitation(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
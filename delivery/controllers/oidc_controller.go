@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcStateCookie    = "oidc_state"
+	oidcVerifierCookie = "oidc_verifier"
+	oidcCookiePath     = "/oidc"
+	// seconds the user has to finish the login at the identity provider
+	oidcCookieMaxAge = 600
+)
+
+type OIDCController struct {
+	Service usecases.OIDCServiceInterface
+	// send the state cookies over https only
+	SecureCookies bool
+}
+
+// redirect to the identity provider, state and code verifier are kept in cookies until the callback
+func (con *OIDCController) Login(c *gin.Context) {
+	authRequest, err := con.Service.StartLogin()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// lax so the cookies are sent on the redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, authRequest.State, oidcCookieMaxAge, oidcCookiePath, "", con.SecureCookies, true)
+	c.SetCookie(oidcVerifierCookie, authRequest.CodeVerifier, oidcCookieMaxAge, oidcCookiePath, "", con.SecureCookies, true)
+	c.Redirect(http.StatusFound, authRequest.URL)
+}
+
+// the identity provider redirects here with the authorization code
+func (con *OIDCController) Callback(c *gin.Context) {
+	state, stateErr := c.Cookie(oidcStateCookie)
+	codeVerifier, verifierErr := c.Cookie(oidcVerifierCookie)
+
+	// the cookies are single use
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", con.SecureCookies, true)
+	c.SetCookie(oidcVerifierCookie, "", -1, oidcCookiePath, "", con.SecureCookies, true)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.Error(&domain.Error{Kind: domain.KindUnauthorized, Message: "identity provider returned " + providerErr})
+		return
+	}
+
+	if stateErr != nil || verifierErr != nil || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.Error(domain.ErrInvalidOIDCState)
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.Error(&domain.ValidationError{Detail: "authorization code is required", Params: []domain.InvalidParam{{Name: "code", Reason: "is required"}}})
+		return
+	}
+
+	result, err := con.Service.FinishLogin(c.Request.Context(), code, codeVerifier)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	writeLoginResult(c, result)
+}
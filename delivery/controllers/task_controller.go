@@ -6,7 +6,6 @@ import (
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
@@ -15,9 +14,9 @@ type TaskController struct {
 }
 
 func (con *TaskController) GetTasks(c *gin.Context) {
-	tasks, err := con.Service.GetTasks()
+	tasks, err := con.Service.GetTasks(c.Request.Context())
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.IndentedJSON(http.StatusOK, tasks)
@@ -26,70 +25,53 @@ func (con *TaskController) GetTasks(c *gin.Context) {
 func (con *TaskController) GetTaskById(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		c.Error(invalidIDError("invalid task ID"))
 		return
 	}
 
-	task, err := con.Service.GetTaskById(id)
+	task, err := con.Service.GetTaskById(c.Request.Context(), id)
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Task Not Found"})
+		c.Error(err)
 		return
 	}
 
 	c.IndentedJSON(http.StatusOK, task)
 }
 
+func (con *TaskController) GetTaskHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(invalidIDError("invalid task ID"))
+		return
+	}
+
+	history, err := con.Service.GetTaskHistory(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, history)
+}
+
 func (con *TaskController) UpdateTaskByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		c.Error(invalidIDError("invalid task ID"))
 		return
 	}
 
 	var updatedTask domain.Task
 
-	if err := c.ShouldBindJSON(&updatedTask); err != nil {
-
-		var validationErrors validator.ValidationErrors
-		if errors, ok := err.(validator.ValidationErrors); ok {
-		  validationErrors = errors
-		}
-	
-		errorMessages := make(map[string]string)
-		for _, e := range validationErrors {
-	
-		  field := e.Field()
-		  fmt.Println(field, "this is field")
-		  switch field {
-		  case "Title":
-			errorMessages["title"] = "Title is required."
-		  case "Description":
-			errorMessages["description"] = "Description is required."
-	
-		  case "Status":
-			errorMessages["status"] = "Status is required."
-	
-		  case "DueDate":
-			errorMessages["due_date"] = "DueDate is required."
-		  }
-		}
-
-		if len(errorMessages) == 0 {
-			errorMessages["error"] = "Invalid JSON"
-		}
-	
-		c.JSON(http.StatusBadRequest, gin.H{"errors": errorMessages})
+	if !bindJSON(c, &updatedTask) {
 		return
-	  }
+	}
 	
 
-	err = con.Service.UpdateTaskByID(id, updatedTask)
+	err = con.Service.UpdateTaskByID(c.Request.Context(), c.GetString("username"), id, updatedTask)
 
-	if err != nil && err.Error() == "task not found" {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Task Not Found"})
-		return
-	} else if err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -99,63 +81,73 @@ func (con *TaskController) UpdateTaskByID(c *gin.Context) {
 func (con *TaskController) DeleteTask(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		c.Error(invalidIDError("invalid task ID"))
 		return
 	}
 
-	err = con.Service.DeleteTask(id)
-	if err == nil {
-		c.Status(http.StatusNoContent)
+	err = con.Service.DeleteTask(c.Request.Context(), c.GetString("username"), id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	c.IndentedJSON(http.StatusNotFound, gin.H{"error": "Task Not Found"})
+	c.Status(http.StatusNoContent)
+}
+
+func (con *TaskController) GetDeletedTasks(c *gin.Context) {
+	tasks, err := con.Service.GetDeletedTasks(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, tasks)
+}
+
+func (con *TaskController) RestoreTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(invalidIDError("invalid task ID"))
+		return
+	}
+
+	err = con.Service.RestoreTask(c.Request.Context(), c.GetString("username"), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
 
 func (con *TaskController) AddTask(c *gin.Context) {
 	var newTask domain.Task
-	if err := c.ShouldBindJSON(&newTask); err != nil {
-
-		var validationErrors validator.ValidationErrors
-		if errors, ok := err.(validator.ValidationErrors); ok {
-		  validationErrors = errors
-		}
-	
-		errorMessages := make(map[string]string)
-		for _, e := range validationErrors {
-	
-		  field := e.Field()
-		  fmt.Println(field, "this is field")
-		  switch field {
-		  case "Title":
-			errorMessages["title"] = "Title is required."
-		  case "Description":
-			errorMessages["description"] = "Description is required."
-	
-		  case "Status":
-			errorMessages["status"] = "Status is required."
-	
-		  case "DueDate":
-			errorMessages["due_date"] = "DueDate is required."
-		  }
-		}
-
-		if len(errorMessages) == 0 {
-			errorMessages["json"] = "Invalid JSON"
-		}
-	
-		c.JSON(http.StatusBadRequest, gin.H{"errors": errorMessages})
+	if !bindJSON(c, &newTask) {
 		return
-	  }
+	}
 	
-	baseURL := fmt.Sprintf("http://%s", c.Request.Host)
+	task, err := con.Service.AddTask(c.Request.Context(), c.GetString("username"), newTask)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	resourceLocation := fmt.Sprintf("%s%s/%s", baseURL, c.Request.URL.Path, newTask.ID)
+	// the id is assigned by the service
+	baseURL := fmt.Sprintf("http://%s", c.Request.Host)
+	resourceLocation := fmt.Sprintf("%s%s/%s", baseURL, c.Request.URL.Path, task.ID)
 	c.Header("Location", resourceLocation)
+	c.IndentedJSON(http.StatusCreated, task)
+}
 
-	task, err := con.Service.AddTask(newTask)
+func (con *TaskController) ApplyBulkOperations(c *gin.Context) {
+	var request domain.BulkTaskRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	response, err := con.Service.ApplyBulkOperations(c.Request.Context(), c.GetString("username"), request)
 	if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	c.IndentedJSON(http.StatusCreated, task)
+
+	c.IndentedJSON(http.StatusOK, response)
 }
@@ -2,10 +2,10 @@ package controllers
 
 import (
 	"net/http"
+
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 type UserController struct {
@@ -13,39 +13,17 @@ type UserController struct {
 }
 
 func (con *UserController) RegisterUser(c *gin.Context) {
-	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		var validationErrors validator.ValidationErrors
-		if errors, ok := err.(validator.ValidationErrors); ok {
-		  validationErrors = errors
-		}
-	
-		errorMessages := make(map[string]string)
-		for _, e := range validationErrors {
-	
-		  field := e.Field()
-		  switch field {
-			case "Username":
-				errorMessages["username"] = "username is required."
-			case "Password":
-				errorMessages["password"] = "password is required."
-		  }
-		}
-	
-		if len(errorMessages) == 0 {
-			errorMessages["json"] = "Invalid JSON"
-		}
-		
-		c.JSON(http.StatusBadRequest, gin.H{"errors": errorMessages})
-		return
-	}
-
-	newUser, err := con.Service.RegisterUser(&user)
-	if err != nil && err.Error() == "username already exists" {
-		c.IndentedJSON(http.StatusConflict, gin.H{"error": "username already exists"})
-		return
-	} else if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var body struct {
+		domain.User
+		InvitationToken string `json:"invitation_token"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	newUser, err := con.Service.RegisterUser(c.Request.Context(), &body.User, body.InvitationToken)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -54,39 +32,177 @@ func (con *UserController) RegisterUser(c *gin.Context) {
 
 func (con *UserController) Login(c *gin.Context) {
 	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &user) {
+		return
+	}
+
+	result, err := con.Service.LoginUser(c.Request.Context(), user, c.ClientIP())
+
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	token, err := con.Service.LoginUser(user)
-	if err != nil && err.Error() == "user not found" {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	writeLoginResult(c, result)
+}
+
+// respond with the token, or the challenge token when a second factor is needed
+func writeLoginResult(c *gin.Context, result *domain.LoginResult) {
+	if result.TwoFactorRequired {
+		c.IndentedJSON(http.StatusOK, gin.H{"message": "Two-factor authentication required", "two_factor_required": true, "challenge_token": result.ChallengeToken})
 		return
-	} else if err != nil && err.Error() == "invalid credentials" {
-		c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	}
+
+	if result.TwoFactorEnrollmentRequired {
+		c.IndentedJSON(http.StatusOK, gin.H{"message": "User logged in successfully, enable two-factor authentication to use admin rights", "token": result.Token, "two_factor_enrollment_required": true})
 		return
-	} else if err != nil {
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "User logged in successfully", "token": result.Token})
+}
+
+// second login step for users with two-factor authentication
+func (con *UserController) LoginTwoFactor(c *gin.Context) {
+	var login domain.TwoFactorLogin
+	if !bindJSON(c, &login) {
+		return
+	}
+
+	token, err := con.Service.VerifyTwoFactorLogin(c.Request.Context(), login, c.ClientIP())
+
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.IndentedJSON(http.StatusOK, gin.H{"message": "User logged in successfully", "token": token})
 }
 
+// start two-factor enrollment for the logged in user
+func (con *UserController) EnrollTwoFactor(c *gin.Context) {
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	enrollment, err := con.Service.EnrollTwoFactor(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, enrollment)
+}
+
+// confirm two-factor enrollment with a code from the authenticator app
+func (con *UserController) ConfirmTwoFactor(c *gin.Context) {
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	var body struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	recoveryCodes, err := con.Service.ConfirmTwoFactor(c.Request.Context(), c.GetString("username"), body.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": recoveryCodes})
+}
+
 // promote user to admin
 func (con *UserController) PromoteUser(c *gin.Context) {
 	// get username from query parameter
 	username := c.Query("username")
-	err := con.Service.PromoteUser(username)
+	err := con.Service.PromoteUser(c.Request.Context(), c.GetString("username"), username)
 	if err != nil {
-		if err.Error() =="username not found" {
-			c.IndentedJSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.IndentedJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// clear failed login attempts and lockout for a user
+func (con *UserController) UnlockUser(c *gin.Context) {
+	username := c.Query("username")
+	err := con.Service.UnlockUser(c.Request.Context(), c.GetString("username"), username)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// confirm an email address, this is the link sent in verification emails
+func (con *UserController) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.Error(&domain.ValidationError{Detail: "token is required", Params: []domain.InvalidParam{{Name: "token", Reason: "is required"}}})
+		return
+	}
+
+	err := con.Service.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// send a new verification link, always accepted so emails of accounts can't be probed
+func (con *UserController) ResendEmailVerification(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	err := con.Service.ResendEmailVerification(c.Request.Context(), body.Email)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusAccepted, gin.H{"message": "If the email belongs to an unverified account, a new verification link was sent"})
+}
+
+// profile of the logged in user
+func (con *UserController) GetProfile(c *gin.Context) {
+	profile, err := con.Service.GetProfile(c.Request.Context(), c.GetString("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, profile)
+}
+
+// change profile fields of the logged in user, omitted fields are kept and empty strings clear them
+func (con *UserController) UpdateProfile(c *gin.Context) {
+	// the email is part of account recovery, so only the user can change it
+	if rejectAPIKeyCaller(c) {
+		return
+	}
+
+	var update domain.ProfileUpdate
+	if !bindJSON(c, &update) {
+		return
+	}
+
+	profile, err := con.Service.UpdateProfile(c.Request.Context(), c.GetString("username"), update)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, profile)
+}
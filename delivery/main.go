@@ -2,55 +2,270 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/config"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/router"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
-	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
-	err := godotenv.Load("../.env")
-    if err != nil {
-        log.Fatalf("Error loading .env file")
-    }
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	// every layer logs through the default logger or one injected from here,
+	// the standard log package is redirected to it as well
+	logger := infrastructure.NewLogger(os.Stderr, cfg.Log.SlogLevel(), cfg.Log.Format)
+	slog.SetDefault(logger)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
+	}
+	// gin's own debug output is only wanted at debug level
+	if cfg.Log.SlogLevel() > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	logger.Info("configuration loaded", "config", cfg)
+
+	// SIGINT and SIGTERM start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	clientOptions := options.Client().ApplyURI(os.Getenv("MONGODB_URI"))
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	// traces of requests, use cases and mongo commands when TRACING_EXPORTER is set
+	shutdownTracing, err := infrastructure.SetupTracing(ctx, infrastructure.TracingConfig{
+		Exporter:     cfg.Tracing.Exporter,
+		ServiceName:  cfg.Tracing.ServiceName,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+	})
+	if err != nil {
+		fatal("could not set up tracing", err)
+	}
+
+	clientOptions := options.Client().ApplyURI(cfg.Mongo.URI).SetMonitor(infrastructure.NewMongoMonitor(otel.GetTracerProvider()))
+	client, err := mongo.Connect(ctx, clientOptions)
 
 	if err != nil {
-		log.Fatal(err)
+		fatal("could not connect to mongo", err)
 	}
 	// Check the connection
-	err = client.Ping(context.Background(), nil)
+	err = client.Ping(ctx, nil)
 
 	if err != nil {
-		log.Fatal(err)
-	} else {
-		fmt.Println("Connected to MongoDB!")
+		fatal("could not reach mongo", err)
 	}
+	logger.Info("connected to mongo", "database", cfg.Mongo.Database)
 
-	dbName := "task-management"
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	dbName := cfg.Mongo.Database
+	deadlines := repositories.Deadlines{Read: cfg.Mongo.ReadTimeout, List: cfg.Mongo.ListTimeout, Write: cfg.Mongo.WriteTimeout}
+	// background jobs, stopped on shutdown before mongo is disconnected
+	workers := infrastructure.Workers{Logger: logger}
+	metrics := infrastructure.NewMetrics()
+	var PasswordService usecases.PasswordServiceInterface = newPasswordService(cfg.Password)
+	var JwtService usecases.JwtServiceInterface = &infrastructure.JwtService{JwtSecret: []byte(cfg.Auth.JWTSecret)}
 
-	var PasswordService usecases.PasswordServiceInterface = &infrastructure.PasswordService{}
-	var JwtService usecases.JwtServiceInterface = &infrastructure.JwtService{JwtSecret: jwtSecret}
+	taskRepository := repositories.NewTaskRepository(client, dbName, "tasks", deadlines)
+	taskRepository.Observer = metrics
+	var TaskRepository usecases.TaskRepoInterface = taskRepository
+	// security relevant and data changing actions, only ever appended to
+	var AuditRepository usecases.AuditRepoInterface = repositories.NewAuditRepository(client, dbName, "audit_log", deadlines)
+	auditController := controllers.AuditController{Service: &usecases.AuditService{AuditRepo: AuditRepository}}
 
-	var TaskRepository usecases.TaskRepoInterface = repositories.NewTaskRepository(client, dbName, "tasks")
-	taskService := usecases.TaskService{TaskRepo: TaskRepository}
+	var TaskHistoryRepository usecases.TaskHistoryRepoInterface = repositories.NewTaskHistoryRepository(client, dbName, "task_history", deadlines)
+	taskService := usecases.TaskService{TaskRepo: TaskRepository, Audit: AuditRepository, History: TaskHistoryRepository, TrashRetention: cfg.Trash.Retention, Logger: logger}
 	taskController := controllers.TaskController{Service: &taskService}
+	// retries of requests with an Idempotency-Key get the first response for IDEMPOTENCY_WINDOW
+	var IdempotencyService usecases.IdempotencyServiceInterface
+	if cfg.Idempotency.Window > 0 {
+		var IdempotencyRepository usecases.IdempotencyRepoInterface = repositories.NewIdempotencyRepository(client, dbName, "idempotency_keys", deadlines)
+		IdempotencyService = &usecases.IdempotencyService{IdempotencyRepo: IdempotencyRepository, Window: cfg.Idempotency.Window, Lease: cfg.Idempotency.Lease}
+	}
+	// deleted tasks stay in the trash for TRASH_RETENTION
+	if cfg.Trash.Retention > 0 {
+		workers.Every(ctx, "purging deleted tasks", cfg.Trash.PurgeInterval, func(ctx context.Context) error {
+			purged, err := taskService.PurgeDeletedTasks(ctx)
+			if purged > 0 {
+				logger.Info("purged deleted tasks", "count", purged)
+			}
+			return err
+		})
+	}
 
-	var UserRepository usecases.UserRepoInterface = repositories.NewUserRepository(client, dbName, "users")
-	userService := usecases.UserService{UserRepo: UserRepository, PasswordService: PasswordService, JwtService: JwtService}
+	userRepository := repositories.NewUserRepository(client, dbName, "users", deadlines)
+	userRepository.Observer = metrics
+	var UserRepository usecases.UserRepoInterface = userRepository
+	// failed logins are tracked in mongo unless LOGIN_ATTEMPT_STORE=memory
+	var LoginAttemptRepository usecases.LoginAttemptRepoInterface
+	if cfg.Auth.LoginAttemptStore == "memory" {
+		memoryAttempts := repositories.NewInMemoryLoginAttemptRepository()
+		workers.Every(ctx, "pruning login attempts", time.Hour, func(ctx context.Context) error {
+			memoryAttempts.DeleteStale(time.Now().UTC())
+			return nil
+		})
+		LoginAttemptRepository = memoryAttempts
+	} else {
+		LoginAttemptRepository = repositories.NewLoginAttemptRepository(client, dbName, "login_attempts", deadlines)
+	}
+
+	var TotpService usecases.TotpServiceInterface = &infrastructure.TotpService{Issuer: "Task Manager"}
+	requireAdminTwoFactor := cfg.Auth.RequireAdminTwoFactor
+
+	passwordLoginDisabled := cfg.Auth.DisablePasswordLogin
+
+	var TokenGenerator usecases.TokenGeneratorInterface = &infrastructure.TokenGenerator{}
+	var InvitationRepository usecases.InvitationRepoInterface = repositories.NewInvitationRepository(client, dbName, "invitations", deadlines)
+	invitationService := usecases.InvitationService{InvitationRepo: InvitationRepository, TokenGenerator: TokenGenerator, Audit: AuditRepository, Logger: logger}
+	invitationController := controllers.InvitationController{Service: &invitationService}
+
+	var BootstrapRepository usecases.BootstrapRepoInterface = repositories.NewBootstrapRepository(client, dbName, "bootstrap", deadlines)
+
+	registrationMode := usecases.RegistrationMode(cfg.Auth.RegistrationMode)
+
+	userService := usecases.UserService{UserRepo: UserRepository, Bootstrap: BootstrapRepository, RegistrationMode: registrationMode, Invitations: InvitationRepository, TokenGenerator: TokenGenerator, PasswordService: PasswordService, JwtService: JwtService, LoginAttempts: LoginAttemptRepository, Lockout: usecases.DefaultLockoutPolicy(), TotpService: TotpService, RequireAdminTwoFactor: requireAdminTwoFactor, PasswordLoginDisabled: passwordLoginDisabled}
+	userService.Mailer = newMailer(cfg.SMTP)
+	userService.RequireEmailVerification = cfg.Email.RequireVerification
+	userService.VerificationURL = cfg.Email.VerificationURL
+	userService.Metrics = metrics
+	userService.Logger = logger
+	userService.Audit = AuditRepository
 	userController := controllers.UserController{Service: &userService}
-	
-	r := router.SetupRouter(&taskController, &userController)
-	r.Run("localhost:" + os.Getenv("SERVER_PORT"))
+
+	// create the initial admin when INITIAL_ADMIN_USERNAME is set
+	if adminUsername := cfg.Auth.InitialAdminUsername; adminUsername != "" {
+		if err := userService.BootstrapAdmin(ctx, adminUsername, cfg.Auth.InitialAdminPassword); err != nil {
+			fatal("could not create the initial admin", err)
+		}
+	}
+
+	var APIKeyRepository usecases.APIKeyRepoInterface = repositories.NewAPIKeyRepository(client, dbName, "api_keys", deadlines)
+	apiKeyService := usecases.APIKeyService{APIKeyRepo: APIKeyRepository, UserRepo: UserRepository, TokenGenerator: TokenGenerator, RequireAdminTwoFactor: requireAdminTwoFactor, RequireEmailVerification: cfg.Email.RequireVerification, Audit: AuditRepository, Logger: logger}
+	apiKeyController := controllers.APIKeyController{Service: &apiKeyService}
+
+	// sign in with an openid connect provider when OIDC_ISSUER_URL is set
+	var oidcController *controllers.OIDCController
+	if issuerURL := cfg.OIDC.IssuerURL; issuerURL != "" {
+		OIDCProvider, err := infrastructure.NewOIDCProvider(issuerURL, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, cfg.OIDC.RedirectURL)
+		if err != nil {
+			fatal("could not set up the oidc provider", err)
+		}
+		oidcService := usecases.OIDCService{Provider: OIDCProvider, UserRepo: UserRepository, JwtService: JwtService, TokenGenerator: TokenGenerator, AutoProvision: cfg.OIDC.AutoProvision, LinkByEmail: cfg.OIDC.LinkByEmail, RequireAdminTwoFactor: requireAdminTwoFactor, RequireEmailVerification: cfg.Email.RequireVerification, Audit: AuditRepository, Logger: logger}
+		oidcController = &controllers.OIDCController{Service: &oidcService, SecureCookies: strings.HasPrefix(cfg.OIDC.RedirectURL, "https://")}
+	}
+
+	healthController := controllers.HealthController{
+		Checks: []controllers.HealthCheck{
+			{Name: "mongo", Check: func(ctx context.Context) error { return client.Ping(ctx, nil) }},
+			{Name: "indexes", Check: repositories.CheckIndexes},
+			{Name: "workers", Check: workers.Check},
+		},
+		Timeout: cfg.Server.ReadinessTimeout,
+		Logger:  logger,
+	}
+
+	r := router.SetupRouter(router.Dependencies{
+		JwtService:           metrics.CountTokenFailures(JwtService),
+		APIKeyService:        &apiKeyService,
+		Accounts:             &userService,
+		Validator:            infrastructure.NewValidator(),
+		TaskController:       &taskController,
+		UserController:       &userController,
+		APIKeyController:     &apiKeyController,
+		InvitationController: &invitationController,
+		AuditController:      &auditController,
+		IdempotencyService:   IdempotencyService,
+		OIDCController:       oidcController,
+		HealthController:     &healthController,
+		MetricsHandler:       metrics.Handler(),
+		Logger:               logger,
+		TrustedProxies:       cfg.Server.TrustedProxies,
+		Middlewares:          []gin.HandlerFunc{otelgin.Middleware(cfg.Tracing.ServiceName), infrastructure.AccessLogMiddleware(logger), metrics.Middleware()},
+	})
+
+	serverConfig := infrastructure.ServerConfig{
+		Addr:              cfg.Server.Addr(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout,
+		Logger:            logger,
+	}
+	listener, err := net.Listen("tcp", serverConfig.Addr)
+	if err != nil {
+		fatal("could not listen", err)
+	}
+	logger.Info("listening", "addr", listener.Addr().String())
+	if err := infrastructure.Serve(ctx, infrastructure.NewServer(r, serverConfig), listener, serverConfig.ShutdownTimeout); err != nil {
+		logger.Error("server stopped", "error", err)
+	}
+	stop()
+	logger.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := workers.Wait(shutdownCtx); err != nil {
+		logger.Error("background jobs did not stop", "error", err)
+	}
+	if err := client.Disconnect(shutdownCtx); err != nil {
+		logger.Error("could not disconnect from mongo", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("could not flush traces", "error", err)
+	}
+}
+
+// new passwords are hashed with PASSWORD_HASHER ("bcrypt" by default or "argon2id"),
+// hashes of the other algorithm are still accepted and upgraded on login
+func newPasswordService(cfg config.Password) *infrastructure.PasswordService {
+	bcryptHasher := &infrastructure.BcryptHasher{Cost: cfg.BcryptCost}
+	argon2idHasher := &infrastructure.Argon2idHasher{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+	}
+
+	switch cfg.Hasher {
+	case "bcrypt":
+		return &infrastructure.PasswordService{Hasher: bcryptHasher, Legacy: []infrastructure.PasswordHasher{argon2idHasher}}
+	case "argon2id":
+		return &infrastructure.PasswordService{Hasher: argon2idHasher, Legacy: []infrastructure.PasswordHasher{bcryptHasher}}
+	default:
+		log.Fatalf("unknown PASSWORD_HASHER %q", cfg.Hasher)
+		return nil
+	}
+}
+
+// emails go through SMTP_HOST when set, otherwise they are only logged
+func newMailer(cfg config.SMTP) usecases.MailerInterface {
+	if cfg.Host == "" {
+		return &infrastructure.LogMailer{}
+	}
+	return &infrastructure.SMTPMailer{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		From:     cfg.From,
+	}
 }
@@ -1,35 +1,129 @@
 package router
 
 import (
-	"log"
-	"os"
+	"fmt"
+	"log/slog"
+	"net/http"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/gin-gonic/gin/binding"
 )
 
-func SetupRouter(taskController *controllers.TaskController, userController *controllers.UserController) *gin.Engine {
-    router := gin.Default()
-    err := godotenv.Load("../.env")
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+// Dependencies is everything the router is built from, nothing is read from the environment
+type Dependencies struct {
+	JwtService usecases.JwtServiceInterface
+	// nil to accept JWTs only
+	APIKeyService usecases.APIKeyServiceInterface
+	// nil to accept every valid JWT, otherwise asked whether its user may still use the api
+	Accounts usecases.AccountCheckerInterface
+	// nil to ignore Idempotency-Key headers
+	IdempotencyService usecases.IdempotencyServiceInterface
+	// binds requests and translates validation errors, a new one is used when nil
+	Validator *infrastructure.Validator
+
+	TaskController       *controllers.TaskController
+	UserController       *controllers.UserController
+	APIKeyController     *controllers.APIKeyController
+	InvitationController *controllers.InvitationController
+	AuditController      *controllers.AuditController
+	// nil when no OIDC provider is configured, the oidc routes are left out
+	OIDCController   *controllers.OIDCController
+	HealthController *controllers.HealthController
+	// serves /metrics, the route is left out when nil
+	MetricsHandler http.Handler
+
+	// where internal errors are logged, slog.Default() when nil
+	Logger *slog.Logger
+
+	// the proxies whose X-Forwarded-For headers give the client IP, IPs or CIDRs.
+	// None are trusted when empty, otherwise any client could pick its IP and
+	// get around the per-IP login throttling
+	TrustedProxies []string
+
+	// run around every request after its id is set and before the error handling,
+	// e.g. infrastructure.AccessLogMiddleware
+	Middlewares []gin.HandlerFunc
+}
+
+func SetupRouter(deps Dependencies) *gin.Engine {
+	router := gin.New()
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("invalid trusted proxies: %v", err))
+	}
+	// requests are bound with the shared validator so its rules and translations apply everywhere
+	validator := deps.Validator
+	if validator == nil {
+		validator = infrastructure.NewValidator()
+	}
+	binding.Validator = validator
+	// the request id comes first so every log line of the request has it
+	router.Use(infrastructure.RequestIDMiddleware())
+	router.Use(deps.Middlewares...)
+	// errors reported by middlewares and handlers, and panics, become problem+json responses
+	router.Use(infrastructure.ErrorMiddleware(validator, deps.Logger), gin.CustomRecovery(infrastructure.RecoveryHandler))
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(domain.ErrRouteNotFound)
+	})
+
+	// probes for the orchestrator, they need no credentials
+	router.GET("/healthz", deps.HealthController.Live)
+	router.GET("/readyz", deps.HealthController.Ready)
+	if deps.MetricsHandler != nil {
+		router.GET("/metrics", gin.WrapH(deps.MetricsHandler))
 	}
-	// get jwt secret from env
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-	var jwtservice usecases.JwtServiceInterface = &infrastructure.JwtService{JwtSecret: jwtSecret}
 
-    router.GET("/tasks", infrastructure.AuthMiddleware(jwtservice, false), taskController.GetTasks)
-    router.GET("/tasks/:id", infrastructure.AuthMiddleware(jwtservice, false), taskController.GetTaskById)
-    router.PUT("/tasks/:id", infrastructure.AuthMiddleware(jwtservice, true), taskController.UpdateTaskByID)
-    router.DELETE("/tasks/:id", infrastructure.AuthMiddleware(jwtservice, true), taskController.DeleteTask)
-    router.POST("/tasks", infrastructure.AuthMiddleware(jwtservice, true), taskController.AddTask)
+	authenticated := infrastructure.AuthMiddleware(deps.JwtService, deps.APIKeyService, deps.Accounts, false)
+	admin := infrastructure.AuthMiddleware(deps.JwtService, deps.APIKeyService, deps.Accounts, true)
+
+	taskController := deps.TaskController
+	router.GET("/tasks", authenticated, taskController.GetTasks)
+	router.GET("/tasks/trash", admin, taskController.GetDeletedTasks)
+	router.GET("/tasks/:id", authenticated, taskController.GetTaskById)
+	router.GET("/tasks/:id/history", authenticated, taskController.GetTaskHistory)
+	router.PUT("/tasks/:id", admin, taskController.UpdateTaskByID)
+	router.DELETE("/tasks/:id", admin, taskController.DeleteTask)
+	router.POST("/tasks/:id/restore", admin, taskController.RestoreTask)
+	// retried task creations with an Idempotency-Key don't create duplicates
+	idempotent := func(c *gin.Context) { c.Next() }
+	if deps.IdempotencyService != nil {
+		idempotent = infrastructure.IdempotencyMiddleware(deps.IdempotencyService, deps.Logger)
+	}
+	router.POST("/tasks", admin, idempotent, taskController.AddTask)
+	router.POST("/tasks/bulk", admin, taskController.ApplyBulkOperations)
 
+	userController := deps.UserController
 	router.POST("/register", userController.RegisterUser)
 	router.POST("/login", userController.Login)
-    router.PATCH("/promote", infrastructure.AuthMiddleware(jwtservice, true), userController.PromoteUser)
+	router.GET("/verify-email", userController.VerifyEmail)
+	router.POST("/verify-email/resend", userController.ResendEmailVerification)
+	router.POST("/login/2fa", userController.LoginTwoFactor)
+	if deps.OIDCController != nil {
+		router.GET("/oidc/login", deps.OIDCController.Login)
+		router.GET("/oidc/callback", deps.OIDCController.Callback)
+	}
+	router.POST("/2fa/enroll", authenticated, userController.EnrollTwoFactor)
+	router.POST("/2fa/verify", authenticated, userController.ConfirmTwoFactor)
+	router.GET("/me", authenticated, userController.GetProfile)
+	router.PATCH("/me", authenticated, userController.UpdateProfile)
+	router.PATCH("/promote", admin, userController.PromoteUser)
+	router.PATCH("/unlock", admin, userController.UnlockUser)
+
+	invitationController := deps.InvitationController
+	router.POST("/invitations", admin, invitationController.CreateInvitation)
+	router.GET("/invitations", admin, invitationController.GetInvitations)
+	router.DELETE("/invitations/:id", admin, invitationController.DeleteInvitation)
+
+	// the audit log can only be read, entries are added by the services
+	router.GET("/audit", admin, deps.AuditController.GetEntries)
+
+	apiKeyController := deps.APIKeyController
+	router.POST("/api-keys", authenticated, apiKeyController.CreateAPIKey)
+	router.GET("/api-keys", authenticated, apiKeyController.GetAPIKeys)
+	router.DELETE("/api-keys/:id", authenticated, apiKeyController.RevokeAPIKey)
 
-    return router
-}
\ No newline at end of file
+	return router
+}
@@ -1,23 +1,359 @@
 package domain
 
 import (
+	"strings"
 	"time"
+
 	"github.com/google/uuid"
 )
 
 type Task struct {
-	ID          uuid.UUID   `bson:"_id" json:"id"`
-	Title       string    	`bson:"title" json:"title" binding:"required"`
-	Description string    	`bson:"description" json:"description" binding:"required"`
-	DueDate     time.Time 	`bson:"due_date" json:"due_date" binding:"required"`
-	Status      string    	`bson:"status" json:"status"`
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	Title       string    `bson:"title" json:"title" binding:"required,max=200"`
+	Description string    `bson:"description" json:"description" binding:"required"`
+	DueDate     time.Time `bson:"due_date" json:"due_date" binding:"required"`
+	Status      string    `bson:"status" json:"status" binding:"required,task_status"`
+	// set while the task is in the trash
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
+
+// kinds of entries in the history of a task
+const (
+	TaskCreated  = "created"
+	TaskUpdated  = "updated"
+	TaskDeleted  = "deleted"
+	TaskRestored = "restored"
+)
+
+// A change to one field of a task, values are in their JSON form
+type TaskFieldChange struct {
+	Field    string `json:"field" bson:"field"`
+	OldValue string `json:"old_value" bson:"old_value"`
+	NewValue string `json:"new_value" bson:"new_value"`
+}
+
+// An entry in the history of a task, who changed which fields when
+type TaskHistoryEntry struct {
+	ID      uuid.UUID         `json:"id" bson:"_id"`
+	TaskID  uuid.UUID         `json:"task_id" bson:"task_id"`
+	Time    time.Time         `json:"time" bson:"time"`
+	Actor   string            `json:"actor" bson:"actor"`
+	Action  string            `json:"action" bson:"action"`
+	Changes []TaskFieldChange `json:"changes" bson:"changes"`
+}
+
+// Changes lists the fields that differ between t and updated, a new task is
+// compared against the zero Task
+func (t Task) Changes(updated Task) []TaskFieldChange {
+	changes := make([]TaskFieldChange, 0)
+	add := func(field string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, TaskFieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	formatDate := func(date time.Time) string {
+		if date.IsZero() {
+			return ""
+		}
+		return date.UTC().Format(time.RFC3339)
+	}
+
+	add("title", t.Title, updated.Title)
+	add("description", t.Description, updated.Description)
+	add("due_date", formatDate(t.DueDate), formatDate(updated.DueDate))
+	add("status", t.Status, updated.Status)
+	add("deleted_at", formatDate(t.deletedAt()), formatDate(updated.deletedAt()))
+	add("deleted_by", t.DeletedBy, updated.DeletedBy)
+	return changes
+}
+
+func (t Task) deletedAt() time.Time {
+	if t.DeletedAt == nil {
+		return time.Time{}
+	}
+	return *t.DeletedAt
+}
+
+// validation rules of tasks that aren't built into the validator
+const (
+	RuleTaskStatus = "task_status"
+	RuleNotPast    = "notpast"
+)
+
+// IsTaskStatus reports whether status is pending, in progress or completed, in any case
+func IsTaskStatus(status string) bool {
+	switch strings.ToLower(status) {
+	case "pending", "in progress", "completed":
+		return true
+	}
+	return false
+}
+
+// operations of a bulk request
+const (
+	BulkCreate    = "create"
+	BulkUpdate    = "update"
+	BulkDelete    = "delete"
+	BulkSetStatus = "set_status"
+)
+
+// One operation of a bulk request. Task is the new task for create and update,
+// Status the new status for set_status, ID the task changed by all but create
+type BulkTaskOperation struct {
+	Op     string    `json:"op" binding:"required,oneof=create update delete set_status"`
+	ID     uuid.UUID `json:"id" binding:"required_unless=Op create"`
+	Task   *Task     `json:"task" binding:"required_if=Op create,required_if=Op update"`
+	Status string    `json:"status" binding:"required_if=Op set_status"`
+}
+
+// A batch of task changes, with Atomic either all of them are applied or none
+type BulkTaskRequest struct {
+	Atomic     bool                `json:"atomic"`
+	Operations []BulkTaskOperation `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+// outcomes of an operation of a bulk request
+const (
+	BulkApplied = "applied"
+	BulkFailed  = "failed"
+	// the operation was fine but another one of an atomic batch failed
+	BulkSkipped = "skipped"
+)
+
+// The outcome of the operation at Index, ID is the id of the task, new tasks included
+type BulkTaskResult struct {
+	Index         int            `json:"index"`
+	Op            string         `json:"op"`
+	ID            uuid.UUID      `json:"id"`
+	Status        string         `json:"status"`
+	Error         string         `json:"error,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Atomic  bool             `json:"atomic"`
+	Applied int              `json:"applied"`
+	Failed  int              `json:"failed"`
+	Results []BulkTaskResult `json:"results"`
+}
+
+// A write of a bulk request, Task is the task as it should be afterwards
+type TaskWrite struct {
+	Op   string
+	Task Task
+}
+
+// The first response to a request with an idempotency key, replayed for retries
+// of the request until ExpiresAt
+type IdempotencyRecord struct {
+	ID       uuid.UUID `bson:"_id"`
+	Username string    `bson:"username"`
+	Key      string    `bson:"key"`
+	// hash of the method, path and body of the request
+	RequestHash string `bson:"request_hash"`
+	// zero while the first request is still being handled
+	Status      int       `bson:"status"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+
+	// other response headers that are replayed, like Location
+	Headers map[string]string `bson:"headers,omitempty"`
 }
 
 // A user struct with id, username and password with json and bson tags
 type User struct {
-	ID       uuid.UUID 	`json:"id" bson:"_id"`
-	Username string     `json:"username" bson:"username" binding:"required"`
-	Password string     `json:"password" bson:"password" binding:"required"`
-	IsAdmin  bool       `json:"is_admin" bson:"is_admin"`
+	ID                       uuid.UUID `json:"id" bson:"_id"`
+	Username                 string    `json:"username" bson:"username" binding:"required"`
+	Password                 string    `json:"password" bson:"password" binding:"required"`
+	IsAdmin                  bool      `json:"is_admin" bson:"is_admin"`
+	TwoFactorEnabled         bool      `json:"two_factor_enabled" bson:"two_factor_enabled"`
+	TOTPSecret               string    `json:"-" bson:"totp_secret,omitempty"`
+	RecoveryCodes            []string  `json:"-" bson:"recovery_codes,omitempty"`    // bcrypt hashes of the unused recovery codes
+	TOTPLastCounter          int64     `json:"-" bson:"totp_last_counter,omitempty"` // time step of the last accepted totp code, codes can't be used twice
+	OIDCIssuer               string    `json:"-" bson:"oidc_issuer,omitempty"`
+	OIDCSubject              string    `json:"-" bson:"oidc_subject,omitempty"` // users signing in with OIDC are linked by issuer and subject
+	Email                    string    `json:"email,omitempty" bson:"email,omitempty" binding:"omitempty,email"`
+	EmailVerified            bool      `json:"email_verified" bson:"email_verified"`
+	EmailVerificationHash    string    `json:"-" bson:"email_verification_hash,omitempty"` // hash of the pending email verification token
+	EmailVerificationExpires time.Time `json:"-" bson:"email_verification_expires,omitempty"`
+	PendingEmail             string    `json:"-" bson:"pending_email,omitempty"` // a new email waiting for verification, Email stays in use until then
+	DisplayName              string    `json:"display_name,omitempty" bson:"display_name,omitempty" binding:"omitempty,max=100"`
+	Timezone                 string    `json:"timezone,omitempty" bson:"timezone,omitempty" binding:"omitempty,timezone"`       // IANA name, e.g. Europe/Berlin
+	Locale                   string    `json:"locale,omitempty" bson:"locale,omitempty" binding:"omitempty,bcp47_language_tag"` // BCP 47 tag, e.g. en-US
+	AvatarURL                string    `json:"avatar_url,omitempty" bson:"avatar_url,omitempty" binding:"omitempty,http_url,max=2048"`
+}
+
+// The public profile of a user, without credentials or security settings
+type UserProfile struct {
+	Username      string `json:"username"`
+	DisplayName   string `json:"display_name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	PendingEmail  string `json:"pending_email,omitempty"`
+	Timezone      string `json:"timezone"`
+	Locale        string `json:"locale"`
+	AvatarURL     string `json:"avatar_url"`
+}
+
+func (u *User) Profile() *UserProfile {
+	return &UserProfile{
+		Username:      u.Username,
+		DisplayName:   u.DisplayName,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		PendingEmail:  u.PendingEmail,
+		Timezone:      u.Timezone,
+		Locale:        u.Locale,
+		AvatarURL:     u.AvatarURL,
+	}
+}
+
+// Changes to a profile, nil fields are left unchanged and empty strings clear a field
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Email       *string `json:"email" binding:"omitempty,eq=|email"`
+	Timezone    *string `json:"timezone" binding:"omitempty,eq=|timezone"`
+	Locale      *string `json:"locale" binding:"omitempty,eq=|bcp47_language_tag"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,eq=|http_url,max=2048"`
+}
+
+// Result of the password step of a login
+type LoginResult struct {
+	Token string `json:"token,omitempty"`
+	// set instead of Token when the user has two-factor authentication enabled
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	// set when admin rights were withheld because the admin has not enrolled in two-factor authentication
+	TwoFactorEnrollmentRequired bool `json:"two_factor_enrollment_required,omitempty"`
+}
+
+// Start of an OpenID Connect login, State and CodeVerifier must be kept until the callback
+type OIDCAuthRequest struct {
+	URL          string
+	State        string
+	CodeVerifier string
+}
+
+// Identity of a user verified by an OpenID Connect provider
+type OIDCIdentity struct {
+	Issuer            string
+	Subject           string
+	PreferredUsername string
+	Email             string
+	EmailVerified     bool
+}
+
+// Second step of a login for users with two-factor authentication
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// a TOTP code or one of the recovery codes
+	Code string `json:"code" binding:"required"`
+}
+
+// Secret handed to an authenticator app when enrolling in two-factor authentication
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// A login attempt record tracking failed logins for a username or client ip
+type LoginAttempt struct {
+	Key         string    `json:"key" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LastFailure time.Time `json:"last_failure" bson:"last_failure"`
+	LockedUntil time.Time `json:"locked_until" bson:"locked_until"`
+}
+
+// scopes that can be granted to an api key
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+	// needed on top of read or write for admin-only routes, and only works for admins
+	APIKeyScopeAdmin = "admin"
+)
+
+// A personal api key for scripts, only a hash of the key itself is stored
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" bson:"_id"`
+	Username   string     `json:"username" bson:"username"`
+	Name       string     `json:"name" bson:"name" binding:"required"`
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	Scopes     []string   `json:"scopes" bson:"scopes" binding:"required,min=1,dive,oneof=read write admin"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// An invitation to register, created by an admin and usable once before it expires.
+// Only a hash of the invitation token is stored.
+type Invitation struct {
+	ID        uuid.UUID  `json:"id" bson:"_id"`
+	TokenHash string     `json:"-" bson:"token_hash"`
+	Email     string     `json:"email,omitempty" bson:"email,omitempty" binding:"omitempty,email"` // when set the invited user must register with this email
+	Username  string     `json:"username,omitempty" bson:"username,omitempty"`                     // when set the invited user must register with this username
+	IsAdmin   bool       `json:"is_admin" bson:"is_admin"`
+	CreatedBy string     `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	UsedBy    string     `json:"used_by,omitempty" bson:"used_by,omitempty"`
+}
+
+// actions recorded in the audit log
+const (
+	AuditLoginSucceeded    = "login.succeeded"
+	AuditLoginFailed       = "login.failed"
+	AuditUserRegistered    = "user.registered"
+	AuditUserPromoted      = "user.promoted"
+	AuditUserUnlocked      = "user.unlocked"
+	AuditOIDCLinked        = "user.oidc_linked"
+	AuditAPIKeyCreated     = "api_key.created"
+	AuditAPIKeyRevoked     = "api_key.revoked"
+	AuditInvitationCreated = "invitation.created"
+	AuditTaskCreated       = "task.created"
+	AuditTaskUpdated       = "task.updated"
+	AuditTaskDeleted       = "task.deleted"
+	AuditTaskRestored      = "task.restored"
+	AuditTaskPurged        = "task.purged"
+)
+
+// An entry of the audit log, entries are only ever added
+type AuditEntry struct {
+	ID   uuid.UUID `json:"id" bson:"_id"`
+	Time time.Time `json:"time" bson:"time"`
+	// username of who did it, for failed logins the username that was tried
+	Actor  string `json:"actor" bson:"actor"`
+	Action string `json:"action" bson:"action"`
+	// what it was done to, a username or task id
+	Target   string `json:"target,omitempty" bson:"target,omitempty"`
+	ClientIP string `json:"client_ip,omitempty" bson:"client_ip,omitempty"`
+	// e.g. why a login failed
+	Detail string `json:"detail,omitempty" bson:"detail,omitempty"`
+	// snapshots of a task before and after the change
+	Before *Task `json:"before,omitempty" bson:"before,omitempty"`
+	After  *Task `json:"after,omitempty" bson:"after,omitempty"`
 }
 
+// Filters of the audit log, empty fields match every entry
+type AuditFilter struct {
+	Actor  string    `json:"actor" form:"actor"`
+	Action string    `json:"action" form:"action"`
+	Target string    `json:"target" form:"target"`
+	Since  time.Time `json:"since" form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until  time.Time `json:"until" form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	// newest entries returned, 100 when zero
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=1,max=1000"`
+}
@@ -0,0 +1,135 @@
+package domain
+
+import "errors"
+
+// ErrorKind tells the delivery layer how to report an error
+type ErrorKind int
+
+const (
+	// unexpected failures, the default for errors that aren't domain errors
+	KindInternal ErrorKind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	// well formed but can't be processed, e.g. an idempotency key reused for another request
+	KindUnprocessable
+)
+
+// Error is an expected failure with a message that is safe to show to clients,
+// wrap it with %w to add context for logs
+type Error struct {
+	Kind    ErrorKind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(kind ErrorKind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// KindOf returns the kind of the first domain error in the chain of err
+func KindOf(err error) ErrorKind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return KindInternal
+}
+
+// ValidationError is a rejected request, with the reason for each invalid field.
+// Cause keeps the error of the validator so its fields can be described in the
+// client's language
+type ValidationError struct {
+	Detail string
+	Params []InvalidParam
+	Cause  error
+}
+
+// InvalidParam is a request field that failed validation, Rule names the failed
+// rule so the reason can be translated
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Rule   string `json:"-"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Detail == "" {
+		return ErrInvalidRequest.Message
+	}
+	return e.Detail
+}
+
+// validation errors are invalid requests for errors.Is and KindOf
+func (e *ValidationError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{ErrInvalidRequest}
+	}
+	return []error{ErrInvalidRequest, e.Cause}
+}
+
+// requests
+var (
+	ErrInvalidRequest = newError(KindInvalid, "invalid request")
+	ErrRouteNotFound  = newError(KindNotFound, "route not found")
+)
+
+// tasks
+var (
+	ErrTaskNotFound      = newError(KindNotFound, "task not found")
+	ErrInvalidTaskStatus = newError(KindInvalid, "status must be pending, in progress or completed")
+	ErrTaskInBatchTwice  = newError(KindInvalid, "task is changed by another operation of the batch")
+)
+
+// idempotency keys
+var (
+	ErrIdempotencyKeyReused     = newError(KindUnprocessable, "idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = newError(KindConflict, "a request with this idempotency key is still being processed")
+)
+
+// users and registration
+var (
+	ErrUserNotFound              = newError(KindNotFound, "user not found")
+	ErrUsernameExists            = newError(KindConflict, "username already exists")
+	ErrEmailExists               = newError(KindConflict, "email already exists")
+	ErrEmailRequired             = newError(KindInvalid, "email is required")
+	ErrRegistrationClosed        = newError(KindForbidden, "registration is closed")
+	ErrInvitationRequired        = newError(KindForbidden, "invitation required")
+	ErrInvalidInvitation         = newError(KindForbidden, "invalid invitation")
+	ErrInvitationForOtherUser    = newError(KindForbidden, "invitation is for another username")
+	ErrInvitationForOtherEmail   = newError(KindForbidden, "invitation is for another email")
+	ErrInvitationNotFound        = newError(KindNotFound, "invitation not found")
+	ErrExpiryInPast              = newError(KindInvalid, "expiry must be in the future")
+	ErrInvalidVerificationToken  = newError(KindInvalid, "invalid verification token")
+	ErrEmailVerificationDisabled = newError(KindNotFound, "email verification is disabled")
+)
+
+// authentication
+var (
+	ErrAuthorizationRequired   = newError(KindUnauthorized, "authorization header is required")
+	ErrInvalidAuthHeader       = newError(KindUnauthorized, "invalid authorization header")
+	ErrAdminRequired           = newError(KindForbidden, "admin rights required")
+	ErrAPIKeysNotAccepted      = newError(KindUnauthorized, "API keys are not accepted")
+	ErrAPIKeyNotAllowed        = newError(KindForbidden, "not allowed with an API key")
+	ErrInvalidCredentials      = newError(KindUnauthorized, "invalid credentials")
+	ErrPasswordLoginDisabled   = newError(KindForbidden, "password login is disabled")
+	ErrEmailNotVerified        = newError(KindForbidden, "email not verified")
+	ErrInvalidJWT              = newError(KindUnauthorized, "invalid JWT")
+	ErrInvalidChallengeToken   = newError(KindUnauthorized, "invalid challenge token")
+	ErrInvalidTwoFactorCode    = newError(KindUnauthorized, "invalid two-factor code")
+	ErrTwoFactorNotEnabled     = newError(KindInvalid, "two-factor authentication is not enabled")
+	ErrTwoFactorEnabled        = newError(KindConflict, "two-factor authentication is already enabled")
+	ErrTwoFactorNotStarted     = newError(KindConflict, "two-factor enrollment not started")
+	ErrAPIKeyNotFound          = newError(KindNotFound, "api key not found")
+	ErrInvalidAPIKey           = newError(KindUnauthorized, "invalid API key")
+	ErrInvalidOIDCState        = newError(KindInvalid, "invalid state")
+	ErrInvalidAuthCode         = newError(KindUnauthorized, "invalid authorization code")
+	ErrNoLinkedUser            = newError(KindForbidden, "no user is linked to this identity")
+	ErrOIDCIdentityLinked      = newError(KindConflict, "oidc identity already linked")
+	ErrIdentityWithoutUsername = newError(KindInvalid, "identity provider did not return a username")
+)
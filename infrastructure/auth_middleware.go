@@ -5,22 +5,37 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/dgrijalva/jwt-go"
 )
 
-func AuthMiddleware(jwtservice usecases.JwtServiceInterface,adminCheck bool) gin.HandlerFunc {
+// AuthMiddleware accepts a Bearer JWT, or an API key given as "Authorization: ApiKey <key>"
+// or in the X-API-Key header. apiKeyService may be nil to accept JWTs only. accounts may be
+// nil, otherwise it is asked whether the user of a valid JWT may still use the api.
+func AuthMiddleware(jwtservice usecases.JwtServiceInterface, apiKeyService usecases.APIKeyServiceInterface, accounts usecases.AccountCheckerInterface, adminCheck bool) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+		apiKey := c.GetHeader("X-API-Key")
+		if authHeader == "" && apiKey == "" {
+			c.Error(domain.ErrAuthorizationRequired)
 			c.Abort()
 			return
 		}
 
 		authParts := strings.Split(authHeader, " ")
+		if apiKey == "" && len(authParts) == 2 && strings.ToLower(authParts[0]) == "apikey" {
+			apiKey = authParts[1]
+		}
+
+		if apiKey != "" {
+			apiKeyAuth(c, apiKeyService, apiKey, adminCheck)
+			return
+		}
+
 		if len(authParts) != 2 || strings.ToLower(authParts[0]) != "bearer" {
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			c.Error(domain.ErrInvalidAuthHeader)
 			c.Abort()
 			return
 		}
@@ -28,7 +43,7 @@ func AuthMiddleware(jwtservice usecases.JwtServiceInterface,adminCheck bool) gin
 		token, err := jwtservice.ValidateToken(authParts[1])
 
 		if err != nil {
-			c.IndentedJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Error(err)
 			c.Abort()
 			return
 		}
@@ -36,7 +51,25 @@ func AuthMiddleware(jwtservice usecases.JwtServiceInterface,adminCheck bool) gin
 		// if adminCheck is true check for is_admin in the token by decoding
 		if adminCheck {
 			if !jwtservice.ValidateAdmin(token) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+				c.Error(domain.ErrAdminRequired)
+				c.Abort()
+				return
+			}
+		}
+
+		// expose the caller to the handlers
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if username, ok := claims["username"].(string); ok {
+				c.Set("username", username)
+			}
+			if isAdmin, ok := claims["is_admin"].(bool); ok {
+				c.Set("is_admin", isAdmin)
+			}
+		}
+
+		if accounts != nil {
+			if err := accounts.CheckAccount(c.Request.Context(), c.GetString("username")); err != nil {
+				c.Error(err)
 				c.Abort()
 				return
 			}
@@ -44,3 +77,50 @@ func AuthMiddleware(jwtservice usecases.JwtServiceInterface,adminCheck bool) gin
 		c.Next()
 	}
 }
+
+// authenticate with an api key, reads need the read scope and everything else the write scope.
+// Admin-only routes also need the admin scope so a leaked write key of an admin can't manage users
+func apiKeyAuth(c *gin.Context, apiKeyService usecases.APIKeyServiceInterface, key string, adminCheck bool) {
+	if apiKeyService == nil {
+		c.Error(domain.ErrAPIKeysNotAccepted)
+		c.Abort()
+		return
+	}
+
+	apiKey, user, err := apiKeyService.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+
+	scope := domain.APIKeyScopeWrite
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		scope = domain.APIKeyScopeRead
+	}
+	if !apiKey.HasScope(scope) {
+		c.Error(missingScopeError(scope))
+		c.Abort()
+		return
+	}
+
+	if adminCheck && !user.IsAdmin {
+		c.Error(domain.ErrAdminRequired)
+		c.Abort()
+		return
+	}
+	if adminCheck && !apiKey.HasScope(domain.APIKeyScopeAdmin) {
+		c.Error(missingScopeError(domain.APIKeyScopeAdmin))
+		c.Abort()
+		return
+	}
+
+	c.Set("username", user.Username)
+	c.Set("is_admin", user.IsAdmin)
+	c.Set("api_key_id", apiKey.ID)
+	c.Next()
+}
+
+func missingScopeError(scope string) error {
+	return &domain.Error{Kind: domain.KindForbidden, Message: "API key is missing the " + scope + " scope"}
+}
@@ -0,0 +1,118 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error response
+type Problem struct {
+	Type          string                `json:"type"`
+	Title         string                `json:"title"`
+	Status        int                   `json:"status"`
+	Detail        string                `json:"detail,omitempty"`
+	Instance      string                `json:"instance,omitempty"`
+	RequestID     string                `json:"request_id,omitempty"`
+	InvalidParams []domain.InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// HTTP status of each kind of domain error
+var errorStatuses = map[domain.ErrorKind]int{
+	domain.KindInvalid:       http.StatusBadRequest,
+	domain.KindUnauthorized:  http.StatusUnauthorized,
+	domain.KindForbidden:     http.StatusForbidden,
+	domain.KindNotFound:      http.StatusNotFound,
+	domain.KindConflict:      http.StatusConflict,
+	domain.KindUnprocessable: http.StatusUnprocessableEntity,
+}
+
+// ErrorStatus returns the HTTP status for an error returned by a service
+func ErrorStatus(err error) int {
+	var lockoutErr *usecases.TooManyAttemptsError
+	if errors.As(err, &lockoutErr) {
+		return http.StatusTooManyRequests
+	}
+	if status, ok := errorStatuses[domain.KindOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// NewProblem describes err for the client, domain errors only show their own message
+// so the context they were wrapped with stays out of responses, internal errors show nothing
+func NewProblem(err error, instance string, requestID string) Problem {
+	status := ErrorStatus(err)
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  instance,
+		RequestID: requestID,
+	}
+
+	var validationErr *domain.ValidationError
+	var domainErr *domain.Error
+	var lockoutErr *usecases.TooManyAttemptsError
+	switch {
+	case errors.As(err, &validationErr):
+		problem.Detail = validationErr.Error()
+		problem.InvalidParams = validationErr.Params
+	case errors.As(err, &domainErr):
+		problem.Detail = domainErr.Message
+	case errors.As(err, &lockoutErr):
+		problem.Detail = lockoutErr.Error()
+	}
+	return problem
+}
+
+// ErrorMiddleware turns the last error reported with c.Error into a problem+json
+// response, unless the handler already wrote one. Invalid fields are described by
+// validator in the language of the Accept-Language header. Internal errors are
+// logged to logger, slog.Default() when nil
+func ErrorMiddleware(validator *Validator, logger *slog.Logger) gin.HandlerFunc {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		problem := NewProblem(err, c.Request.URL.Path, c.GetString("request_id"))
+		var validationErr *domain.ValidationError
+		if validator != nil && errors.As(err, &validationErr) {
+			problem.InvalidParams = validator.InvalidParams(validationErr, c.GetHeader("Accept-Language"))
+		}
+		if problem.Status == http.StatusInternalServerError {
+			logger.ErrorContext(c.Request.Context(), "request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+		}
+
+		var lockoutErr *usecases.TooManyAttemptsError
+		if errors.As(err, &lockoutErr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+		}
+
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// RecoveryHandler reports panics as internal errors, use it with gin.CustomRecovery
+// after ErrorMiddleware so panics get a problem response too
+func RecoveryHandler(c *gin.Context, recovered any) {
+	c.Error(fmt.Errorf("panic: %v", recovered))
+	c.Abort()
+}
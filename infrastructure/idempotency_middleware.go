@@ -0,0 +1,146 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// set on responses replayed for a retry
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+var validIdempotencyKey = regexp.MustCompile(`^[\x21-\x7e]{1,255}$`)
+
+// response headers besides Content-Type that are stored and replayed with the body
+var replayedHeaders = []string{"Location"}
+
+// IdempotencyMiddleware makes retries of a request with an Idempotency-Key header
+// safe. The first response for a key of the caller is stored and replayed for
+// later requests with the key, a request with a key that was used for another
+// request is rejected. Requests that fail without a response of the handler, with
+// a server error or a panic, keep no response so they can be retried. Use it after
+// AuthMiddleware, keys are per user. Responses that can't be stored or released
+// are logged to logger, slog.Default() when nil
+func IdempotencyMiddleware(service usecases.IdempotencyServiceInterface, logger *slog.Logger) gin.HandlerFunc {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey.MatchString(key) {
+			c.Error(&domain.ValidationError{
+				Detail: "invalid idempotency key",
+				Params: []domain.InvalidParam{{Name: IdempotencyKeyHeader, Reason: "must be 1 to 255 visible ASCII characters"}},
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Error(&domain.ValidationError{Detail: "could not read the request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record, err := service.Begin(ctx, c.GetString("username"), key, requestHash(c.Request, body))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if record.Status != 0 {
+			for name, value := range record.Headers {
+				c.Header(name, value)
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.Status, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// the outcome is kept even when the client is gone, it will retry
+			ctx := context.WithoutCancel(ctx)
+			// a panicking handler leaves no response to keep, the recovery handler answers
+			if recovered := recover(); recovered != nil {
+				releaseIdempotencyKey(ctx, logger, service, record)
+				panic(recovered)
+			}
+			status := c.Writer.Status()
+			if len(c.Errors) > 0 || status >= http.StatusInternalServerError {
+				releaseIdempotencyKey(ctx, logger, service, record)
+				return
+			}
+			header := c.Writer.Header()
+			if err := service.Complete(ctx, record, status, header.Get("Content-Type"), storedHeaders(header), writer.body.Bytes()); err != nil {
+				logger.ErrorContext(ctx, "could not store idempotent response", "error", err)
+			}
+		}()
+		c.Next()
+	}
+}
+
+func releaseIdempotencyKey(ctx context.Context, logger *slog.Logger, service usecases.IdempotencyServiceInterface, record *domain.IdempotencyRecord) {
+	if err := service.Release(ctx, record); err != nil {
+		logger.ErrorContext(ctx, "could not release idempotency key", "error", err)
+	}
+}
+
+// the replayed headers the response has, nil when there are none
+func storedHeaders(header http.Header) map[string]string {
+	var headers map[string]string
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			if headers == nil {
+				headers = make(map[string]string, len(replayedHeaders))
+			}
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// a request is the same as the first one with its key when its method, path and body are
+func requestHash(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, request.Method+" "+request.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
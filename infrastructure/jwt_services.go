@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/dgrijalva/jwt-go"
 )
 
+// purpose claim of the short-lived token issued between the password and two-factor steps of a login
+const challengeTokenPurpose = "2fa_challenge"
+
 type JwtService struct {
 	JwtSecret []byte
 }
@@ -39,7 +43,12 @@ func (j *JwtService) ValidateToken(token string) (*jwt.Token, error) {
 	})
 
 	if err != nil || !jwtoken.Valid {
-		return nil, errors.New("invalid JWT")
+		return nil, domain.ErrInvalidJWT
+	}
+
+	// challenge tokens only prove the password step and must not grant access
+	if claims, ok := jwtoken.Claims.(jwt.MapClaims); ok && claims["purpose"] != nil {
+		return nil, domain.ErrInvalidJWT
 	}
 
 	return jwtoken, err
@@ -49,3 +58,47 @@ func (j *JwtService) ValidateAdmin(token *jwt.Token) bool {
 	claims, ok := token.Claims.(jwt.MapClaims)
 	return ok && claims["is_admin"].(bool)
 }
+
+// token proving the password step of a login, exchanged for a real token with a two-factor code
+func (j *JwtService) GenerateChallengeToken(username string) (string, error) {
+	expirationTime := time.Now().Add(5 * time.Minute).Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": username,
+		"purpose":  challengeTokenPurpose,
+		"exp":      expirationTime,
+	})
+	challengeToken, e := token.SignedString(j.JwtSecret)
+
+	if e != nil {
+		return "", errors.New("can't sign token")
+	}
+
+	return challengeToken, nil
+}
+
+// validate a challenge token and return the username it was issued for
+func (j *JwtService) ValidateChallengeToken(token string) (string, error) {
+	jwtoken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return j.JwtSecret, nil
+	})
+
+	if err != nil || !jwtoken.Valid {
+		return "", domain.ErrInvalidChallengeToken
+	}
+
+	claims, ok := jwtoken.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != challengeTokenPurpose {
+		return "", domain.ErrInvalidChallengeToken
+	}
+
+	username, ok := claims["username"].(string)
+	if !ok {
+		return "", domain.ErrInvalidChallengeToken
+	}
+
+	return username, nil
+}
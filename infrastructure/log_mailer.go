@@ -0,0 +1,21 @@
+package infrastructure
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogMailer writes emails to the log instead of sending them, for development without an SMTP server
+type LogMailer struct {
+	// slog.Default() when nil
+	Logger *slog.Logger
+}
+
+func (m *LogMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	logger := m.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "email", "to", to, "subject", subject, "body", body)
+	return nil
+}
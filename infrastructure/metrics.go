@@ -0,0 +1,113 @@
+package infrastructure
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are the prometheus metrics of the server, in a registry of their own
+// so tests can create as many as they like
+type Metrics struct {
+	registry             *prometheus.Registry
+	httpRequests         *prometheus.CounterVec
+	httpDuration         *prometheus.HistogramVec
+	logins               *prometheus.CounterVec
+	tokenFailures        prometheus.Counter
+	repositoryOperations *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_logins_total",
+			Help: "Password logins by result.",
+		}, []string{"result"}),
+		tokenFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "auth_token_validation_failures_total",
+			Help: "Bearer tokens rejected by the auth middleware.",
+		}),
+		repositoryOperations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Repository operation latency by repository and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"repository", "method"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.tokenFailures,
+		m.repositoryOperations,
+	)
+	return m
+}
+
+// Handler serves the metrics for /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and their latency per route, requests that match
+// no route share the route label "unmatched" to keep the number of series bounded
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := prometheus.Labels{"method": c.Request.Method, "route": route, "status": strconv.Itoa(c.Writer.Status())}
+		m.httpRequests.With(labels).Inc()
+		m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveLogin implements usecases.LoginMetricsInterface
+func (m *Metrics) ObserveLogin(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// ObserveOperation implements repositories.OperationObserver
+func (m *Metrics) ObserveOperation(repository string, method string, duration time.Duration) {
+	m.repositoryOperations.WithLabelValues(repository, method).Observe(duration.Seconds())
+}
+
+// CountTokenFailures wraps jwtService so the tokens it rejects are counted
+func (m *Metrics) CountTokenFailures(jwtService usecases.JwtServiceInterface) usecases.JwtServiceInterface {
+	return &countingJwtService{JwtServiceInterface: jwtService, failures: m.tokenFailures}
+}
+
+type countingJwtService struct {
+	usecases.JwtServiceInterface
+	failures prometheus.Counter
+}
+
+func (s *countingJwtService) ValidateToken(token string) (*jwt.Token, error) {
+	validated, err := s.JwtServiceInterface.ValidateToken(token)
+	if err != nil {
+		s.failures.Inc()
+	}
+	return validated, err
+}
@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider runs the authorization code flow with PKCE against an OpenID Connect provider
+type OIDCProvider struct {
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider fetches the provider's discovery document from issuerURL
+func NewOIDCProvider(issuerURL, clientID, clientSecret, redirectURL string) (*OIDCProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCProvider{
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+func (p *OIDCProvider) AuthCodeURL(state string, codeVerifier string) string {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier))
+}
+
+// exchange the code for tokens and return the identity from the verified id token
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*domain.OIDCIdentity, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("id token missing from token response")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		PreferredUsername string `json:"preferred_username"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &domain.OIDCIdentity{
+		Issuer:            idToken.Issuer,
+		Subject:           idToken.Subject,
+		PreferredUsername: claims.PreferredUsername,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+	}, nil
+}
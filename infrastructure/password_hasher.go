@@ -0,0 +1,148 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher is one password hashing algorithm. Hashes are self-describing
+// so a hasher can tell its own hashes and their parameters apart.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) bool
+	// true if the hash was made by this algorithm, with any parameters
+	Recognizes(hash string) bool
+	// true if the hash was made with parameters other than the current ones
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt, a zero Cost means bcrypt.DefaultCost
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func (h *BcryptHasher) Verify(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost()
+}
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idHasher hashes passwords with argon2id into PHC strings like
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	// memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2idHasher uses 64 MiB, 3 iterations and 2 lanes
+func DefaultArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 64 * 1024, Iterations: 3, Parallelism: 2}
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verify with the parameters stored in the hash, not the current ones
+func (h *Argon2idHasher) Verify(hash string, password string) bool {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1
+}
+
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params.memory != h.Memory || params.iterations != h.Iterations || params.parallelism != h.Parallelism || len(params.key) != argon2idKeyLength
+}
+
+func parseArgon2idHash(hash string) (*argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+
+	var params argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+	if params.iterations == 0 || params.parallelism == 0 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+	return &params, nil
+}
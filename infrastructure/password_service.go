@@ -1,21 +1,49 @@
 package infrastructure
 
-import (
-	"golang.org/x/crypto/bcrypt"
-)
-
+// PasswordService hashes new passwords with Hasher and still verifies hashes of
+// the Legacy hashers, the zero value uses bcrypt with the default cost
 type PasswordService struct {
+	Hasher PasswordHasher
+	Legacy []PasswordHasher
 }
 
-func (p *PasswordService) HashPassword(password string) (string, error) {
-	hashedPassword, e := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (p *PasswordService) hasher() PasswordHasher {
+	if p.Hasher == nil {
+		return &BcryptHasher{}
+	}
+	return p.Hasher
+}
 
-	if e != nil {
-		return "", e
+// the hasher that made the hash, nil if none of them did
+func (p *PasswordService) hasherFor(hash string) PasswordHasher {
+	if current := p.hasher(); current.Recognizes(hash) {
+		return current
+	}
+	for _, legacy := range p.Legacy {
+		if legacy.Recognizes(hash) {
+			return legacy
+		}
 	}
-	return string(hashedPassword), nil
+	return nil
+}
+
+func (p *PasswordService) HashPassword(password string) (string, error) {
+	return p.hasher().Hash(password)
 }
 
 func (p *PasswordService) ComparePassword(existingPassword string, userPassword string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(existingPassword), []byte(userPassword)) == nil
+	hasher := p.hasherFor(existingPassword)
+	if hasher == nil {
+		return false
+	}
+	return hasher.Verify(existingPassword, userPassword)
+}
+
+// true if the hash was made by another algorithm or with outdated parameters
+func (p *PasswordService) NeedsRehash(existingPassword string) bool {
+	current := p.hasher()
+	if !current.Recognizes(existingPassword) {
+		return true
+	}
+	return current.NeedsRehash(existingPassword)
 }
@@ -0,0 +1,30 @@
+package infrastructure
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// ids from clients are only reused when they can't break logs or headers
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware keeps the X-Request-ID of the request, or makes a new one,
+// and exposes it as "request_id", in the request context for logging and in the
+// response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
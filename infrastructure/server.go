@@ -0,0 +1,84 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ServerConfig configures the HTTP server, zero timeouts mean none
+type ServerConfig struct {
+	// host:port to listen on, an empty host listens on all interfaces
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration
+	// where the server reports connection errors like failed TLS handshakes, the standard logger when nil
+	Logger *slog.Logger
+}
+
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+	}
+}
+
+func NewServer(handler http.Handler, config ServerConfig) *http.Server {
+	server := &http.Server{
+		Addr:              config.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	if config.Logger != nil {
+		server.ErrorLog = slog.NewLogLogger(config.Logger.Handler(), slog.LevelError)
+	}
+	return server
+}
+
+// Serve handles requests on listener until ctx is done, then stops accepting
+// connections and waits up to shutdownTimeout for in-flight requests. Requests
+// still running after that are cut off and their contexts canceled
+func Serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	// request contexts outlive ctx so draining requests aren't canceled right away
+	requests, cancelRequests := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRequests()
+	server.BaseContext = func(net.Listener) context.Context { return requests }
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		cancelRequests()
+		server.Close()
+		return err
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how long sending an email may take when the context has no earlier deadline
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer sends plain text emails through an SMTP server, using STARTTLS when the server offers it
+type SMTPMailer struct {
+	Host string
+	Port int
+	// optional, no authentication when empty
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to string, subject string, body string) error {
+	// header values must not be able to add headers of their own
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("invalid email header")
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.From, to, subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(body, "\n", "\r\n"))
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.Host, strconv.Itoa(m.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// a server that stops responding can't hold the request, canceling the
+	// context interrupts the conversation as well
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return m.send(client, to, []byte(message))
+}
+
+// the steps of smtp.SendMail on a client whose connection has a deadline
+func (m *SMTPMailer) send(client *smtp.Client, to string, message []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
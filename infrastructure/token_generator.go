@@ -0,0 +1,28 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// TokenGenerator creates random secrets such as api keys and hashes them for storage.
+// Unlike passwords these secrets have enough entropy for a fast hash, which keeps them
+// searchable by hash.
+type TokenGenerator struct {
+}
+
+// random url safe secret from size random bytes
+func (t *TokenGenerator) GenerateSecret(size int) (string, error) {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func (t *TokenGenerator) HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// accepted clock drift in periods on either side of the current one
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TotpService implements RFC 6238 time-based one-time passwords
+type TotpService struct {
+	Issuer string
+}
+
+func (t *TotpService) GenerateSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// otpauth:// uri to be shown as a QR code by the client
+func (t *TotpService) ProvisioningURI(username string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", t.Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	label := url.PathEscape(t.Issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// generate the code for the period containing at
+func (t *TotpService) GenerateCode(secret string, at time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, uint64(at.Unix())/uint64(totpPeriod.Seconds())), nil
+}
+
+// check a code against the periods around now and return the counter of its period.
+// Codes of lastCounter's period or earlier were used already and are rejected, RFC 6238 section 5.2
+func (t *TotpService) ValidateCode(secret string, code string, lastCounter int64) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	counter := time.Now().Unix() / int64(totpPeriod.Seconds())
+	for skew := int64(-totpSkew); skew <= totpSkew; skew++ {
+		if counter+skew <= lastCounter {
+			continue
+		}
+		expected := totpCode(key, uint64(counter+skew))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + skew, true
+		}
+	}
+	return 0, false
+}
+
+// single use codes for when the authenticator app is lost, formatted as xxxxx-xxxxx
+func (t *TotpService) GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(raw))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// HOTP value (RFC 4226) for a counter
+func totpCode(key []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
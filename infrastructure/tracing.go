@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingConfig struct {
+	// "otlp", "stdout" or "none"
+	Exporter    string
+	ServiceName string
+	// where "otlp" sends spans, e.g. http://localhost:4318, the OTEL_EXPORTER_OTLP_* defaults apply when empty
+	OTLPEndpoint string
+	// where "stdout" writes spans, os.Stdout when nil
+	Output io.Writer
+}
+
+// SetupTracing installs the global tracer provider and trace context propagation.
+// The returned shutdown flushes the spans that weren't exported yet
+func SetupTracing(ctx context.Context, config TracingConfig) (shutdown func(ctx context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch config.Exporter {
+	case "", "none":
+		return func(ctx context.Context) error { return nil }, nil
+	case "otlp":
+		var options []otlptracehttp.Option
+		if config.OTLPEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case "stdout":
+		options := []stdouttrace.Option{stdouttrace.WithPrettyPrint()}
+		if config.Output != nil {
+			options = append(options, stdouttrace.WithWriter(config.Output))
+		}
+		exporter, err = stdouttrace.New(options...)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(config.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// NewMongoMonitor traces every command sent to MongoDB as a child of the span in
+// the context of the operation, set it with options.Client().SetMonitor
+func NewMongoMonitor(provider trace.TracerProvider) *event.CommandMonitor {
+	tracer := provider.Tracer("github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure/mongo")
+	// spans of the commands in flight
+	var spans sync.Map
+	type spanKey struct {
+		connectionID string
+		requestID    int64
+	}
+
+	end := func(evt event.CommandFinishedEvent, failure string) {
+		value, ok := spans.LoadAndDelete(spanKey{evt.ConnectionID, evt.RequestID})
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if failure != "" {
+			span.SetStatus(codes.Error, failure)
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			collection, _ := evt.Command.Lookup(evt.CommandName).StringValueOK()
+			name := evt.CommandName
+			if collection != "" {
+				name = collection + "." + evt.CommandName
+			}
+			_, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBNamespace(evt.DatabaseName),
+					semconv.DBOperationName(evt.CommandName),
+					semconv.DBCollectionName(collection),
+				),
+			)
+			spans.Store(spanKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			end(evt.CommandFinishedEvent, "")
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			end(evt.CommandFinishedEvent, evt.Failure)
+		},
+	}
+}
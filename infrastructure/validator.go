@@ -0,0 +1,205 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	"golang.org/x/text/language"
+)
+
+// messages for the rules that the validator translations don't cover, "invalid" is the
+// fallback for any other rule
+var ruleTranslations = map[string]map[string]string{
+	"en": {
+		domain.RuleTaskStatus: "{0} must be pending, in progress or completed",
+		domain.RuleNotPast:    "{0} must not be in the past",
+		"timezone":            "{0} must be an IANA time zone",
+		"bcp47_language_tag":  "{0} must be a BCP 47 language tag",
+		"http_url":            "{0} must be an http or https URL",
+		"invalid":             "{0} is invalid",
+	},
+	"es": {
+		domain.RuleTaskStatus: "{0} debe ser pending, in progress o completed",
+		domain.RuleNotPast:    "{0} no puede estar en el pasado",
+		"timezone":            "{0} debe ser una zona horaria IANA",
+		"bcp47_language_tag":  "{0} debe ser una etiqueta de idioma BCP 47",
+		"http_url":            "{0} debe ser una URL http o https",
+		"invalid":             "{0} no es válido",
+	},
+	"fr": {
+		domain.RuleTaskStatus: "{0} doit être pending, in progress ou completed",
+		domain.RuleNotPast:    "{0} ne doit pas être dans le passé",
+		"timezone":            "{0} doit être un fuseau horaire IANA",
+		"bcp47_language_tag":  "{0} doit être une balise de langue BCP 47",
+		"http_url":            "{0} doit être une URL http ou https",
+		"invalid":             "{0} n'est pas valide",
+	},
+}
+
+// languages with translations, the first one is the default
+var supportedLanguages = []language.Tag{language.English, language.Spanish, language.French}
+
+// Validator checks request structs for gin's binding and describes the failures
+// in the language the client asked for
+type Validator struct {
+	once        sync.Once
+	validate    *validator.Validate
+	translators *ut.UniversalTranslator
+	matcher     language.Matcher
+}
+
+func NewValidator() *Validator {
+	v := &Validator{}
+	v.lazyinit()
+	return v
+}
+
+func (v *Validator) lazyinit() {
+	v.once.Do(func() {
+		v.validate = validator.New(validator.WithRequiredStructEnabled())
+		v.validate.SetTagName("binding")
+		// report fields by their json names, the way clients send them
+		v.validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return field.Name
+			}
+			return name
+		})
+		if err := v.validate.RegisterValidation(domain.RuleTaskStatus, func(fl validator.FieldLevel) bool {
+			return domain.IsTaskStatus(fl.Field().String())
+		}); err != nil {
+			panic(err)
+		}
+
+		english := en.New()
+		v.translators = ut.New(english, english, es.New(), fr.New())
+		v.matcher = language.NewMatcher(supportedLanguages)
+		v.register("en", en_translations.RegisterDefaultTranslations)
+		v.register("es", es_translations.RegisterDefaultTranslations)
+		v.register("fr", fr_translations.RegisterDefaultTranslations)
+	})
+}
+
+func (v *Validator) register(locale string, defaults func(*validator.Validate, ut.Translator) error) {
+	translator, _ := v.translators.GetTranslator(locale)
+	if err := defaults(v.validate, translator); err != nil {
+		panic(err)
+	}
+	for rule, message := range ruleTranslations[locale] {
+		if err := translator.Add(rule, message, true); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ValidateStruct implements binding.StructValidator
+func (v *Validator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		v.lazyinit()
+		return v.validate.Struct(obj)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.ValidateStruct(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Engine implements binding.StructValidator
+func (v *Validator) Engine() any {
+	v.lazyinit()
+	return v.validate
+}
+
+// the translator for the best match of an Accept-Language header
+func (v *Validator) translator(acceptLanguage string) ut.Translator {
+	v.lazyinit()
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := v.matcher.Match(tags...)
+	base, _ := supportedLanguages[index].Base()
+	translator, _ := v.translators.GetTranslator(base.String())
+	return translator
+}
+
+// InvalidParams describes the fields of a validation error in the language of the Accept-Language header
+func (v *Validator) InvalidParams(validationErr *domain.ValidationError, acceptLanguage string) []domain.InvalidParam {
+	translator := v.translator(acceptLanguage)
+	params := make([]domain.InvalidParam, 0, len(validationErr.Params))
+
+	var fieldErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(validationErr.Cause, &fieldErrs):
+		for _, fieldErr := range fieldErrs {
+			params = append(params, domain.InvalidParam{Name: fieldPath(fieldErr), Reason: translateFieldError(translator, fieldErr)})
+		}
+	case errors.As(validationErr.Cause, &typeErr) && typeErr.Field != "":
+		params = append(params, domain.InvalidParam{Name: typeErr.Field, Reason: translateRule(translator, "invalid", typeErr.Field)})
+	}
+
+	for _, param := range validationErr.Params {
+		if param.Rule != "" {
+			param.Reason = translateRule(translator, param.Rule, param.Name)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// the path of a field from the request body, e.g. operations[2].task.title for
+// nested fields, the namespace without the name of the struct
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+func translateFieldError(translator ut.Translator, fieldErr validator.FieldError) string {
+	tag := fieldErr.Tag()
+	// rules like eq=|email also accept an empty value, the last one is what went wrong
+	if strings.Contains(tag, "|") {
+		return translateRule(translator, tag[strings.LastIndex(tag, "|")+1:], fieldErr.Field())
+	}
+
+	message := fieldErr.Translate(translator)
+	if message == fieldErr.Error() {
+		return translateRule(translator, tag, fieldErr.Field())
+	}
+	return message
+}
+
+func translateRule(translator ut.Translator, rule string, field string) string {
+	if message, err := translator.T(rule, field); err == nil {
+		return message
+	}
+	message, _ := translator.T("invalid", field)
+	return message
+}
@@ -0,0 +1,93 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Workers runs background jobs that stop once their context is canceled,
+// so shutdown can wait for them before closing what they use
+type Workers struct {
+	// where failed jobs are logged, slog.Default() when nil
+	Logger *slog.Logger
+
+	wg sync.WaitGroup
+
+	mu sync.Mutex
+	// names of the periodic jobs that are no longer running
+	stopped []string
+}
+
+// Go runs job in its own goroutine
+func (w *Workers) Go(ctx context.Context, job func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		job(ctx)
+	}()
+}
+
+// Every runs job every interval until ctx is canceled, errors are logged
+func (w *Workers) Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	w.Go(ctx, func(ctx context.Context) {
+		defer w.stop(name)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job(ctx); err != nil {
+					w.logger().ErrorContext(ctx, "background job failed", "job", name, "error", err)
+				}
+			}
+		}
+	})
+}
+
+func (w *Workers) logger() *slog.Logger {
+	if w.Logger == nil {
+		return slog.Default()
+	}
+	return w.Logger
+}
+
+func (w *Workers) stop(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = append(w.stopped, name)
+}
+
+// Check reports the periodic jobs that stopped, which they only do on shutdown
+func (w *Workers) Check(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for _, name := range w.stopped {
+		errs = append(errs, fmt.Errorf("%s stopped", name))
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until every job returned, or until ctx is done
+func (w *Workers) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *APIKeyRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	// keys are looked up by hash on every request and listed per user
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "username", Value: 1}},
+		},
+	}
+	ensureIndexes(collection, indexModels...)
+
+	return &APIKeyRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+func (ar *APIKeyRepository) AddAPIKey(ctx context.Context, apiKey *domain.APIKey) (*domain.APIKey, error) {
+	ctx, cancel := ar.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := ar.collection.InsertOne(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (ar *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	ctx, cancel := ar.deadlines.read(ctx)
+	defer cancel()
+
+	var apiKey domain.APIKey
+	err := ar.collection.FindOne(ctx, bson.D{{Key: "key_hash", Value: keyHash}}).Decode(&apiKey)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// all keys of a user, newest first
+func (ar *APIKeyRepository) GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error) {
+	ctx, cancel := ar.deadlines.list(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := ar.collection.Find(ctx, bson.D{{Key: "username", Value: username}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	apiKeys := make([]domain.APIKey, 0)
+	if err := cursor.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// mark a key of the user as revoked, revoked keys are kept for reference
+func (ar *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID, username string) error {
+	ctx, cancel := ar.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "username", Value: username},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	result, err := ar.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.M{"revoked_at": time.Now().UTC()}}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("api key %s: %w", id, domain.ErrAPIKeyNotFound)
+	}
+	return nil
+}
+
+func (ar *APIKeyRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	ctx, cancel := ar.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := ar.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.M{"last_used_at": usedAt}}})
+	return err
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository stores the audit log, it only inserts and reads
+type AuditRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewAuditRepository creates a new AuditRepository.
+func NewAuditRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *AuditRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	// the log is read newest first, by itself or for one actor or target
+	ensureIndexes(collection,
+		mongo.IndexModel{Keys: bson.D{{Key: "time", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "time", Value: -1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "target", Value: 1}, {Key: "time", Value: -1}}},
+	)
+
+	return &AuditRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+func (ar *AuditRepository) AddEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := ar.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := ar.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (ar *AuditRepository) GetEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	ctx, cancel := ar.deadlines.list(ctx)
+	defer cancel()
+
+	query := bson.D{}
+	for _, field := range []bson.E{{Key: "actor", Value: filter.Actor}, {Key: "action", Value: filter.Action}, {Key: "target", Value: filter.Target}} {
+		if field.Value != "" {
+			query = append(query, field)
+		}
+	}
+	timeRange := bson.D{}
+	if !filter.Since.IsZero() {
+		timeRange = append(timeRange, bson.E{Key: "$gte", Value: filter.Since})
+	}
+	if !filter.Until.IsZero() {
+		timeRange = append(timeRange, bson.E{Key: "$lt", Value: filter.Until})
+	}
+	if len(timeRange) > 0 {
+		query = append(query, bson.E{Key: "time", Value: timeRange})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	cursor, err := ar.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]domain.AuditEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// id of the document marking that the first admin was chosen
+const firstAdminClaim = "first_admin"
+
+type BootstrapRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewBootstrapRepository creates a new BootstrapRepository.
+func NewBootstrapRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *BootstrapRepository {
+	return &BootstrapRepository{
+		collection: client.Database(dbName).Collection(collectionName),
+		deadlines:  deadlines,
+	}
+}
+
+// the unique _id makes the insert succeed only once, even across instances
+func (br *BootstrapRepository) ClaimFirstAdmin(ctx context.Context, username string) (bool, error) {
+	ctx, cancel := br.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := br.collection.InsertOne(ctx, bson.M{"_id": firstAdminClaim, "username": username, "claimed_at": time.Now().UTC()})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// Deadlines bounds how long each kind of database operation may run, a shorter
+// deadline of the caller's context still applies and so does its cancellation
+type Deadlines struct {
+	// finding a single document
+	Read time.Duration
+	// queries returning many documents
+	List time.Duration
+	// inserts, updates and deletes
+	Write time.Duration
+}
+
+// DefaultDeadlines are the deadlines the repositories always used
+func DefaultDeadlines() Deadlines {
+	return Deadlines{Read: 10 * time.Second, List: 30 * time.Second, Write: 10 * time.Second}
+}
+
+func (d Deadlines) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDeadline(ctx, d.Read)
+}
+
+func (d Deadlines) list(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDeadline(ctx, d.List)
+}
+
+func (d Deadlines) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withDeadline(ctx, d.Write)
+}
+
+// a zero timeout leaves the deadline to the caller
+func withDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
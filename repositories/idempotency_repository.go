@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository.
+func NewIdempotencyRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *IdempotencyRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	ensureIndexes(collection,
+		// a key is reserved once per user
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "username", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		// records are removed by MongoDB once they expire
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	)
+
+	return &IdempotencyRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	ctx, cancel := r.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "username", Value: record.Username}, {Key: "key", Value: record.Key}}
+	// the TTL monitor only runs every minute, expired records may still be around
+	expired := bson.D{
+		{Key: "username", Value: record.Username},
+		{Key: "key", Value: record.Key},
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: record.CreatedAt}}},
+	}
+	if _, err := r.collection.DeleteOne(ctx, expired); err != nil {
+		return nil, err
+	}
+
+	_, err := r.collection.InsertOne(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var stored domain.IdempotencyRecord
+	err = r.collection.FindOne(ctx, filter).Decode(&stored)
+	if err != nil {
+		// released between the insert and the find, the request that had it just failed
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrIdempotencyKeyInProgress
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	ctx, cancel := r.deadlines.write(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: record.Status},
+		{Key: "content_type", Value: record.ContentType},
+		{Key: "headers", Value: record.Headers},
+		{Key: "body", Value: record.Body},
+		{Key: "expires_at", Value: record.ExpiresAt},
+	}}}
+	_, err := r.collection.UpdateByID(ctx, record.ID, update)
+	return err
+}
+
+func (r *IdempotencyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := r.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	return err
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// indexes that could not be created yet, by collection
+var pendingIndexes = struct {
+	sync.Mutex
+	collections map[*mongo.Collection][]mongo.IndexModel
+}{collections: make(map[*mongo.Collection][]mongo.IndexModel)}
+
+// create the indexes of a collection, failures are logged and retried by CheckIndexes
+func ensureIndexes(collection *mongo.Collection, models ...mongo.IndexModel) {
+	if _, err := collection.Indexes().CreateMany(context.TODO(), models); err != nil {
+		slog.Error("could not create indexes, retrying on readiness checks", "collection", collection.Name(), "error", err)
+		pendingIndexes.Lock()
+		pendingIndexes.collections[collection] = append(pendingIndexes.collections[collection], models...)
+		pendingIndexes.Unlock()
+	}
+}
+
+// CheckIndexes retries the indexes that failed to be created and reports the
+// collections that still lack them
+func CheckIndexes(ctx context.Context) error {
+	pendingIndexes.Lock()
+	defer pendingIndexes.Unlock()
+
+	var errs []error
+	for collection, models := range pendingIndexes.collections {
+		if _, err := collection.Indexes().CreateMany(ctx, models); err != nil {
+			errs = append(errs, fmt.Errorf("indexes of %s: %w", collection.Name(), err))
+			continue
+		}
+		delete(pendingIndexes.collections, collection)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewInvitationRepository creates a new InvitationRepository.
+func NewInvitationRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *InvitationRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	ensureIndexes(collection, indexModel)
+
+	return &InvitationRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+func (ir *InvitationRepository) AddInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	ctx, cancel := ir.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := ir.collection.InsertOne(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (ir *InvitationRepository) GetInvitationByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	ctx, cancel := ir.deadlines.read(ctx)
+	defer cancel()
+
+	var invitation domain.Invitation
+	err := ir.collection.FindOne(ctx, bson.D{{Key: "token_hash", Value: tokenHash}}).Decode(&invitation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// all invitations, newest first
+func (ir *InvitationRepository) GetInvitations(ctx context.Context) ([]domain.Invitation, error) {
+	ctx, cancel := ir.deadlines.list(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := ir.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invitations := make([]domain.Invitation, 0)
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ir *InvitationRepository) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := ir.deadlines.write(ctx)
+	defer cancel()
+
+	result, err := ir.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("invitation %s: %w", id, domain.ErrInvitationNotFound)
+	}
+	return nil
+}
+
+// mark an unused, unexpired invitation as used, at most one caller succeeds
+func (ir *InvitationRepository) UseInvitation(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) error {
+	ctx, cancel := ir.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: usedAt}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.M{"used_at": usedAt, "used_by": username}}}
+	result, err := ir.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("invitation %s: %w", id, domain.ErrInvitationNotFound)
+	}
+	return nil
+}
+
+// make a used invitation usable again after a failed registration
+func (ir *InvitationRepository) ReleaseInvitation(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := ir.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := ir.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$unset", Value: bson.M{"used_at": "", "used_by": ""}}})
+	return err
+}
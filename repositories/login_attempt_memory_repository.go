@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+// InMemoryLoginAttemptRepository keeps login attempts in process memory,
+// suitable for a single server instance.
+type InMemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempt
+}
+
+// NewInMemoryLoginAttemptRepository creates a new InMemoryLoginAttemptRepository.
+func NewInMemoryLoginAttemptRepository() *InMemoryLoginAttemptRepository {
+	return &InMemoryLoginAttemptRepository{
+		attempts: make(map[string]domain.LoginAttempt),
+	}
+}
+
+func (mr *InMemoryLoginAttemptRepository) GetAttempt(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	attempt, ok := mr.attempts[key]
+	if !ok {
+		attempt = domain.LoginAttempt{Key: key}
+	}
+	return &attempt, nil
+}
+
+func (mr *InMemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*domain.LoginAttempt, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	now := time.Now().UTC()
+	attempt, ok := mr.attempts[key]
+	// the window starts after a lockout, the next failure can't come before it ends
+	since := attempt.LastFailure
+	if attempt.LockedUntil.After(since) {
+		since = attempt.LockedUntil
+	}
+	if !ok || since.Before(now.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailure = now
+	mr.attempts[key] = attempt
+
+	return &attempt, nil
+}
+
+func (mr *InMemoryLoginAttemptRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	attempt, ok := mr.attempts[key]
+	if !ok {
+		attempt = domain.LoginAttempt{Key: key}
+	}
+	attempt.LockedUntil = until
+	mr.attempts[key] = attempt
+
+	return nil
+}
+
+func (mr *InMemoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	delete(mr.attempts, key)
+	return nil
+}
+
+// DeleteStale removes attempts that are untouched for as long as the mongo store keeps
+// them and no longer locked, run it periodically so the map doesn't grow forever
+func (mr *InMemoryLoginAttemptRepository) DeleteStale(now time.Time) int {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	deleted := 0
+	for key, attempt := range mr.attempts {
+		if attempt.LastFailure.Before(now.Add(-loginAttemptTTL)) && !attempt.LockedUntil.After(now) {
+			delete(mr.attempts, key)
+			deleted++
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attempts untouched for this long are removed by mongo
+const loginAttemptTTL = 24 * time.Hour
+
+type LoginAttemptRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository.
+func NewLoginAttemptRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *LoginAttemptRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	// expire stale attempts so the collection does not grow forever
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "last_failure", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(loginAttemptTTL.Seconds())),
+	}
+	ensureIndexes(collection, indexModel)
+
+	return &LoginAttemptRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+// get the attempt record for a key, an empty record if there is none
+func (lr *LoginAttemptRepository) GetAttempt(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	ctx, cancel := lr.deadlines.read(ctx)
+	defer cancel()
+
+	var attempt domain.LoginAttempt
+	err := lr.collection.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&attempt)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return &domain.LoginAttempt{Key: key}, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// atomically count a failure, restarting the count if the last one is older than window
+func (lr *LoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*domain.LoginAttempt, error) {
+	ctx, cancel := lr.deadlines.write(ctx)
+	defer cancel()
+
+	now := time.Now().UTC()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "failures", Value: bson.D{{Key: "$cond", Value: bson.A{
+				// the window starts after a lockout, the next failure can't come before it ends
+				bson.D{{Key: "$gte", Value: bson.A{bson.D{{Key: "$max", Value: bson.A{"$last_failure", "$locked_until"}}}, now.Add(-window)}}},
+				bson.D{{Key: "$add", Value: bson.A{"$failures", 1}}},
+				1,
+			}}}},
+			{Key: "last_failure", Value: now},
+		}}},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempt domain.LoginAttempt
+	err := lr.collection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: key}}, update, opts).Decode(&attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (lr *LoginAttemptRepository) LockUntil(ctx context.Context, key string, until time.Time) error {
+	ctx, cancel := lr.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := lr.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: key}}, bson.D{{Key: "$set", Value: bson.M{"locked_until": until}}})
+	return err
+}
+
+func (lr *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	ctx, cancel := lr.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := lr.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+	return err
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import "time"
+
+// OperationObserver records how long repository operations take
+type OperationObserver interface {
+	ObserveOperation(repository string, method string, duration time.Duration)
+}
+
+// start timing an operation, the returned func records it:
+//
+//	defer observe(r.Observer, "task", "GetTasks")()
+func observe(observer OperationObserver, repository string, method string) func() {
+	if observer == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		observer.ObserveOperation(repository, method, time.Since(start))
+	}
+}
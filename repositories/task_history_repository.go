@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskHistoryRepository keeps the changed fields of tasks, one document per change
+type TaskHistoryRepository struct {
+	collection *mongo.Collection
+	deadlines  Deadlines
+}
+
+// NewTaskHistoryRepository creates a new TaskHistoryRepository.
+func NewTaskHistoryRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *TaskHistoryRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "time", Value: -1}},
+	}
+	ensureIndexes(collection, indexModel)
+
+	return &TaskHistoryRepository{
+		collection: collection,
+		deadlines:  deadlines,
+	}
+}
+
+func (hr *TaskHistoryRepository) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	ctx, cancel := hr.deadlines.write(ctx)
+	defer cancel()
+
+	_, err := hr.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (hr *TaskHistoryRepository) GetHistory(ctx context.Context, taskID uuid.UUID) ([]domain.TaskHistoryEntry, error) {
+	ctx, cancel := hr.deadlines.list(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	cursor, err := hr.collection.Find(ctx, bson.D{{Key: "task_id", Value: taskID}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	history := make([]domain.TaskHistoryEntry, 0)
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
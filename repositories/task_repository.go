@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
@@ -14,21 +15,38 @@ import (
 
 type TaskRepository struct {
 	collection *mongo.Collection
+	deadlines  Deadlines
+	// optional, records how long each method takes
+	Observer OperationObserver
 }
 
+// tasks in the trash have a deleted_at, every other method leaves them alone
+var notDeleted = bson.E{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: false}}}
+var deleted = bson.E{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: true}}}
+
 // NewTaskRepository creates a new TaskRepository.
-func NewTaskRepository(client *mongo.Client, dbName, collectionName string) *TaskRepository {
+func NewTaskRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *TaskRepository {
 	collection := client.Database(dbName).Collection(collectionName)
+
+	// the trash is listed and purged by deletion time
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.D{deleted}),
+	}
+	ensureIndexes(collection, indexModel)
+
 	return &TaskRepository{
 		collection: collection,
+		deadlines:  deadlines,
 	}
 }
 
-func (tr *TaskRepository) GetTasks() ([]domain.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (tr *TaskRepository) GetTasks(ctx context.Context) ([]domain.Task, error) {
+	defer observe(tr.Observer, "task", "GetTasks")()
+	ctx, cancel := tr.deadlines.list(ctx)
 	defer cancel()
   
-	cursor, err := tr.collection.Find(ctx, bson.D{{}})
+	cursor, err := tr.collection.Find(ctx, bson.D{notDeleted})
 	if err != nil {
   
 	  return nil, err
@@ -54,19 +72,20 @@ func (tr *TaskRepository) GetTasks() ([]domain.Task, error) {
 	return tasks, nil
 }
 
-func (tr *TaskRepository) GetTaskById(id uuid.UUID) (*domain.Task, error) {
+func (tr *TaskRepository) GetTaskById(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	defer observe(tr.Observer, "task", "GetTaskById")()
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := tr.deadlines.read(ctx)
 	defer cancel()
   
-	filter := bson.D{{Key: "_id", Value: id}}
+	filter := bson.D{{Key: "_id", Value: id}, notDeleted}
   
 	// Find a single document that matches the filter
 	var task domain.Task
 	err := tr.collection.FindOne(ctx, filter).Decode(&task)
 	if err != nil {
-	  if err == mongo.ErrNoDocuments {
-		return nil, errors.New("task Not Found")
+	  if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("task %s: %w", id, domain.ErrTaskNotFound)
 	  }
 	  return nil, err
 	}
@@ -74,11 +93,13 @@ func (tr *TaskRepository) GetTaskById(id uuid.UUID) (*domain.Task, error) {
 	return &task, nil
 }
 
-func (tr *TaskRepository) UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// update a task, the task is returned as it was before
+func (tr *TaskRepository) UpdateTaskByID(ctx context.Context, id uuid.UUID, updatedTask domain.Task) (*domain.Task, error) {
+	defer observe(tr.Observer, "task", "UpdateTaskByID")()
+	ctx, cancel := tr.deadlines.write(ctx)
 	defer cancel()
   
-	filter := bson.D{{Key: "_id", Value: id}}
+	filter := bson.D{{Key: "_id", Value: id}, notDeleted}
   
 	update := bson.D{
 	  {Key: "$set", Value: bson.D{
@@ -88,40 +109,80 @@ func (tr *TaskRepository) UpdateTaskByID(id uuid.UUID, updatedTask domain.Task)
 		{Key: "status", Value: updatedTask.Status},
 	  }},
 	}
+	return tr.findOneAndUpdate(ctx, id, filter, update)
+}
 
-	// Update the document that matches the filter
-	result :=  tr.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
-	if result.Err() != nil {
-		if result.Err() == mongo.ErrNoDocuments {
-			return errors.New("task not found")
-		}
-		return result.Err()
-	}
-  
-	return nil
+// move a task to the trash, the task is returned as it was before
+func (tr *TaskRepository) DeleteTask(ctx context.Context, id uuid.UUID, deletedBy string, deletedAt time.Time) (*domain.Task, error) {
+	defer observe(tr.Observer, "task", "DeleteTask")()
+	ctx, cancel := tr.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: id}, notDeleted}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "deleted_at", Value: deletedAt}, {Key: "deleted_by", Value: deletedBy}}}}
+	return tr.findOneAndUpdate(ctx, id, filter, update)
 }
 
-func (tr *TaskRepository) DeleteTask(id uuid.UUID) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// tasks in the trash, most recently deleted first
+func (tr *TaskRepository) GetDeletedTasks(ctx context.Context) ([]domain.Task, error) {
+	defer observe(tr.Observer, "task", "GetDeletedTasks")()
+	ctx, cancel := tr.deadlines.list(ctx)
 	defer cancel()
-    
-	filter := bson.D{{Key: "_id", Value: id}}
-  
-	// Delete the document that matches the filter
-	result, err := tr.collection.DeleteOne(ctx, filter)
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := tr.collection.Find(ctx, bson.D{deleted}, opts)
 	if err != nil {
-	  return err
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	if result.DeletedCount == 0 {
-		return errors.New("task not found")
+	tasks := make([]domain.Task, 0)
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
 	}
+	return tasks, nil
+}
 
-	return nil
+// take a task out of the trash, the task is returned as it was in the trash
+func (tr *TaskRepository) RestoreTask(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	defer observe(tr.Observer, "task", "RestoreTask")()
+	ctx, cancel := tr.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: id}, deleted}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "deleted_at", Value: ""}, {Key: "deleted_by", Value: ""}}}}
+	return tr.findOneAndUpdate(ctx, id, filter, update)
+}
+
+// remove the tasks deleted before deletedBefore for good, returns how many there were
+func (tr *TaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer observe(tr.Observer, "task", "PurgeDeletedTasks")()
+	ctx, cancel := tr.deadlines.write(ctx)
+	defer cancel()
+
+	result, err := tr.collection.DeleteMany(ctx, bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// update the task matching filter and return it as it was before
+func (tr *TaskRepository) findOneAndUpdate(ctx context.Context, id uuid.UUID, filter bson.D, update bson.D) (*domain.Task, error) {
+	var task domain.Task
+	err := tr.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&task)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("task %s: %w", id, domain.ErrTaskNotFound)
+		}
+		return nil, err
+	}
+	return &task, nil
 }
 
-func (tr *TaskRepository) AddTask(task domain.Task) (*domain.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (tr *TaskRepository) AddTask(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	defer observe(tr.Observer, "task", "AddTask")()
+	ctx, cancel := tr.deadlines.write(ctx)
 	defer cancel()
 
 	// Recreate task until the ID conflict is resolved
@@ -136,4 +197,147 @@ func (tr *TaskRepository) AddTask(task domain.Task) (*domain.Task, error) {
 		}
 		return &task, nil
 	}
-}
\ No newline at end of file
+}
+// the tasks with these ids that aren't in the trash
+func (tr *TaskRepository) GetTasksByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Task, error) {
+	defer observe(tr.Observer, "task", "GetTasksByIDs")()
+	ctx, cancel := tr.deadlines.list(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}, notDeleted}
+	cursor, err := tr.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := make([]domain.Task, 0, len(ids))
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// apply the writes with one unordered BulkWrite, a write that fails doesn't stop
+// the others. Writes to tasks that were trashed or purged since the callers read
+// them match nothing and fail with ErrTaskNotFound
+func (tr *TaskRepository) BulkWriteTasks(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	defer observe(tr.Observer, "task", "BulkWriteTasks")()
+	ctx, cancel := tr.deadlines.list(ctx)
+	defer cancel()
+
+	models := make([]mongo.WriteModel, 0, len(writes))
+	for _, write := range writes {
+		task := write.Task
+		filter := bson.D{{Key: "_id", Value: task.ID}, notDeleted}
+		var set bson.D
+		switch write.Op {
+		case domain.BulkCreate:
+			models = append(models, mongo.NewInsertOneModel().SetDocument(task))
+			continue
+		case domain.BulkUpdate:
+			set = bson.D{
+				{Key: "title", Value: task.Title},
+				{Key: "description", Value: task.Description},
+				{Key: "due_date", Value: task.DueDate},
+				{Key: "status", Value: task.Status},
+			}
+		case domain.BulkSetStatus:
+			set = bson.D{{Key: "status", Value: task.Status}}
+		case domain.BulkDelete:
+			set = bson.D{{Key: "deleted_at", Value: task.DeletedAt}, {Key: "deleted_by", Value: task.DeletedBy}}
+		default:
+			return nil, fmt.Errorf("unknown bulk operation %q", write.Op)
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{Key: "$set", Value: set}}))
+	}
+
+	writeErrs := make([]error, len(writes))
+	result, err := tr.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	// write conflicts in a transaction are left to WithTransaction to retry
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && !bulkErr.HasErrorLabel("TransientTransactionError") {
+		for _, writeErr := range bulkErr.WriteErrors {
+			writeErrs[writeErr.Index] = writeErr
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	// a failed write aborts a transaction, the batch is rolled back either way
+	if len(bulkErr.WriteErrors) > 0 && mongo.SessionFromContext(ctx) != nil {
+		return writeErrs, nil
+	}
+
+	// when the updates that went through matched fewer tasks than expected, the
+	// tasks are looked up to find the ones that are gone
+	var updated int64
+	for i, write := range writes {
+		if write.Op != domain.BulkCreate && writeErrs[i] == nil {
+			updated++
+		}
+	}
+	if result.MatchedCount < updated {
+		if err := tr.findUnmatchedWrites(ctx, writes, writeErrs); err != nil {
+			return nil, err
+		}
+	}
+	return writeErrs, nil
+}
+
+// set ErrTaskNotFound for the updates whose task is gone or in the trash, deletes
+// are only applied when the task carries their deletion
+func (tr *TaskRepository) findUnmatchedWrites(ctx context.Context, writes []domain.TaskWrite, writeErrs []error) error {
+	ids := make([]uuid.UUID, 0, len(writes))
+	for i, write := range writes {
+		if write.Op != domain.BulkCreate && writeErrs[i] == nil {
+			ids = append(ids, write.Task.ID)
+		}
+	}
+
+	cursor, err := tr.collection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}},
+		options.Find().SetProjection(bson.D{{Key: "deleted_at", Value: 1}, {Key: "deleted_by", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	var tasks []domain.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+	found := make(map[uuid.UUID]domain.Task, len(tasks))
+	for _, task := range tasks {
+		found[task.ID] = task
+	}
+
+	for i, write := range writes {
+		if write.Op == domain.BulkCreate || writeErrs[i] != nil {
+			continue
+		}
+		task, ok := found[write.Task.ID]
+		if write.Op == domain.BulkDelete {
+			// mongo keeps milliseconds, another delete in the meantime has its own time
+			ok = ok && task.DeletedAt != nil && task.DeletedBy == write.Task.DeletedBy &&
+				task.DeletedAt.Equal(write.Task.DeletedAt.Truncate(time.Millisecond))
+		} else {
+			ok = ok && task.DeletedAt == nil
+		}
+		if !ok {
+			writeErrs[i] = fmt.Errorf("task %s: %w", write.Task.ID, domain.ErrTaskNotFound)
+		}
+	}
+	return nil
+}
+
+// run fn in a transaction, it needs a replica set
+func (tr *TaskRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := tr.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
@@ -3,7 +3,8 @@ package repositories
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -16,60 +17,80 @@ import (
 
 type UserRepository struct {
 	collection *mongo.Collection
+	deadlines  Deadlines
+	// optional, records how long each method takes
+	Observer OperationObserver
+}
+
+// whether the collection has an index on username alone, false when the
+// indexes can't be read so ensureIndexes gets to create it
+func hasUsernameIndex(collection *mongo.Collection) bool {
+	cursor, err := collection.Indexes().List(context.TODO())
+	if err != nil {
+		slog.Error("could not list indexes", "collection", collection.Name(), "error", err)
+		return false
+	}
+	defer cursor.Close(context.TODO())
+
+	var indexes []bson.M
+	if err := cursor.All(context.TODO(), &indexes); err != nil {
+		slog.Error("could not parse indexes", "collection", collection.Name(), "error", err)
+		return false
+	}
+
+	for _, index := range indexes {
+		key, ok := index["key"].(bson.M)
+		if ok && len(key) == 1 && key["username"] != nil {
+			return true
+		}
+	}
+	return false
 }
 
 // NewUserRepository creates a new UserRepository.
-func NewUserRepository(client *mongo.Client, dbName, collectionName string) *UserRepository {
+func NewUserRepository(client *mongo.Client, dbName, collectionName string, deadlines Deadlines) *UserRepository {
 	collection := client.Database(dbName).Collection(collectionName)
 
 	// check if there is an index on the username field
+	indexExists := hasUsernameIndex(collection)
 
-	// Get a list of existing indexes
-    cursor, err := collection.Indexes().List(context.TODO())
-    if err != nil {
-        log.Printf("could not list indexes: %v", err)
-    }
-    defer cursor.Close(context.TODO())
-
-    var indexes []bson.M
-    if err := cursor.All(context.TODO(), &indexes); err != nil {
-        log.Printf("could not parse indexes: %v", err)
-    }
-
-    // Check if the "username" index already exists
-    indexExists := false
-    for _, index := range indexes {
-        key := index["key"].(bson.M)
-        if len(key) == 1 && key["username"] != nil {
-            indexExists = true
-            break
-        }
-    }
-
-    // If the index does not exist, create it
-    if !indexExists {
+	// If the index does not exist, create it, also when the indexes couldn't be listed
+	if !indexExists {
 		indexModel := mongo.IndexModel{
 			Keys:    bson.D{{Key: "username", Value: 1}}, // Create index on the "username" field
 			Options: options.Index().SetUnique(true),    // Ensure the index is unique
 		}
 		
 		// Create the index
-		_, err = collection.Indexes().CreateOne(context.TODO(), indexModel)
-		if err != nil {
-			log.Printf("could not create index: %v", err)
-		}
+		ensureIndexes(collection, indexModel)
 	} else {
-		log.Println("username index already exists")
+		slog.Debug("username index already exists", "collection", collection.Name())
 	}
+
+	// emails are unique like usernames, users without an email are left out
+	emailIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"email": bson.M{"$exists": true}}),
+	}
+	ensureIndexes(collection, emailIndex)
+
+	// an oidc identity can only be linked to one user
+	oidcIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$exists": true}}),
+	}
+	ensureIndexes(collection, oidcIndex)
 	
 	return &UserRepository{
 		collection: collection,
+		deadlines:  deadlines,
 	}
 }
 
 
-func (ur *UserRepository) Count() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (ur *UserRepository) Count(ctx context.Context) (int64, error) {
+	defer observe(ur.Observer, "user", "Count")()
+	ctx, cancel := ur.deadlines.read(ctx)
 	defer cancel()
 
 	count, err := ur.collection.CountDocuments(ctx, bson.M{})
@@ -81,8 +102,9 @@ func (ur *UserRepository) Count() (int64, error) {
 }
 
 // register new user with unique username and password
-func (ur *UserRepository) RegisterUser(user *domain.User) (*domain.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (ur *UserRepository) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	defer observe(ur.Observer, "user", "RegisterUser")()
+	ctx, cancel := ur.deadlines.write(ctx)
 	defer cancel()
 
 	// Check if user already exists
@@ -91,14 +113,22 @@ func (ur *UserRepository) RegisterUser(user *domain.User) (*domain.User, error)
 		
 		// if user exists return error
 		if mongo.IsDuplicateKeyError(err) {
+			// Check if the duplicate key error is caused by the email field
+			if strings.Contains(err.Error(), "index: email_1") {
+				return nil, domain.ErrEmailExists
+			}
 			// Check if the duplicate key error is caused by the username field
 			if strings.Contains(err.Error(), "username") {
-				return nil, errors.New("username already exists")
+				return nil, domain.ErrUsernameExists
 			}
 			// Check if the duplicate key error is caused by the _id field
 			if strings.Contains(err.Error(), "_id") {
 				continue
 			}
+			if strings.Contains(err.Error(), "oidc_subject") {
+				return nil, domain.ErrOIDCIdentityLinked
+			}
+			return nil, err
 		} else if err != nil {
 			return nil, err
 		}
@@ -110,15 +140,52 @@ func (ur *UserRepository) RegisterUser(user *domain.User) (*domain.User, error)
 
 
 // login user 
-func (ur *UserRepository) GetUser(username string) (*domain.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (ur *UserRepository) GetUser(ctx context.Context, username string) (*domain.User, error) {
+	defer observe(ur.Observer, "user", "GetUser")()
+	ctx, cancel := ur.deadlines.read(ctx)
 	defer cancel()
 
 	var existingUser domain.User
 	err := ur.collection.FindOne(ctx, bson.M{"username": username}).Decode(&existingUser)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+		}
+		return nil, err
+	}
+	return &existingUser, nil
+}
+
+
+// find a user by email
+func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	defer observe(ur.Observer, "user", "GetUserByEmail")()
+	ctx, cancel := ur.deadlines.read(ctx)
+	defer cancel()
+
+	var existingUser domain.User
+	err := ur.collection.FindOne(ctx, bson.M{"email": email}).Decode(&existingUser)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &existingUser, nil
+}
+
+
+// find the user linked to an oidc identity
+func (ur *UserRepository) GetUserByOIDCSubject(ctx context.Context, issuer string, subject string) (*domain.User, error) {
+	defer observe(ur.Observer, "user", "GetUserByOIDCSubject")()
+	ctx, cancel := ur.deadlines.read(ctx)
+	defer cancel()
+
+	var existingUser domain.User
+	err := ur.collection.FindOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject}).Decode(&existingUser)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -126,9 +193,34 @@ func (ur *UserRepository) GetUser(username string) (*domain.User, error) {
 }
 
 
+// link an oidc identity to a user that has none yet
+func (ur *UserRepository) LinkOIDCIdentity(ctx context.Context, username string, issuer string, subject string) error {
+	defer observe(ur.Observer, "user", "LinkOIDCIdentity")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.M{"username": username, "oidc_subject": bson.M{"$exists": false}}
+	update := bson.D{{Key: "$set", Value: bson.M{"oidc_issuer": issuer, "oidc_subject": subject}}}
+	result, err := ur.collection.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrOIDCIdentityLinked
+	}
+	if err != nil {
+		return err
+	}
+
+	// the user is linked to another identity already
+	if result.MatchedCount == 0 {
+		return domain.ErrOIDCIdentityLinked
+	}
+	return nil
+}
+
+
 // promote user to admin
-func (ur *UserRepository) PromoteUser(username string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (ur *UserRepository) PromoteUser(ctx context.Context, username string) error {
+	defer observe(ur.Observer, "user", "PromoteUser")()
+	ctx, cancel := ur.deadlines.write(ctx)
 	defer cancel()
 
 	result, err := ur.collection.UpdateOne(ctx, bson.D{{Key: "username", Value: username}}, bson.D{{Key: "$set", Value: bson.M{"is_admin": true}}})
@@ -137,8 +229,221 @@ func (ur *UserRepository) PromoteUser(username string) error {
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("username not found")
+		return fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
 	} 
 	
+	return nil
+}
+
+
+// replace the password hash of a user
+func (ur *UserRepository) UpdatePassword(ctx context.Context, username string, password string) error {
+	defer observe(ur.Observer, "user", "UpdatePassword")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	result, err := ur.collection.UpdateOne(ctx, bson.D{{Key: "username", Value: username}}, bson.D{{Key: "$set", Value: bson.M{"password": password}}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+
+// store a new email verification token hash, replacing any pending one, a
+// pending email is only moved to email once the token is used
+func (ur *UserRepository) SetEmailVerification(ctx context.Context, username string, pendingEmail string, tokenHash string, expiresAt time.Time) error {
+	defer observe(ur.Observer, "user", "SetEmailVerification")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	set := bson.M{
+		"email_verification_hash":    tokenHash,
+		"email_verification_expires": expiresAt,
+	}
+	update := bson.D{{Key: "$set", Value: set}}
+	if pendingEmail != "" {
+		set["pending_email"] = pendingEmail
+	} else {
+		update = append(update, bson.E{Key: "$unset", Value: bson.M{"pending_email": ""}})
+	}
+
+	result, err := ur.collection.UpdateOne(ctx, bson.D{{Key: "username", Value: username}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+
+// mark the email of the user with this unexpired token as verified, the token can only be used once
+func (ur *UserRepository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) error {
+	defer observe(ur.Observer, "user", "VerifyEmail")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "email_verification_hash", Value: tokenHash},
+		{Key: "email_verification_expires", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	// a pending email replaces the current one, the unique index rejects it when
+	// another user took it since the change was requested
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "email", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$pending_email", "$email"}}}},
+			{Key: "email_verified", Value: true},
+		}}},
+		{{Key: "$unset", Value: bson.A{"pending_email", "email_verification_hash", "email_verification_expires"}}},
+	}
+
+	result, err := ur.collection.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrEmailExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+
+// apply the changed profile fields, empty values are removed, a new email has to be verified again
+func (ur *UserRepository) UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (*domain.User, error) {
+	defer observe(ur.Observer, "user", "UpdateProfile")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	set := bson.M{}
+	unset := bson.M{}
+	fields := map[string]*string{
+		"display_name": update.DisplayName,
+		"email":        update.Email,
+		"timezone":     update.Timezone,
+		"locale":       update.Locale,
+		"avatar_url":   update.AvatarURL,
+	}
+	for field, value := range fields {
+		if value == nil {
+			continue
+		}
+		// unset instead of storing "" so the partial unique index on email ignores it
+		if *value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = *value
+		}
+	}
+	if update.Email != nil {
+		set["email_verified"] = false
+		unset["pending_email"] = ""
+		unset["email_verification_hash"] = ""
+		unset["email_verification_expires"] = ""
+	}
+
+	changes := bson.D{}
+	if len(set) > 0 {
+		changes = append(changes, bson.E{Key: "$set", Value: set})
+	}
+	if len(unset) > 0 {
+		changes = append(changes, bson.E{Key: "$unset", Value: unset})
+	}
+	if len(changes) == 0 {
+		return ur.GetUser(ctx, username)
+	}
+
+	var updatedUser domain.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := ur.collection.FindOneAndUpdate(ctx, bson.D{{Key: "username", Value: username}}, changes, opts).Decode(&updatedUser)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrEmailExists
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+		}
+		return nil, err
+	}
+
+	return &updatedUser, nil
+}
+
+
+// store the totp secret and recovery codes of a user
+func (ur *UserRepository) UpdateTwoFactor(ctx context.Context, username string, secret string, enabled bool, recoveryCodes []string) error {
+	defer observe(ur.Observer, "user", "UpdateTwoFactor")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "$set", Value: bson.M{
+		"totp_secret":        secret,
+		"two_factor_enabled": enabled,
+		"recovery_codes":     recoveryCodes,
+	}}}
+
+	result, err := ur.collection.UpdateOne(ctx, bson.D{{Key: "username", Value: username}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+
+// record the time step of an accepted totp code, the filter makes sure a code is only
+// accepted once even by concurrent logins
+func (ur *UserRepository) UseTOTPCounter(ctx context.Context, username string, counter int64) error {
+	defer observe(ur.Observer, "user", "UseTOTPCounter")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	filter := bson.M{"username": username, "$or": bson.A{
+		bson.M{"totp_last_counter": bson.M{"$lt": counter}},
+		bson.M{"totp_last_counter": bson.M{"$exists": false}},
+	}}
+	result, err := ur.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.M{"totp_last_counter": counter}}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// replace the remaining recovery codes of a user
+func (ur *UserRepository) UpdateRecoveryCodes(ctx context.Context, username string, recoveryCodes []string) error {
+	defer observe(ur.Observer, "user", "UpdateRecoveryCodes")()
+	ctx, cancel := ur.deadlines.write(ctx)
+	defer cancel()
+
+	result, err := ur.collection.UpdateOne(ctx, bson.D{{Key: "username", Value: username}}, bson.D{{Key: "$set", Value: bson.M{"recovery_codes": recoveryCodes}}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("user %q: %w", username, domain.ErrUserNotFound)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyControllerSuite struct {
+	suite.Suite
+	controller  *controllers.APIKeyController
+	mockService *mocks.APIKeyServiceInterface
+}
+
+func (suite *APIKeyControllerSuite) SetupTest() {
+	suite.mockService = new(mocks.APIKeyServiceInterface)
+	suite.controller = &controllers.APIKeyController{Service: suite.mockService}
+}
+
+func (suite *APIKeyControllerSuite) TestCreateAPIKey_Success() {
+	request := domain.APIKey{Name: "ci", Scopes: []string{"read", "write"}}
+	created := &domain.APIKey{ID: uuid.New(), Username: "testuser", Name: "ci", Prefix: "tm_abcdefgh", Scopes: request.Scopes}
+	suite.mockService.On("CreateAPIKey", mock.Anything, "testuser", request).Return("tm_abcdefghijkl", created, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api-keys", bytes.NewBufferString(`{"name": "ci", "scopes": ["read", "write"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.CreateAPIKey)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "tm_abcdefghijkl")
+	assert.NotContains(suite.T(), w.Body.String(), "key_hash")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *APIKeyControllerSuite) TestCreateAPIKey_InvalidScope() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api-keys", bytes.NewBufferString(`{"name": "ci", "scopes": ["everything"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.CreateAPIKey)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "CreateAPIKey", mock.Anything)
+}
+
+func (suite *APIKeyControllerSuite) TestCreateAPIKey_RejectsAPIKeyCaller() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api-keys", bytes.NewBufferString(`{"name": "ci", "scopes": ["read"]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+	c.Set("api_key_id", uuid.New())
+
+	handle(c, suite.controller.CreateAPIKey)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *APIKeyControllerSuite) TestGetAPIKeys_Success() {
+	apiKeys := []domain.APIKey{{ID: uuid.New(), Username: "testuser", Name: "ci", Prefix: "tm_abcdefgh"}}
+	suite.mockService.On("GetAPIKeys", mock.Anything, "testuser").Return(apiKeys, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api-keys", nil)
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.GetAPIKeys)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "tm_abcdefgh")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *APIKeyControllerSuite) TestRevokeAPIKey_NotFound() {
+	id := uuid.New()
+	suite.mockService.On("RevokeAPIKey", mock.Anything, "testuser", id).Return(domain.ErrAPIKeyNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("DELETE", "/api-keys/"+id.String(), nil)
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.RevokeAPIKey)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func TestAPIKeyControllerSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyControllerSuite))
+}
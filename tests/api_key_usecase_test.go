@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyServiceTestSuite struct {
+	suite.Suite
+	service       *usecases.APIKeyService
+	mockKeyRepo   *mocks.APIKeyRepoInterface
+	mockUserRepo  *mocks.UserRepoInterface
+	mockGenerator *mocks.TokenGeneratorInterface
+}
+
+func (suite *APIKeyServiceTestSuite) SetupTest() {
+	suite.mockKeyRepo = new(mocks.APIKeyRepoInterface)
+	suite.mockUserRepo = new(mocks.UserRepoInterface)
+	suite.mockGenerator = new(mocks.TokenGeneratorInterface)
+	suite.service = &usecases.APIKeyService{
+		APIKeyRepo:     suite.mockKeyRepo,
+		UserRepo:       suite.mockUserRepo,
+		TokenGenerator: suite.mockGenerator,
+	}
+}
+
+// Test CreateAPIKey storing only the hash of the key
+func (suite *APIKeyServiceTestSuite) TestCreateAPIKey() {
+	suite.mockGenerator.On("GenerateSecret", 32).Return("abcdefghijklmnop", nil)
+	suite.mockGenerator.On("HashSecret", "tm_abcdefghijklmnop").Return("hashed-key")
+	suite.mockKeyRepo.On("AddAPIKey", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(func(_ context.Context, apiKey *domain.APIKey) *domain.APIKey { return apiKey }, nil)
+
+	key, apiKey, err := suite.service.CreateAPIKey(context.Background(), "testuser", domain.APIKey{Name: "ci", Scopes: []string{"read"}})
+
+	suite.NoError(err)
+	suite.Equal("tm_abcdefghijklmnop", key)
+	suite.Equal("testuser", apiKey.Username)
+	suite.Equal("tm_abcdefgh", apiKey.Prefix)
+	suite.Equal("hashed-key", apiKey.KeyHash)
+	suite.NotEqual(uuid.Nil, apiKey.ID)
+	suite.False(strings.Contains(apiKey.Prefix, "ijklmnop"))
+	suite.mockKeyRepo.AssertExpectations(suite.T())
+}
+
+// Test AuthenticateAPIKey with a valid key
+func (suite *APIKeyServiceTestSuite) TestAuthenticateAPIKey_Valid() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", Scopes: []string{"read"}}
+	user := &domain.User{Username: "testuser", IsAdmin: true}
+
+	suite.mockGenerator.On("HashSecret", "tm_key").Return("hashed-key")
+	suite.mockKeyRepo.On("GetAPIKeyByHash", mock.Anything, "hashed-key").Return(apiKey, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(user, nil)
+	suite.mockKeyRepo.On("UpdateLastUsed", mock.Anything, apiKey.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	gotKey, gotUser, err := suite.service.AuthenticateAPIKey(context.Background(), "tm_key")
+
+	suite.NoError(err)
+	suite.Equal(apiKey, gotKey)
+	suite.True(gotUser.IsAdmin)
+	suite.mockKeyRepo.AssertExpectations(suite.T())
+}
+
+// Test AuthenticateAPIKey with an unknown key
+func (suite *APIKeyServiceTestSuite) TestAuthenticateAPIKey_Unknown() {
+	suite.mockGenerator.On("HashSecret", "tm_key").Return("hashed-key")
+	suite.mockKeyRepo.On("GetAPIKeyByHash", mock.Anything, "hashed-key").Return(nil, domain.ErrAPIKeyNotFound)
+
+	_, _, err := suite.service.AuthenticateAPIKey(context.Background(), "tm_key")
+
+	suite.ErrorIs(err, domain.ErrInvalidAPIKey)
+}
+
+// Test AuthenticateAPIKey with a revoked key
+func (suite *APIKeyServiceTestSuite) TestAuthenticateAPIKey_Revoked() {
+	revokedAt := time.Now()
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", RevokedAt: &revokedAt}
+
+	suite.mockGenerator.On("HashSecret", "tm_key").Return("hashed-key")
+	suite.mockKeyRepo.On("GetAPIKeyByHash", mock.Anything, "hashed-key").Return(apiKey, nil)
+
+	_, _, err := suite.service.AuthenticateAPIKey(context.Background(), "tm_key")
+
+	suite.ErrorIs(err, domain.ErrInvalidAPIKey)
+	suite.mockKeyRepo.AssertNotCalled(suite.T(), "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test AuthenticateAPIKey dropping admin rights when two-factor authentication is enforced
+func (suite *APIKeyServiceTestSuite) TestAuthenticateAPIKey_AdminWithoutTwoFactor() {
+	suite.service.RequireAdminTwoFactor = true
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "admin", Scopes: []string{"write"}}
+
+	suite.mockGenerator.On("HashSecret", "tm_key").Return("hashed-key")
+	suite.mockKeyRepo.On("GetAPIKeyByHash", mock.Anything, "hashed-key").Return(apiKey, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "admin").Return(&domain.User{Username: "admin", IsAdmin: true}, nil)
+	suite.mockKeyRepo.On("UpdateLastUsed", mock.Anything, apiKey.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, user, err := suite.service.AuthenticateAPIKey(context.Background(), "tm_key")
+
+	suite.NoError(err)
+	suite.False(user.IsAdmin)
+}
+
+// Test AuthenticateAPIKey rejecting keys of users who haven't verified their email
+func (suite *APIKeyServiceTestSuite) TestAuthenticateAPIKey_EmailNotVerified() {
+	suite.service.RequireEmailVerification = true
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", Scopes: []string{"read"}}
+
+	suite.mockGenerator.On("HashSecret", "tm_key").Return("hashed-key")
+	suite.mockKeyRepo.On("GetAPIKeyByHash", mock.Anything, "hashed-key").Return(apiKey, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Email: "new@example.com"}, nil)
+
+	_, _, err := suite.service.AuthenticateAPIKey(context.Background(), "tm_key")
+
+	suite.ErrorIs(err, domain.ErrEmailNotVerified)
+	suite.mockKeyRepo.AssertNotCalled(suite.T(), "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test RevokeAPIKey
+func (suite *APIKeyServiceTestSuite) TestRevokeAPIKey() {
+	id := uuid.New()
+	suite.mockKeyRepo.On("RevokeAPIKey", mock.Anything, id, "testuser").Return(nil)
+
+	err := suite.service.RevokeAPIKey(context.Background(), "testuser", id)
+
+	suite.NoError(err)
+	suite.mockKeyRepo.AssertExpectations(suite.T())
+}
+
+func TestAPIKeyServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyServiceTestSuite))
+}
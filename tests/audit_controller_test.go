@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditControllerSuite struct {
+	suite.Suite
+	controller  *controllers.AuditController
+	mockService *mocks.AuditServiceInterface
+}
+
+func (suite *AuditControllerSuite) SetupTest() {
+	suite.mockService = new(mocks.AuditServiceInterface)
+	suite.controller = &controllers.AuditController{Service: suite.mockService}
+}
+
+func (suite *AuditControllerSuite) get(query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/audit?"+query, nil)
+	handle(c, suite.controller.GetEntries)
+	return w
+}
+
+func (suite *AuditControllerSuite) TestGetEntries_Filters() {
+	since := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.AuditFilter{Actor: "alice", Action: domain.AuditLoginFailed, Since: since, Limit: 10}
+	entries := []domain.AuditEntry{{ID: uuid.New(), Time: since, Actor: "alice", Action: domain.AuditLoginFailed}}
+	suite.mockService.On("GetEntries", mock.Anything, mock.MatchedBy(func(f domain.AuditFilter) bool {
+		return f.Actor == filter.Actor && f.Action == filter.Action && f.Since.Equal(filter.Since) && f.Until.IsZero() && f.Limit == filter.Limit
+	})).Return(entries, nil)
+
+	w := suite.get("actor=alice&action=login.failed&since=2030-01-01T00:00:00Z&limit=10")
+
+	suite.Equal(http.StatusOK, w.Code)
+	var found []domain.AuditEntry
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &found))
+	suite.Equal(entries[0].ID, found[0].ID)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *AuditControllerSuite) TestGetEntries_InvalidLimit() {
+	w := suite.get("limit=5000")
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem := decodeProblem(suite.T(), w)
+	suite.Require().Len(problem.InvalidParams, 1)
+	suite.Equal("limit", problem.InvalidParams[0].Name)
+	suite.mockService.AssertNotCalled(suite.T(), "GetEntries", mock.Anything, mock.Anything)
+}
+
+func (suite *AuditControllerSuite) TestGetEntries_InvalidTime() {
+	w := suite.get("since=yesterday")
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Contains(decodeProblem(suite.T(), w).Detail, "invalid query")
+	suite.mockService.AssertNotCalled(suite.T(), "GetEntries", mock.Anything, mock.Anything)
+}
+
+func TestAuditControllerSuite(t *testing.T) {
+	suite.Run(t, new(AuditControllerSuite))
+}
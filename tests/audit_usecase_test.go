@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditServiceTestSuite struct {
+	suite.Suite
+	mockAuditRepo *mocks.AuditRepoInterface
+	// entries added to the audit log
+	entries []domain.AuditEntry
+}
+
+func (suite *AuditServiceTestSuite) SetupTest() {
+	suite.mockAuditRepo = new(mocks.AuditRepoInterface)
+	suite.entries = nil
+	suite.mockAuditRepo.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Run(func(args mock.Arguments) {
+		suite.entries = append(suite.entries, *args.Get(1).(*domain.AuditEntry))
+	}).Return(nil).Maybe()
+}
+
+func (suite *AuditServiceTestSuite) TestGetEntries_DefaultLimit() {
+	service := &usecases.AuditService{AuditRepo: suite.mockAuditRepo}
+	entries := []domain.AuditEntry{{ID: uuid.New(), Actor: "admin", Action: domain.AuditTaskCreated}}
+	suite.mockAuditRepo.On("GetEntries", mock.Anything, domain.AuditFilter{Actor: "admin", Limit: 100}).Return(entries, nil)
+
+	found, err := service.GetEntries(context.Background(), domain.AuditFilter{Actor: "admin"})
+
+	suite.NoError(err)
+	suite.Equal(entries, found)
+}
+
+func (suite *AuditServiceTestSuite) TestTaskChanges() {
+	mockTaskRepo := new(mocks.TaskRepoInterface)
+	service := &usecases.TaskService{TaskRepo: mockTaskRepo, Audit: suite.mockAuditRepo}
+	dueDate := time.Now().Add(24 * time.Hour).UTC()
+	before := &domain.Task{ID: uuid.New(), Title: "Draft", Status: "pending", DueDate: dueDate}
+	after := domain.Task{Title: "Final", Status: "completed", DueDate: dueDate}
+	mockTaskRepo.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).Return(before, nil)
+	mockTaskRepo.On("UpdateTaskByID", mock.Anything, before.ID, after).Return(before, nil)
+	mockTaskRepo.On("DeleteTask", mock.Anything, before.ID, "admin", mock.AnythingOfType("time.Time")).Return(before, nil)
+
+	_, err := service.AddTask(context.Background(), "admin", domain.Task{Title: "Draft", Status: "pending", DueDate: dueDate})
+	suite.Require().NoError(err)
+	suite.Require().NoError(service.UpdateTaskByID(context.Background(), "admin", before.ID, after))
+	suite.Require().NoError(service.DeleteTask(context.Background(), "admin", before.ID))
+
+	suite.Require().Len(suite.entries, 3)
+	for _, entry := range suite.entries {
+		suite.Equal("admin", entry.Actor)
+		suite.Equal(before.ID.String(), entry.Target)
+		suite.NotEqual(uuid.Nil, entry.ID)
+		suite.False(entry.Time.IsZero())
+	}
+	suite.Equal(domain.AuditTaskCreated, suite.entries[0].Action)
+	suite.Equal(before, suite.entries[0].After)
+	suite.Equal(domain.AuditTaskUpdated, suite.entries[1].Action)
+	suite.Equal(before, suite.entries[1].Before)
+	suite.Equal("Final", suite.entries[1].After.Title)
+	suite.Equal(before.ID, suite.entries[1].After.ID)
+	suite.Equal(domain.AuditTaskDeleted, suite.entries[2].Action)
+	suite.Equal(before, suite.entries[2].Before)
+	suite.Equal("admin", suite.entries[2].After.DeletedBy)
+	suite.NotNil(suite.entries[2].After.DeletedAt)
+}
+
+// a failed change is not audited
+func (suite *AuditServiceTestSuite) TestTaskChange_Failed() {
+	mockTaskRepo := new(mocks.TaskRepoInterface)
+	service := &usecases.TaskService{TaskRepo: mockTaskRepo, Audit: suite.mockAuditRepo}
+	id := uuid.New()
+	mockTaskRepo.On("DeleteTask", mock.Anything, id, "admin", mock.AnythingOfType("time.Time")).Return(nil, domain.ErrTaskNotFound)
+
+	err := service.DeleteTask(context.Background(), "admin", id)
+
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+	suite.Empty(suite.entries)
+}
+
+func (suite *AuditServiceTestSuite) TestTaskRestoredAndPurged() {
+	mockTaskRepo := new(mocks.TaskRepoInterface)
+	service := &usecases.TaskService{TaskRepo: mockTaskRepo, Audit: suite.mockAuditRepo, TrashRetention: time.Hour}
+	deletedAt := time.Now().UTC()
+	deletedTask := &domain.Task{ID: uuid.New(), Title: "Draft", DeletedAt: &deletedAt, DeletedBy: "admin"}
+	mockTaskRepo.On("RestoreTask", mock.Anything, deletedTask.ID).Return(deletedTask, nil)
+	mockTaskRepo.On("PurgeDeletedTasks", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(3), nil)
+
+	suite.Require().NoError(service.RestoreTask(context.Background(), "admin", deletedTask.ID))
+	_, err := service.PurgeDeletedTasks(context.Background())
+	suite.Require().NoError(err)
+
+	suite.Require().Len(suite.entries, 2)
+	suite.Equal(domain.AuditTaskRestored, suite.entries[0].Action)
+	suite.Equal("admin", suite.entries[0].Actor)
+	suite.Equal(deletedTask, suite.entries[0].Before)
+	suite.Nil(suite.entries[0].After.DeletedAt)
+	suite.Equal(domain.AuditTaskPurged, suite.entries[1].Action)
+	suite.Empty(suite.entries[1].Actor)
+	suite.Contains(suite.entries[1].Detail, "3 tasks deleted before")
+}
+
+func (suite *AuditServiceTestSuite) TestLogins() {
+	mockUserRepo := new(mocks.UserRepoInterface)
+	mockPwdService := new(mocks.PasswordServiceInterface)
+	mockJwtService := new(mocks.JwtServiceInterface)
+	service := &usecases.UserService{UserRepo: mockUserRepo, PasswordService: mockPwdService, JwtService: mockJwtService, Audit: suite.mockAuditRepo}
+	mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Password: "hashed"}, nil)
+	mockPwdService.On("ComparePassword", "hashed", "password123").Return(true)
+	mockPwdService.On("ComparePassword", "hashed", "wrongpassword").Return(false)
+	mockPwdService.On("NeedsRehash", "hashed").Return(false)
+	mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+
+	service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+	service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "wrongpassword"}, "10.0.0.1")
+
+	suite.Require().Len(suite.entries, 2)
+	suite.Equal(domain.AuditLoginSucceeded, suite.entries[0].Action)
+	suite.Equal("testuser", suite.entries[0].Actor)
+	suite.Equal("127.0.0.1", suite.entries[0].ClientIP)
+	suite.Equal(domain.AuditLoginFailed, suite.entries[1].Action)
+	suite.Equal("10.0.0.1", suite.entries[1].ClientIP)
+	suite.Equal(usecases.LoginInvalidCredentials, suite.entries[1].Detail)
+}
+
+func (suite *AuditServiceTestSuite) TestPromoteUser() {
+	mockUserRepo := new(mocks.UserRepoInterface)
+	service := &usecases.UserService{UserRepo: mockUserRepo, Audit: suite.mockAuditRepo}
+	mockUserRepo.On("PromoteUser", mock.Anything, "testuser").Return(nil)
+
+	suite.Require().NoError(service.PromoteUser(context.Background(), "admin", "testuser"))
+
+	suite.Require().Len(suite.entries, 1)
+	suite.Equal(domain.AuditEntry{ID: suite.entries[0].ID, Time: suite.entries[0].Time, Actor: "admin", Action: domain.AuditUserPromoted, Target: "testuser"}, suite.entries[0])
+}
+
+func (suite *AuditServiceTestSuite) TestUnlockUser() {
+	mockUserRepo := new(mocks.UserRepoInterface)
+	service := &usecases.UserService{UserRepo: mockUserRepo, Audit: suite.mockAuditRepo}
+	mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser"}, nil)
+
+	suite.Require().NoError(service.UnlockUser(context.Background(), "admin", "testuser"))
+
+	suite.Require().Len(suite.entries, 1)
+	suite.Equal(domain.AuditEntry{ID: suite.entries[0].ID, Time: suite.entries[0].Time, Actor: "admin", Action: domain.AuditUserUnlocked, Target: "testuser"}, suite.entries[0])
+}
+
+func (suite *AuditServiceTestSuite) TestAPIKeys() {
+	mockKeyRepo := new(mocks.APIKeyRepoInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	service := &usecases.APIKeyService{APIKeyRepo: mockKeyRepo, TokenGenerator: mockGenerator, Audit: suite.mockAuditRepo}
+	mockGenerator.On("GenerateSecret", 32).Return("abcdefghijklmnop", nil)
+	mockGenerator.On("HashSecret", "tm_abcdefghijklmnop").Return("hashed")
+	mockKeyRepo.On("AddAPIKey", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(func(ctx context.Context, apiKey *domain.APIKey) *domain.APIKey { return apiKey }, nil)
+
+	_, apiKey, err := service.CreateAPIKey(context.Background(), "testuser", domain.APIKey{Name: "ci", Scopes: []string{"read", "write"}})
+	suite.Require().NoError(err)
+	mockKeyRepo.On("RevokeAPIKey", mock.Anything, apiKey.ID, "testuser").Return(nil)
+	suite.Require().NoError(service.RevokeAPIKey(context.Background(), "testuser", apiKey.ID))
+
+	suite.Require().Len(suite.entries, 2)
+	suite.Equal(domain.AuditAPIKeyCreated, suite.entries[0].Action)
+	suite.Equal("testuser", suite.entries[0].Actor)
+	suite.Equal(apiKey.ID.String(), suite.entries[0].Target)
+	suite.Equal("scopes: read, write", suite.entries[0].Detail)
+	suite.NotContains(suite.entries[0].Detail, "abcdefghijklmnop")
+	suite.Equal(domain.AuditAPIKeyRevoked, suite.entries[1].Action)
+	suite.Equal("testuser", suite.entries[1].Actor)
+	suite.Equal(apiKey.ID.String(), suite.entries[1].Target)
+}
+
+// revoking a key that isn't there is not audited
+func (suite *AuditServiceTestSuite) TestRevokeAPIKey_NotFound() {
+	mockKeyRepo := new(mocks.APIKeyRepoInterface)
+	service := &usecases.APIKeyService{APIKeyRepo: mockKeyRepo, Audit: suite.mockAuditRepo}
+	id := uuid.New()
+	mockKeyRepo.On("RevokeAPIKey", mock.Anything, id, "testuser").Return(domain.ErrAPIKeyNotFound)
+
+	err := service.RevokeAPIKey(context.Background(), "testuser", id)
+
+	suite.ErrorIs(err, domain.ErrAPIKeyNotFound)
+	suite.Empty(suite.entries)
+}
+
+func (suite *AuditServiceTestSuite) TestCreateInvitation() {
+	mockInvitationRepo := new(mocks.InvitationRepoInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	service := &usecases.InvitationService{InvitationRepo: mockInvitationRepo, TokenGenerator: mockGenerator, Audit: suite.mockAuditRepo}
+	mockGenerator.On("GenerateSecret", 32).Return("secret", nil)
+	mockGenerator.On("HashSecret", "inv_secret").Return("hashed")
+	mockInvitationRepo.On("AddInvitation", mock.Anything, mock.AnythingOfType("*domain.Invitation")).Return(func(ctx context.Context, invitation *domain.Invitation) *domain.Invitation { return invitation }, nil)
+
+	_, invitation, err := service.CreateInvitation(context.Background(), "admin", domain.Invitation{Email: "New@Example.com", IsAdmin: true})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(suite.entries, 1)
+	suite.Equal(domain.AuditInvitationCreated, suite.entries[0].Action)
+	suite.Equal("admin", suite.entries[0].Actor)
+	suite.Equal(invitation.ID.String(), suite.entries[0].Target)
+	suite.Equal("email: new@example.com, admin", suite.entries[0].Detail)
+}
+
+// the action stands when its entry can't be stored, the entry is logged instead
+func (suite *AuditServiceTestSuite) TestRecordFailure() {
+	var logs bytes.Buffer
+	failingAuditRepo := new(mocks.AuditRepoInterface)
+	failingAuditRepo.On("AddEntry", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+	mockUserRepo := new(mocks.UserRepoInterface)
+	service := &usecases.UserService{UserRepo: mockUserRepo, Audit: failingAuditRepo, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+	mockUserRepo.On("PromoteUser", mock.Anything, "testuser").Return(nil)
+
+	err := service.PromoteUser(context.Background(), "admin", "testuser")
+
+	suite.NoError(err)
+	failingAuditRepo.AssertExpectations(suite.T())
+	suite.Contains(logs.String(), "could not record audit entry")
+	suite.Contains(logs.String(), "action=user.promoted")
+	suite.Contains(logs.String(), "connection refused")
+}
+
+func TestAuditServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceTestSuite))
+}
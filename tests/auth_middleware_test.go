@@ -1,16 +1,18 @@
 package tests
 
 import (
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -19,11 +21,14 @@ type AuthMiddlewareSuite struct {
 	suite.Suite
 	router *gin.Engine
 	mockJwtService *mocks.JwtServiceInterface
+	mockAPIKeyService *mocks.APIKeyServiceInterface
 }
 
 func (suite *AuthMiddlewareSuite) SetupTest() {
 	suite.router = gin.Default()
+	suite.router.Use(infrastructure.ErrorMiddleware(testValidator, nil))
 	suite.mockJwtService = new(mocks.JwtServiceInterface)
+	suite.mockAPIKeyService = new(mocks.APIKeyServiceInterface)
 }
 
 // Test AuthMiddleware with valid token
@@ -35,7 +40,7 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_Success() {
 	suite.mockJwtService.On("ValidateAdmin", token).Return(true)
 
 	// Create middleware with admin check enabled
-	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, true))
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, true))
 	suite.router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Access granted")
 	})
@@ -53,9 +58,9 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_Success() {
 // Test AuthMiddleware with invalid token
 
 func (suite *AuthMiddlewareSuite) TestAuthMiddleware_InvalidToken() {
-	suite.mockJwtService.On("ValidateToken", "invalid-token").Return(nil, errors.New("invalid JWT"))
+	suite.mockJwtService.On("ValidateToken", "invalid-token").Return(nil, domain.ErrInvalidJWT)
 
-	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, false))
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, false))
 	suite.router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Access granted")
 	})
@@ -67,13 +72,13 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_InvalidToken() {
 	suite.router.ServeHTTP(rec, req)
 
 	assert.Equal(suite.T(), http.StatusUnauthorized, rec.Code)
-	assert.JSONEq(suite.T(), `{"error":"invalid JWT"}`, rec.Body.String())
+	assert.Equal(suite.T(), "invalid JWT", decodeProblem(suite.T(), rec).Detail)
 }
 
 // Test AuthMiddleware with missing header
 
 func (suite *AuthMiddlewareSuite) TestAuthMiddleware_MissingHeader() {
-	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, false))
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, false))
 	suite.router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Access granted")
 	})
@@ -84,13 +89,13 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_MissingHeader() {
 	suite.router.ServeHTTP(rec, req)
 
 	assert.Equal(suite.T(), http.StatusUnauthorized, rec.Code)
-	assert.JSONEq(suite.T(), `{"error":"authorization header is required"}`, rec.Body.String())
+	assert.Equal(suite.T(), "authorization header is required", decodeProblem(suite.T(), rec).Detail)
 }
 
 // Test AuthMiddleware with invalid token bearer
 
 func (suite *AuthMiddlewareSuite) TestAuthMiddleware_InvalidHeader() {
-	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, false))
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, false))
 	suite.router.GET("/protected", func(c *gin.Context) {
 		c.String(http.StatusOK, "Access granted")
 	})
@@ -102,7 +107,7 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_InvalidHeader() {
 	suite.router.ServeHTTP(rec, req)
 
 	assert.Equal(suite.T(), http.StatusUnauthorized, rec.Code)
-	assert.JSONEq(suite.T(), `{"error": "invalid authorization header"}`, rec.Body.String())
+	assert.Equal(suite.T(), "invalid authorization header", decodeProblem(suite.T(), rec).Detail)
 }
 
 // Test AuthMiddleware with admin check
@@ -112,7 +117,7 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_AdminCheck_Failure() {
 	suite.mockJwtService.On("ValidateToken", "valid-token").Return(token, nil)
 	suite.mockJwtService.On("ValidateAdmin", token).Return(false)
 
-	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, true))
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, true))
 	suite.router.GET("/admin", func(c *gin.Context) {
 		c.String(http.StatusOK, "Admin access")
 	})
@@ -124,7 +129,167 @@ func (suite *AuthMiddlewareSuite) TestAuthMiddleware_AdminCheck_Failure() {
 	suite.router.ServeHTTP(rec, req)
 
 	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
-	assert.JSONEq(suite.T(), `{"error":"Forbidden"}`, rec.Body.String())
+	assert.Equal(suite.T(), "admin rights required", decodeProblem(suite.T(), rec).Detail)
+}
+
+// Test AuthMiddleware exposing the caller to handlers
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_SetsUsername() {
+	token := &jwt.Token{Claims: jwt.MapClaims{"username": "testuser", "is_admin": false}}
+	suite.mockJwtService.On("ValidateToken", "valid-token").Return(token, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, nil, false))
+	suite.router.GET("/me", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username"))
+	})
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	assert.Equal(suite.T(), "testuser", rec.Body.String())
+}
+
+// Test AuthMiddleware rejecting a valid token of a user that may no longer use the api
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_AccountRejected() {
+	token := &jwt.Token{Claims: jwt.MapClaims{"username": "testuser", "is_admin": false}}
+	suite.mockJwtService.On("ValidateToken", "valid-token").Return(token, nil)
+	mockAccounts := new(mocks.AccountCheckerInterface)
+	mockAccounts.On("CheckAccount", mock.Anything, "testuser").Return(domain.ErrEmailNotVerified)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, nil, mockAccounts, false))
+	suite.router.GET("/me", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username"))
+	})
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	assert.Equal(suite.T(), "email not verified", decodeProblem(suite.T(), rec).Detail)
+}
+
+// Test AuthMiddleware with API keys
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_APIKeyHeader() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", Scopes: []string{"read"}}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_key").Return(apiKey, &domain.User{Username: "testuser"}, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, false))
+	suite.router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("username"))
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("X-API-Key", "tm_key")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	assert.Equal(suite.T(), "testuser", rec.Body.String())
+	suite.mockJwtService.AssertNotCalled(suite.T(), "ValidateToken", mock.Anything)
+}
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_APIKeyAuthorizationScheme() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "admin", Scopes: []string{"read", "write", "admin"}}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_key").Return(apiKey, &domain.User{Username: "admin", IsAdmin: true}, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, true))
+	suite.router.POST("/admin", func(c *gin.Context) {
+		c.String(http.StatusOK, "Admin access")
+	})
+
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	req.Header.Set("Authorization", "ApiKey tm_key")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_APIKeyMissingScope() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "admin", Scopes: []string{"read"}}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_key").Return(apiKey, &domain.User{Username: "admin", IsAdmin: true}, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, true))
+	suite.router.POST("/admin", func(c *gin.Context) {
+		c.String(http.StatusOK, "Admin access")
+	})
+
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	req.Header.Set("X-API-Key", "tm_key")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	assert.Equal(suite.T(), "API key is missing the write scope", decodeProblem(suite.T(), rec).Detail)
+}
+
+// a write key of an admin can't use admin-only routes without the admin scope
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_APIKeyMissingAdminScope() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "admin", Scopes: []string{"read", "write"}}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_key").Return(apiKey, &domain.User{Username: "admin", IsAdmin: true}, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, true))
+	suite.router.PATCH("/promote", func(c *gin.Context) {
+		c.String(http.StatusOK, "Admin access")
+	})
+
+	req, _ := http.NewRequest("PATCH", "/promote", nil)
+	req.Header.Set("X-API-Key", "tm_key")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	assert.Equal(suite.T(), "API key is missing the admin scope", decodeProblem(suite.T(), rec).Detail)
+}
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_APIKeyNotAdmin() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", Scopes: []string{"read", "write"}}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_key").Return(apiKey, &domain.User{Username: "testuser"}, nil)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, true))
+	suite.router.POST("/admin", func(c *gin.Context) {
+		c.String(http.StatusOK, "Admin access")
+	})
+
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	req.Header.Set("X-API-Key", "tm_key")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	assert.Equal(suite.T(), "admin rights required", decodeProblem(suite.T(), rec).Detail)
+}
+
+func (suite *AuthMiddlewareSuite) TestAuthMiddleware_InvalidAPIKey() {
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "tm_bad").Return(nil, nil, domain.ErrInvalidAPIKey)
+
+	suite.router.Use(infrastructure.AuthMiddleware(suite.mockJwtService, suite.mockAPIKeyService, nil, false))
+	suite.router.GET("/protected", func(c *gin.Context) {
+		c.String(http.StatusOK, "Access granted")
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("X-API-Key", "tm_bad")
+
+	rec := httptest.NewRecorder()
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, rec.Code)
+	assert.Equal(suite.T(), "invalid API key", decodeProblem(suite.T(), rec).Detail)
 }
 
 func TestAuthMiddlewareSuite(t *testing.T) {
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// the validator of the router, controllers bind requests with it
+var testValidator = infrastructure.NewValidator()
+
+func init() {
+	binding.Validator = testValidator
+}
+
+// run a handler behind the error middleware, like the router does
+func handle(c *gin.Context, handler gin.HandlerFunc) {
+	handler(c)
+	infrastructure.ErrorMiddleware(testValidator, nil)(c)
+}
+
+// decode a problem+json response
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) infrastructure.Problem {
+	assert.Equal(t, infrastructure.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem infrastructure.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return problem
+}
+
+type ErrorMiddlewareSuite struct {
+	suite.Suite
+	router *gin.Engine
+}
+
+func (suite *ErrorMiddlewareSuite) SetupTest() {
+	suite.router = gin.New()
+	suite.router.Use(infrastructure.RequestIDMiddleware(), infrastructure.ErrorMiddleware(testValidator, nil), gin.CustomRecovery(infrastructure.RecoveryHandler))
+}
+
+func (suite *ErrorMiddlewareSuite) serve(handler gin.HandlerFunc, requestID string) *httptest.ResponseRecorder {
+	suite.router.GET("/tasks/:id", handler)
+	req, _ := http.NewRequest("GET", "/tasks/1", nil)
+	if requestID != "" {
+		req.Header.Set(infrastructure.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *ErrorMiddlewareSuite) TestDomainError() {
+	w := suite.serve(func(c *gin.Context) {
+		c.Error(fmt.Errorf("task 1: %w", domain.ErrTaskNotFound))
+	}, "req-1")
+
+	problem := decodeProblem(suite.T(), w)
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(infrastructure.Problem{
+		Type:      "about:blank",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "task not found",
+		Instance:  "/tasks/1",
+		RequestID: "req-1",
+	}, problem)
+	suite.Equal("req-1", w.Header().Get(infrastructure.RequestIDHeader))
+}
+
+func (suite *ErrorMiddlewareSuite) TestValidationError() {
+	w := suite.serve(func(c *gin.Context) {
+		c.Error(&domain.ValidationError{Detail: "request body has invalid fields", Params: []domain.InvalidParam{{Name: "title", Reason: "is required"}}})
+	}, "")
+
+	problem := decodeProblem(suite.T(), w)
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal([]domain.InvalidParam{{Name: "title", Reason: "is required"}}, problem.InvalidParams)
+	// a request id is made up when the client didn't send a usable one
+	suite.NotEmpty(problem.RequestID)
+	suite.Equal(problem.RequestID, w.Header().Get(infrastructure.RequestIDHeader))
+}
+
+func (suite *ErrorMiddlewareSuite) TestInternalErrorHidesDetail() {
+	w := suite.serve(func(c *gin.Context) {
+		c.Error(errors.New("connection refused"))
+	}, "bad id\r\n")
+
+	problem := decodeProblem(suite.T(), w)
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.Empty(problem.Detail)
+	suite.NotContains(w.Body.String(), "connection refused")
+	suite.NotEqual("bad id\r\n", problem.RequestID)
+}
+
+func (suite *ErrorMiddlewareSuite) TestLockoutSetsRetryAfter() {
+	w := suite.serve(func(c *gin.Context) {
+		c.Error(&usecases.TooManyAttemptsError{RetryAfter: 90 * time.Second})
+	}, "")
+
+	problem := decodeProblem(suite.T(), w)
+	suite.Equal(http.StatusTooManyRequests, problem.Status)
+	suite.Equal("90", w.Header().Get("Retry-After"))
+}
+
+func (suite *ErrorMiddlewareSuite) TestPanic() {
+	w := suite.serve(func(c *gin.Context) {
+		panic("boom")
+	}, "")
+
+	suite.Equal(http.StatusInternalServerError, decodeProblem(suite.T(), w).Status)
+}
+
+func (suite *ErrorMiddlewareSuite) TestWrittenResponseIsKept() {
+	w := suite.serve(func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	}, "")
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.JSONEq(`{"message": "ok"}`, w.Body.String())
+}
+
+func TestErrorMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(ErrorMiddlewareSuite))
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthControllerSuite struct {
+	suite.Suite
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+func (suite *HealthControllerSuite) serve(handler gin.HandlerFunc) (*httptest.ResponseRecorder, healthResponse) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	handler(c)
+
+	var response healthResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return w, response
+}
+
+func passing(ctx context.Context) error {
+	return nil
+}
+
+func (suite *HealthControllerSuite) TestLive() {
+	controller := &controllers.HealthController{Checks: []controllers.HealthCheck{
+		{Name: "mongo", Check: func(ctx context.Context) error { return errors.New("down") }},
+	}}
+
+	w, response := suite.serve(controller.Live)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("ok", response.Status)
+}
+
+func (suite *HealthControllerSuite) TestReady() {
+	controller := &controllers.HealthController{Checks: []controllers.HealthCheck{
+		{Name: "mongo", Check: passing},
+		{Name: "workers", Check: passing},
+	}}
+
+	w, response := suite.serve(controller.Ready)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Equal("ok", response.Status)
+	suite.Equal("ok", response.Checks["mongo"].Status)
+	suite.Equal("ok", response.Checks["workers"].Status)
+}
+
+// why a check failed is logged, not shown to the unauthenticated caller
+func (suite *HealthControllerSuite) TestReady_FailingCheck() {
+	var logs bytes.Buffer
+	controller := &controllers.HealthController{
+		Checks: []controllers.HealthCheck{
+			{Name: "mongo", Check: passing},
+			{Name: "indexes", Check: func(ctx context.Context) error { return errors.New("indexes of users on db-0.internal:27017: timeout") }},
+		},
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+
+	w, response := suite.serve(controller.Ready)
+
+	suite.Equal(http.StatusServiceUnavailable, w.Code)
+	suite.Equal("unavailable", response.Status)
+	suite.Equal("ok", response.Checks["mongo"].Status)
+	suite.Equal("unavailable", response.Checks["indexes"].Status)
+	suite.Empty(response.Checks["indexes"].Error)
+	suite.NotContains(w.Body.String(), "db-0.internal")
+	suite.Contains(logs.String(), "check=indexes")
+	suite.Contains(logs.String(), "db-0.internal:27017")
+}
+
+// a hanging dependency makes the server unready instead of hanging the probe
+func (suite *HealthControllerSuite) TestReady_Timeout() {
+	controller := &controllers.HealthController{
+		Checks: []controllers.HealthCheck{{Name: "mongo", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}},
+		Timeout: 10 * time.Millisecond,
+	}
+
+	w, response := suite.serve(controller.Ready)
+
+	suite.Equal(http.StatusServiceUnavailable, w.Code)
+	suite.Equal("unavailable", response.Checks["mongo"].Status)
+}
+
+func TestHealthControllerSuite(t *testing.T) {
+	suite.Run(t, new(HealthControllerSuite))
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyMiddlewareSuite struct {
+	suite.Suite
+	mockService *mocks.IdempotencyServiceInterface
+	router      *gin.Engine
+	// how often the handler ran
+	handled int
+	logs    bytes.Buffer
+}
+
+func (suite *IdempotencyMiddlewareSuite) SetupTest() {
+	suite.mockService = new(mocks.IdempotencyServiceInterface)
+	suite.handled = 0
+	suite.logs.Reset()
+	suite.router = gin.New()
+	suite.router.Use(infrastructure.ErrorMiddleware(testValidator, nil), gin.CustomRecovery(infrastructure.RecoveryHandler))
+	authenticated := func(c *gin.Context) { c.Set("username", "admin") }
+	idempotent := infrastructure.IdempotencyMiddleware(suite.mockService, slog.New(slog.NewTextHandler(&suite.logs, nil)))
+	suite.router.POST("/tasks", authenticated, idempotent, func(c *gin.Context) {
+		suite.handled++
+		c.Header("Location", "/tasks/1")
+		c.Header("X-Request-Id", "request-1")
+		c.JSON(http.StatusCreated, gin.H{"id": "1"})
+	})
+	suite.router.POST("/fail", authenticated, idempotent, func(c *gin.Context) {
+		suite.handled++
+		c.Error(domain.ErrInvalidTaskStatus)
+	})
+	suite.router.POST("/panic", authenticated, idempotent, func(c *gin.Context) {
+		suite.handled++
+		panic("handler bug")
+	})
+}
+
+func (suite *IdempotencyMiddlewareSuite) serve(path string, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(infrastructure.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *IdempotencyMiddlewareSuite) TestWithoutKey() {
+	w := suite.serve("/tasks", "", `{}`)
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal(1, suite.handled)
+	suite.mockService.AssertNotCalled(suite.T(), "Begin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// the first response for a key is stored, with the headers a retry needs
+func (suite *IdempotencyMiddlewareSuite) TestFirstRequest() {
+	record := &domain.IdempotencyRecord{ID: uuid.New()}
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+	suite.mockService.On("Complete", mock.Anything, record, http.StatusCreated, "application/json; charset=utf-8", map[string]string{"Location": "/tasks/1"}, []byte(`{"id":"1"}`)).Return(nil)
+
+	w := suite.serve("/tasks", "key-1", `{"title": "Task"}`)
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal(1, suite.handled)
+	suite.Empty(w.Header().Get(infrastructure.IdempotentReplayedHeader))
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+// retries get the stored response without running the handler
+func (suite *IdempotencyMiddlewareSuite) TestReplay() {
+	record := &domain.IdempotencyRecord{ID: uuid.New(), Status: http.StatusCreated, ContentType: "application/json; charset=utf-8", Headers: map[string]string{"Location": "/tasks/1"}, Body: []byte(`{"id":"1"}`)}
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+
+	w := suite.serve("/tasks", "key-1", `{"title": "Task"}`)
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal(`{"id":"1"}`, w.Body.String())
+	suite.Equal("application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	suite.Equal("/tasks/1", w.Header().Get("Location"))
+	suite.Equal("true", w.Header().Get(infrastructure.IdempotentReplayedHeader))
+	suite.Equal(0, suite.handled)
+}
+
+// requests are told apart by their path and body
+func (suite *IdempotencyMiddlewareSuite) TestRequestHash() {
+	suite.mockService.On("Begin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.ErrIdempotencyKeyReused)
+
+	suite.serve("/tasks", "key-1", `{"title": "Task"}`)
+	suite.serve("/tasks", "key-1", `{"title": "Task"}`)
+	suite.serve("/tasks", "key-1", `{"title": "Other"}`)
+	suite.serve("/fail", "key-1", `{"title": "Task"}`)
+
+	hashes := make([]string, 0, 4)
+	for _, call := range suite.mockService.Calls {
+		hashes = append(hashes, call.Arguments.String(3))
+	}
+	suite.Equal(hashes[0], hashes[1])
+	suite.NotEqual(hashes[0], hashes[2])
+	suite.NotEqual(hashes[0], hashes[3])
+}
+
+func (suite *IdempotencyMiddlewareSuite) TestKeyReused() {
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(nil, domain.ErrIdempotencyKeyReused)
+
+	w := suite.serve("/tasks", "key-1", `{"title": "Other"}`)
+
+	suite.Equal(http.StatusUnprocessableEntity, w.Code)
+	suite.Equal(domain.ErrIdempotencyKeyReused.Message, decodeProblem(suite.T(), w).Detail)
+	suite.Equal(0, suite.handled)
+}
+
+func (suite *IdempotencyMiddlewareSuite) TestInProgress() {
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(nil, domain.ErrIdempotencyKeyInProgress)
+
+	w := suite.serve("/tasks", "key-1", `{}`)
+
+	suite.Equal(http.StatusConflict, w.Code)
+	suite.Equal(0, suite.handled)
+}
+
+// a response that can't be stored is still sent, the error is logged
+func (suite *IdempotencyMiddlewareSuite) TestCompleteFails() {
+	record := &domain.IdempotencyRecord{ID: uuid.New()}
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+	suite.mockService.On("Complete", mock.Anything, record, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+	w := suite.serve("/tasks", "key-1", `{}`)
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Contains(suite.logs.String(), "could not store idempotent response")
+	suite.Contains(suite.logs.String(), "connection refused")
+}
+
+// a failed request keeps no response, its retry runs again
+func (suite *IdempotencyMiddlewareSuite) TestFailedRequestReleasesKey() {
+	record := &domain.IdempotencyRecord{ID: uuid.New()}
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+	suite.mockService.On("Release", mock.Anything, record).Return(nil)
+
+	w := suite.serve("/fail", "key-1", `{}`)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockService.AssertNotCalled(suite.T(), "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// a panicking handler releases the key instead of holding it until the lease runs out
+func (suite *IdempotencyMiddlewareSuite) TestPanicReleasesKey() {
+	record := &domain.IdempotencyRecord{ID: uuid.New()}
+	suite.mockService.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+	suite.mockService.On("Release", mock.Anything, record).Return(nil)
+
+	w := suite.serve("/panic", "key-1", `{}`)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
+	suite.Equal(1, suite.handled)
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockService.AssertNotCalled(suite.T(), "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *IdempotencyMiddlewareSuite) TestInvalidKey() {
+	w := suite.serve("/tasks", strings.Repeat("k", 256), `{}`)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal(infrastructure.IdempotencyKeyHeader, decodeProblem(suite.T(), w).InvalidParams[0].Name)
+	suite.Equal(0, suite.handled)
+}
+
+func TestIdempotencyMiddlewareSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyMiddlewareSuite))
+}
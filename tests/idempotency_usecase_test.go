@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencyServiceTestSuite struct {
+	suite.Suite
+	service  *usecases.IdempotencyService
+	mockRepo *mocks.IdempotencyRepoInterface
+}
+
+func (suite *IdempotencyServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.IdempotencyRepoInterface)
+	suite.service = &usecases.IdempotencyService{IdempotencyRepo: suite.mockRepo, Window: time.Hour, Lease: time.Minute}
+}
+
+func (suite *IdempotencyServiceTestSuite) TestBegin_NewKey() {
+	suite.mockRepo.On("Reserve", mock.Anything, mock.AnythingOfType("*domain.IdempotencyRecord")).Return(nil, nil)
+
+	record, err := suite.service.Begin(context.Background(), "admin", "key-1", "hash")
+
+	suite.Require().NoError(err)
+	suite.Equal("admin", record.Username)
+	suite.Equal("key-1", record.Key)
+	suite.Equal("hash", record.RequestHash)
+	suite.Zero(record.Status)
+	// the key is only held for the lease until there is a response to keep
+	suite.Equal(time.Minute, record.ExpiresAt.Sub(record.CreatedAt))
+}
+
+// a retry gets the stored response
+func (suite *IdempotencyServiceTestSuite) TestBegin_Replay() {
+	stored := &domain.IdempotencyRecord{ID: uuid.New(), Username: "admin", Key: "key-1", RequestHash: "hash", Status: http.StatusCreated, Body: []byte(`{}`)}
+	suite.mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(stored, nil)
+
+	record, err := suite.service.Begin(context.Background(), "admin", "key-1", "hash")
+
+	suite.NoError(err)
+	suite.Equal(stored, record)
+}
+
+func (suite *IdempotencyServiceTestSuite) TestBegin_DifferentRequest() {
+	stored := &domain.IdempotencyRecord{ID: uuid.New(), RequestHash: "hash", Status: http.StatusCreated}
+	suite.mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(stored, nil)
+
+	record, err := suite.service.Begin(context.Background(), "admin", "key-1", "other hash")
+
+	suite.Nil(record)
+	suite.ErrorIs(err, domain.ErrIdempotencyKeyReused)
+}
+
+func (suite *IdempotencyServiceTestSuite) TestBegin_InProgress() {
+	stored := &domain.IdempotencyRecord{ID: uuid.New(), RequestHash: "hash"}
+	suite.mockRepo.On("Reserve", mock.Anything, mock.Anything).Return(stored, nil)
+
+	record, err := suite.service.Begin(context.Background(), "admin", "key-1", "hash")
+
+	suite.Nil(record)
+	suite.ErrorIs(err, domain.ErrIdempotencyKeyInProgress)
+}
+
+func (suite *IdempotencyServiceTestSuite) TestCompleteAndRelease() {
+	createdAt := time.Now().UTC()
+	record := &domain.IdempotencyRecord{ID: uuid.New(), CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Minute)}
+	suite.mockRepo.On("Complete", mock.Anything, record).Return(nil)
+	suite.mockRepo.On("Delete", mock.Anything, record.ID).Return(nil)
+
+	suite.NoError(suite.service.Complete(context.Background(), record, http.StatusCreated, "application/json", map[string]string{"Location": "/tasks/1"}, []byte(`{}`)))
+	suite.NoError(suite.service.Release(context.Background(), record))
+
+	suite.Equal(http.StatusCreated, record.Status)
+	suite.Equal("application/json", record.ContentType)
+	suite.Equal(map[string]string{"Location": "/tasks/1"}, record.Headers)
+	suite.Equal([]byte(`{}`), record.Body)
+	suite.Equal(createdAt.Add(time.Hour), record.ExpiresAt)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func TestIdempotencyServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyServiceTestSuite))
+}
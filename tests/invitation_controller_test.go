@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type InvitationControllerSuite struct {
+	suite.Suite
+	controller  *controllers.InvitationController
+	mockService *mocks.InvitationServiceInterface
+}
+
+func (suite *InvitationControllerSuite) SetupTest() {
+	suite.mockService = new(mocks.InvitationServiceInterface)
+	suite.controller = &controllers.InvitationController{Service: suite.mockService}
+}
+
+func (suite *InvitationControllerSuite) TestCreateInvitation_Success() {
+	request := domain.Invitation{Email: "new@example.com", Username: "newuser"}
+	created := &domain.Invitation{ID: uuid.New(), Email: "new@example.com", Username: "newuser", CreatedBy: "admin"}
+	suite.mockService.On("CreateInvitation", mock.Anything, "admin", request).Return("inv_token", created, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/invitations", bytes.NewBufferString(`{"email": "new@example.com", "username": "newuser"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "admin")
+
+	handle(c, suite.controller.CreateInvitation)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "inv_token")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *InvitationControllerSuite) TestCreateInvitation_InvalidEmail() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/invitations", bytes.NewBufferString(`{"email": "not-an-email"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "admin")
+
+	handle(c, suite.controller.CreateInvitation)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "CreateInvitation", mock.Anything)
+}
+
+func (suite *InvitationControllerSuite) TestDeleteInvitation_NotFound() {
+	id := uuid.New()
+	suite.mockService.On("DeleteInvitation", mock.Anything, id).Return(domain.ErrInvitationNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("DELETE", "/invitations/"+id.String(), nil)
+
+	handle(c, suite.controller.DeleteInvitation)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func TestInvitationControllerSuite(t *testing.T) {
+	suite.Run(t, new(InvitationControllerSuite))
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type InvitationServiceTestSuite struct {
+	suite.Suite
+	service            *usecases.InvitationService
+	mockInvitationRepo *mocks.InvitationRepoInterface
+	mockGenerator      *mocks.TokenGeneratorInterface
+}
+
+func (suite *InvitationServiceTestSuite) SetupTest() {
+	suite.mockInvitationRepo = new(mocks.InvitationRepoInterface)
+	suite.mockGenerator = new(mocks.TokenGeneratorInterface)
+	suite.service = &usecases.InvitationService{
+		InvitationRepo: suite.mockInvitationRepo,
+		TokenGenerator: suite.mockGenerator,
+	}
+}
+
+// Test CreateInvitation with the default expiry
+func (suite *InvitationServiceTestSuite) TestCreateInvitation() {
+	suite.mockGenerator.On("GenerateSecret", 32).Return("secret", nil)
+	suite.mockGenerator.On("HashSecret", "inv_secret").Return("hashed-token")
+	suite.mockInvitationRepo.On("AddInvitation", mock.Anything, mock.AnythingOfType("*domain.Invitation")).Return(func(_ context.Context, invitation *domain.Invitation) *domain.Invitation { return invitation }, nil)
+
+	token, invitation, err := suite.service.CreateInvitation(context.Background(), "admin", domain.Invitation{Username: "newuser", IsAdmin: true})
+
+	suite.NoError(err)
+	suite.Equal("inv_secret", token)
+	suite.Equal("hashed-token", invitation.TokenHash)
+	suite.Equal("admin", invitation.CreatedBy)
+	suite.True(invitation.IsAdmin)
+	suite.WithinDuration(time.Now().Add(usecases.DefaultInvitationExpiry), invitation.ExpiresAt, time.Minute)
+}
+
+// Test CreateInvitation with an expiry in the past
+func (suite *InvitationServiceTestSuite) TestCreateInvitation_PastExpiry() {
+	_, _, err := suite.service.CreateInvitation(context.Background(), "admin", domain.Invitation{ExpiresAt: time.Now().Add(-time.Hour)})
+
+	suite.ErrorIs(err, domain.ErrExpiryInPast)
+	suite.mockInvitationRepo.AssertNotCalled(suite.T(), "AddInvitation", mock.Anything, mock.Anything)
+}
+
+func TestInvitationServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(InvitationServiceTestSuite))
+}
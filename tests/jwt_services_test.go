@@ -81,6 +81,34 @@ func (suite *JwtServiceSuite) TestValidateToken_ExpiredToken() {
 	assert.Equal(suite.T(), "invalid JWT", err.Error())
 }
 
+func (suite *JwtServiceSuite) TestValidateToken_RejectsChallengeToken() {
+	challengeToken, err := suite.service.GenerateChallengeToken("testuser")
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.service.ValidateToken(challengeToken)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "invalid JWT", err.Error())
+}
+
+// Test challenge tokens
+
+func (suite *JwtServiceSuite) TestValidateChallengeToken_Success() {
+	challengeToken, err := suite.service.GenerateChallengeToken("testuser")
+	assert.NoError(suite.T(), err)
+
+	username, err := suite.service.ValidateChallengeToken(challengeToken)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "testuser", username)
+}
+
+func (suite *JwtServiceSuite) TestValidateChallengeToken_RejectsAccessToken() {
+	token, _ := suite.service.GenerateToken("testuser", true)
+
+	_, err := suite.service.ValidateChallengeToken(token)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "invalid challenge token", err.Error())
+}
+
 // Test ValidateAdmin
 
 func (suite *JwtServiceSuite) TestValidateAdmin_True() {
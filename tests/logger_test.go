@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+)
+
+type LoggerSuite struct {
+	suite.Suite
+	output *bytes.Buffer
+	router *gin.Engine
+}
+
+func (suite *LoggerSuite) SetupTest() {
+	suite.output = new(bytes.Buffer)
+	logger := infrastructure.NewLogger(suite.output, slog.LevelInfo, "json")
+	suite.router = gin.New()
+	suite.router.Use(infrastructure.RequestIDMiddleware(), infrastructure.AccessLogMiddleware(logger), infrastructure.ErrorMiddleware(testValidator, logger))
+	suite.router.GET("/tasks/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	suite.router.GET("/fail", func(c *gin.Context) {
+		c.Error(errors.New("mongo is down"))
+	})
+}
+
+// the json records logged so far
+func (suite *LoggerSuite) records() []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(suite.output.String()), "\n") {
+		var record map[string]any
+		suite.Require().NoError(json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func (suite *LoggerSuite) serve(path string, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if requestID != "" {
+		req.Header.Set(infrastructure.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *LoggerSuite) TestAccessLog() {
+	suite.serve("/tasks/1", "req-1")
+
+	records := suite.records()
+	suite.Require().Len(records, 1)
+	suite.Equal("INFO", records[0]["level"])
+	suite.Equal("request", records[0]["msg"])
+	suite.Equal("req-1", records[0]["request_id"])
+	suite.Equal("/tasks/:id", records[0]["route"])
+	suite.Equal("/tasks/1", records[0]["path"])
+	suite.Equal(float64(http.StatusNoContent), records[0]["status"])
+}
+
+// the error and the access log of a failed request share the id of the response
+func (suite *LoggerSuite) TestErrorLog_GeneratedRequestID() {
+	w := suite.serve("/fail", "")
+
+	requestID := w.Header().Get(infrastructure.RequestIDHeader)
+	suite.Require().NotEmpty(requestID)
+	suite.Equal(requestID, decodeProblem(suite.T(), w).RequestID)
+	records := suite.records()
+	suite.Require().Len(records, 2)
+	suite.Equal("request failed", records[0]["msg"])
+	suite.Equal("mongo is down", records[0]["error"])
+	suite.Equal("ERROR", records[1]["level"])
+	for _, record := range records {
+		suite.Equal(requestID, record["request_id"])
+	}
+}
+
+func (suite *LoggerSuite) TestNewLogger_TraceIDs() {
+	ctx, span := otel.Tracer("test").Start(context.Background(), "logged span")
+	defer span.End()
+
+	infrastructure.NewLogger(suite.output, slog.LevelInfo, "json").InfoContext(infrastructure.ContextWithRequestID(ctx, "req-2"), "traced")
+
+	record := suite.records()[0]
+	suite.Equal("req-2", record["request_id"])
+	suite.Equal(span.SpanContext().TraceID().String(), record["trace_id"])
+	suite.Equal(span.SpanContext().SpanID().String(), record["span_id"])
+}
+
+func (suite *LoggerSuite) TestNewLogger_LevelAndFormat() {
+	logger := infrastructure.NewLogger(suite.output, slog.LevelWarn, "text").With("component", "workers")
+
+	logger.Info("hidden")
+	logger.WarnContext(infrastructure.ContextWithRequestID(context.Background(), "req-3"), "shown")
+
+	suite.Equal("", infrastructure.RequestIDFromContext(context.Background()))
+	suite.NotContains(suite.output.String(), "hidden")
+	suite.Contains(suite.output.String(), "level=WARN msg=shown component=workers request_id=req-3")
+}
+
+func TestLoggerSuite(t *testing.T) {
+	suite.Run(t, new(LoggerSuite))
+}
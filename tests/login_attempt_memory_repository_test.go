@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/stretchr/testify/suite"
+)
+
+type InMemoryLoginAttemptRepositorySuite struct {
+	suite.Suite
+	repo *repositories.InMemoryLoginAttemptRepository
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) SetupTest() {
+	suite.repo = repositories.NewInMemoryLoginAttemptRepository()
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) TestGetAttempt_Empty() {
+	attempt, err := suite.repo.GetAttempt(context.Background(), "user:testuser")
+
+	suite.NoError(err)
+	suite.Equal("user:testuser", attempt.Key)
+	suite.Zero(attempt.Failures)
+	suite.True(attempt.LockedUntil.IsZero())
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) TestRecordFailure_Counts() {
+	suite.repo.RecordFailure(context.Background(), "user:testuser", time.Minute)
+	attempt, err := suite.repo.RecordFailure(context.Background(), "user:testuser", time.Minute)
+
+	suite.NoError(err)
+	suite.Equal(2, attempt.Failures)
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) TestRecordFailure_OutsideWindow() {
+	suite.repo.RecordFailure(context.Background(), "user:testuser", time.Minute)
+	// a zero window treats every earlier failure as stale
+	attempt, err := suite.repo.RecordFailure(context.Background(), "user:testuser", 0)
+
+	suite.NoError(err)
+	suite.Equal(1, attempt.Failures)
+}
+
+// lockouts longer than the window keep growing up to MaxLockout instead of starting over
+func (suite *InMemoryLoginAttemptRepositorySuite) TestConsecutiveLockouts() {
+	policy := usecases.LockoutPolicy{MaxFailures: 1, BaseLockout: 10 * time.Millisecond, MaxLockout: 80 * time.Millisecond, Window: 15 * time.Millisecond}
+	var lockouts []time.Duration
+	for range 5 {
+		attempt, err := suite.repo.RecordFailure(context.Background(), "user:testuser", policy.Window)
+		suite.Require().NoError(err)
+		lockout := policy.LockoutFor(attempt.Failures)
+		lockouts = append(lockouts, lockout)
+		suite.Require().NoError(suite.repo.LockUntil(context.Background(), "user:testuser", attempt.LastFailure.Add(lockout)))
+		// the next attempt comes right after the lockout
+		time.Sleep(lockout + time.Millisecond)
+	}
+
+	suite.Equal([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond, 80 * time.Millisecond}, lockouts)
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) TestLockUntilAndReset() {
+	until := time.Now().Add(time.Minute)
+	suite.repo.RecordFailure(context.Background(), "ip:127.0.0.1", time.Minute)
+	suite.NoError(suite.repo.LockUntil(context.Background(), "ip:127.0.0.1", until))
+
+	attempt, _ := suite.repo.GetAttempt(context.Background(), "ip:127.0.0.1")
+	suite.Equal(until, attempt.LockedUntil)
+
+	suite.NoError(suite.repo.Reset(context.Background(), "ip:127.0.0.1"))
+	attempt, _ = suite.repo.GetAttempt(context.Background(), "ip:127.0.0.1")
+	suite.Zero(attempt.Failures)
+	suite.True(attempt.LockedUntil.IsZero())
+}
+
+func (suite *InMemoryLoginAttemptRepositorySuite) TestDeleteStale() {
+	suite.repo.RecordFailure(context.Background(), "user:stale", time.Minute)
+	suite.repo.RecordFailure(context.Background(), "user:locked", time.Minute)
+	later := time.Now().UTC().Add(25 * time.Hour)
+	suite.repo.LockUntil(context.Background(), "user:locked", later.Add(time.Hour))
+
+	suite.Equal(1, suite.repo.DeleteStale(later))
+
+	attempt, _ := suite.repo.GetAttempt(context.Background(), "user:stale")
+	suite.Zero(attempt.Failures)
+	attempt, _ = suite.repo.GetAttempt(context.Background(), "user:locked")
+	suite.Equal(1, attempt.Failures)
+}
+
+func TestInMemoryLoginAttemptRepositorySuite(t *testing.T) {
+	suite.Run(t, new(InMemoryLoginAttemptRepositorySuite))
+}
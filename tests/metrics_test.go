@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+	metrics *infrastructure.Metrics
+}
+
+func (suite *MetricsSuite) SetupTest() {
+	suite.metrics = infrastructure.NewMetrics()
+}
+
+// the metrics in the prometheus text format
+func (suite *MetricsSuite) scrape() string {
+	server := httptest.NewServer(suite.metrics.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	return string(body)
+}
+
+func (suite *MetricsSuite) TestMiddleware() {
+	router := gin.New()
+	router.Use(suite.metrics.Middleware(), infrastructure.ErrorMiddleware(testValidator, nil))
+	router.GET("/tasks/:id", func(c *gin.Context) {
+		c.Error(domain.ErrTaskNotFound)
+	})
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(domain.ErrRouteNotFound)
+	})
+
+	for _, path := range []string{"/tasks/1", "/tasks/2", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	metrics := suite.scrape()
+	// requests are grouped by route, not by path
+	suite.Contains(metrics, `http_requests_total{method="GET",route="/tasks/:id",status="404"} 2`)
+	suite.Contains(metrics, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	suite.Contains(metrics, `http_request_duration_seconds_count{method="GET",route="/tasks/:id",status="404"} 2`)
+}
+
+func (suite *MetricsSuite) TestObserveLogin() {
+	suite.metrics.ObserveLogin(usecases.LoginSucceeded)
+	suite.metrics.ObserveLogin(usecases.LoginInvalidCredentials)
+	suite.metrics.ObserveLogin(usecases.LoginInvalidCredentials)
+
+	metrics := suite.scrape()
+	suite.Contains(metrics, `auth_logins_total{result="success"} 1`)
+	suite.Contains(metrics, `auth_logins_total{result="invalid_credentials"} 2`)
+}
+
+func (suite *MetricsSuite) TestCountTokenFailures() {
+	mockJwtService := new(mocks.JwtServiceInterface)
+	mockJwtService.On("ValidateToken", "valid-token").Return(nil, nil)
+	mockJwtService.On("ValidateToken", "invalid-token").Return(nil, domain.ErrInvalidJWT)
+	jwtService := suite.metrics.CountTokenFailures(mockJwtService)
+
+	_, err := jwtService.ValidateToken("valid-token")
+	suite.NoError(err)
+	_, err = jwtService.ValidateToken("invalid-token")
+	suite.ErrorIs(err, domain.ErrInvalidJWT)
+
+	suite.Contains(suite.scrape(), "auth_token_validation_failures_total 1")
+}
+
+func (suite *MetricsSuite) TestObserveOperation() {
+	suite.metrics.ObserveOperation("task", "GetTasks", 20*time.Millisecond)
+
+	metrics := suite.scrape()
+	suite.Contains(metrics, `repository_operation_duration_seconds_count{method="GetTasks",repository="task"} 1`)
+	suite.Contains(metrics, `repository_operation_duration_seconds_bucket{method="GetTasks",repository="task",le="0.025"} 1`)
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
@@ -0,0 +1,159 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// APIKeyRepoInterface is an autogenerated mock type for the APIKeyRepoInterface type
+type APIKeyRepoInterface struct {
+	mock.Mock
+}
+
+// AddAPIKey provides a mock function with given fields: ctx, apiKey
+func (_m *APIKeyRepoInterface) AddAPIKey(ctx context.Context, apiKey *domain.APIKey) (*domain.APIKey, error) {
+	ret := _m.Called(ctx, apiKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddAPIKey")
+	}
+
+	var r0 *domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.APIKey) (*domain.APIKey, error)); ok {
+		return rf(ctx, apiKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.APIKey) *domain.APIKey); ok {
+		r0 = rf(ctx, apiKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.APIKey) error); ok {
+		r1 = rf(ctx, apiKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *APIKeyRepoInterface) GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByHash")
+	}
+
+	var r0 *domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.APIKey, error)); ok {
+		return rf(ctx, keyHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.APIKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKeys provides a mock function with given fields: ctx, username
+func (_m *APIKeyRepoInterface) GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeys")
+	}
+
+	var r0 []domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.APIKey, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.APIKey); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id, username
+func (_m *APIKeyRepoInterface) RevokeAPIKey(ctx context.Context, id uuid.UUID, username string) error {
+	ret := _m.Called(ctx, id, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, id, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateLastUsed provides a mock function with given fields: ctx, id, usedAt
+func (_m *APIKeyRepoInterface) UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLastUsed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r0 = rf(ctx, id, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAPIKeyRepoInterface creates a new instance of APIKeyRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAPIKeyRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *APIKeyRepoInterface {
+	mock := &APIKeyRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,155 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// APIKeyServiceInterface is an autogenerated mock type for the APIKeyServiceInterface type
+type APIKeyServiceInterface struct {
+	mock.Mock
+}
+
+// AuthenticateAPIKey provides a mock function with given fields: ctx, key
+func (_m *APIKeyServiceInterface) AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, *domain.User, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthenticateAPIKey")
+	}
+
+	var r0 *domain.APIKey
+	var r1 *domain.User
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.APIKey, *domain.User, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *domain.User); ok {
+		r1 = rf(ctx, key)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, username, apiKey
+func (_m *APIKeyServiceInterface) CreateAPIKey(ctx context.Context, username string, apiKey domain.APIKey) (string, *domain.APIKey, error) {
+	ret := _m.Called(ctx, username, apiKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 string
+	var r1 *domain.APIKey
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.APIKey) (string, *domain.APIKey, error)); ok {
+		return rf(ctx, username, apiKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.APIKey) string); ok {
+		r0 = rf(ctx, username, apiKey)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.APIKey) *domain.APIKey); ok {
+		r1 = rf(ctx, username, apiKey)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, domain.APIKey) error); ok {
+		r2 = rf(ctx, username, apiKey)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetAPIKeys provides a mock function with given fields: ctx, username
+func (_m *APIKeyServiceInterface) GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeys")
+	}
+
+	var r0 []domain.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]domain.APIKey, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []domain.APIKey); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, username, id
+func (_m *APIKeyServiceInterface) RevokeAPIKey(ctx context.Context, username string, id uuid.UUID) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAPIKeyServiceInterface creates a new instance of APIKeyServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAPIKeyServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *APIKeyServiceInterface {
+	mock := &APIKeyServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AccountCheckerInterface is an autogenerated mock type for the AccountCheckerInterface type
+type AccountCheckerInterface struct {
+	mock.Mock
+}
+
+// CheckAccount provides a mock function with given fields: ctx, username
+func (_m *AccountCheckerInterface) CheckAccount(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAccountCheckerInterface creates a new instance of AccountCheckerInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAccountCheckerInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AccountCheckerInterface {
+	mock := &AccountCheckerInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,59 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditServiceInterface is an autogenerated mock type for the AuditServiceInterface type
+type AuditServiceInterface struct {
+	mock.Mock
+}
+
+// GetEntries provides a mock function with given fields: ctx, filter
+func (_m *AuditServiceInterface) GetEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetEntries")
+	}
+
+	var r0 []domain.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditFilter) ([]domain.AuditEntry, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditFilter) []domain.AuditEntry); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewAuditServiceInterface creates a new instance of AuditServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditServiceInterface {
+	mock := &AuditServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
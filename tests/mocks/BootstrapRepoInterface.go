@@ -0,0 +1,56 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// BootstrapRepoInterface is an autogenerated mock type for the BootstrapRepoInterface type
+type BootstrapRepoInterface struct {
+	mock.Mock
+}
+
+// ClaimFirstAdmin provides a mock function with given fields: ctx, username
+func (_m *BootstrapRepoInterface) ClaimFirstAdmin(ctx context.Context, username string) (bool, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimFirstAdmin")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewBootstrapRepoInterface creates a new instance of BootstrapRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewBootstrapRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *BootstrapRepoInterface {
+	mock := &BootstrapRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
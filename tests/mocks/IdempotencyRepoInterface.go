@@ -0,0 +1,97 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// IdempotencyRepoInterface is an autogenerated mock type for the IdempotencyRepoInterface type
+type IdempotencyRepoInterface struct {
+	mock.Mock
+}
+
+// Complete provides a mock function with given fields: ctx, record
+func (_m *IdempotencyRepoInterface) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	ret := _m.Called(ctx, record)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Complete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *IdempotencyRepoInterface) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reserve provides a mock function with given fields: ctx, record
+func (_m *IdempotencyRepoInterface) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	ret := _m.Called(ctx, record)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reserve")
+	}
+
+	var r0 *domain.IdempotencyRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)); ok {
+		return rf(ctx, record)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyRecord) *domain.IdempotencyRecord); ok {
+		r0 = rf(ctx, record)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.IdempotencyRecord) error); ok {
+		r1 = rf(ctx, record)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIdempotencyRepoInterface creates a new instance of IdempotencyRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIdempotencyRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IdempotencyRepoInterface {
+	mock := &IdempotencyRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,95 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyServiceInterface is an autogenerated mock type for the IdempotencyServiceInterface type
+type IdempotencyServiceInterface struct {
+	mock.Mock
+}
+
+// Begin provides a mock function with given fields: ctx, username, key, requestHash
+func (_m *IdempotencyServiceInterface) Begin(ctx context.Context, username string, key string, requestHash string) (*domain.IdempotencyRecord, error) {
+	ret := _m.Called(ctx, username, key, requestHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Begin")
+	}
+
+	var r0 *domain.IdempotencyRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.IdempotencyRecord, error)); ok {
+		return rf(ctx, username, key, requestHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.IdempotencyRecord); ok {
+		r0 = rf(ctx, username, key, requestHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, username, key, requestHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Complete provides a mock function with given fields: ctx, record, status, contentType, headers, body
+func (_m *IdempotencyServiceInterface) Complete(ctx context.Context, record *domain.IdempotencyRecord, status int, contentType string, headers map[string]string, body []byte) error {
+	ret := _m.Called(ctx, record, status, contentType, headers, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Complete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyRecord, int, string, map[string]string, []byte) error); ok {
+		r0 = rf(ctx, record, status, contentType, headers, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, record
+func (_m *IdempotencyServiceInterface) Release(ctx context.Context, record *domain.IdempotencyRecord) error {
+	ret := _m.Called(ctx, record)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Release")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.IdempotencyRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewIdempotencyServiceInterface creates a new instance of IdempotencyServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIdempotencyServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IdempotencyServiceInterface {
+	mock := &IdempotencyServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
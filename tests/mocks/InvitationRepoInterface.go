@@ -0,0 +1,177 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// InvitationRepoInterface is an autogenerated mock type for the InvitationRepoInterface type
+type InvitationRepoInterface struct {
+	mock.Mock
+}
+
+// AddInvitation provides a mock function with given fields: ctx, invitation
+func (_m *InvitationRepoInterface) AddInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, invitation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddInvitation")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Invitation) (*domain.Invitation, error)); ok {
+		return rf(ctx, invitation)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Invitation) *domain.Invitation); ok {
+		r0 = rf(ctx, invitation)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Invitation) error); ok {
+		r1 = rf(ctx, invitation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteInvitation provides a mock function with given fields: ctx, id
+func (_m *InvitationRepoInterface) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetInvitationByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *InvitationRepoInterface) GetInvitationByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInvitationByHash")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Invitation, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Invitation); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInvitations provides a mock function with given fields: ctx
+func (_m *InvitationRepoInterface) GetInvitations(ctx context.Context) ([]domain.Invitation, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInvitations")
+	}
+
+	var r0 []domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Invitation, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Invitation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseInvitation provides a mock function with given fields: ctx, id
+func (_m *InvitationRepoInterface) ReleaseInvitation(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseInvitation provides a mock function with given fields: ctx, id, username, usedAt
+func (_m *InvitationRepoInterface) UseInvitation(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, username, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) error); ok {
+		r0 = rf(ctx, id, username, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewInvitationRepoInterface creates a new instance of InvitationRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewInvitationRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *InvitationRepoInterface {
+	mock := &InvitationRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,116 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// InvitationServiceInterface is an autogenerated mock type for the InvitationServiceInterface type
+type InvitationServiceInterface struct {
+	mock.Mock
+}
+
+// CreateInvitation provides a mock function with given fields: ctx, createdBy, invitation
+func (_m *InvitationServiceInterface) CreateInvitation(ctx context.Context, createdBy string, invitation domain.Invitation) (string, *domain.Invitation, error) {
+	ret := _m.Called(ctx, createdBy, invitation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateInvitation")
+	}
+
+	var r0 string
+	var r1 *domain.Invitation
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Invitation) (string, *domain.Invitation, error)); ok {
+		return rf(ctx, createdBy, invitation)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Invitation) string); ok {
+		r0 = rf(ctx, createdBy, invitation)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Invitation) *domain.Invitation); ok {
+		r1 = rf(ctx, createdBy, invitation)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, domain.Invitation) error); ok {
+		r2 = rf(ctx, createdBy, invitation)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteInvitation provides a mock function with given fields: ctx, id
+func (_m *InvitationServiceInterface) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetInvitations provides a mock function with given fields: ctx
+func (_m *InvitationServiceInterface) GetInvitations(ctx context.Context) ([]domain.Invitation, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInvitations")
+	}
+
+	var r0 []domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Invitation, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Invitation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewInvitationServiceInterface creates a new instance of InvitationServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewInvitationServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *InvitationServiceInterface {
+	mock := &InvitationServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -12,6 +12,34 @@ type JwtServiceInterface struct {
 	mock.Mock
 }
 
+// GenerateChallengeToken provides a mock function with given fields: username
+func (_m *JwtServiceInterface) GenerateChallengeToken(username string) (string, error) {
+	ret := _m.Called(username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateChallengeToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(username)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(username)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GenerateToken provides a mock function with given fields: username, isAdmin
 func (_m *JwtServiceInterface) GenerateToken(username string, isAdmin bool) (string, error) {
 	ret := _m.Called(username, isAdmin)
@@ -58,6 +86,34 @@ func (_m *JwtServiceInterface) ValidateAdmin(token *jwt.Token) bool {
 	return r0
 }
 
+// ValidateChallengeToken provides a mock function with given fields: token
+func (_m *JwtServiceInterface) ValidateChallengeToken(token string) (string, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateChallengeToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ValidateToken provides a mock function with given fields: token
 func (_m *JwtServiceInterface) ValidateToken(token string) (*jwt.Token, error) {
 	ret := _m.Called(token)
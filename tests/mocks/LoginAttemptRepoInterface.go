@@ -0,0 +1,127 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// LoginAttemptRepoInterface is an autogenerated mock type for the LoginAttemptRepoInterface type
+type LoginAttemptRepoInterface struct {
+	mock.Mock
+}
+
+// GetAttempt provides a mock function with given fields: ctx, key
+func (_m *LoginAttemptRepoInterface) GetAttempt(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAttempt")
+	}
+
+	var r0 *domain.LoginAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.LoginAttempt, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.LoginAttempt); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginAttempt)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockUntil provides a mock function with given fields: ctx, key, until
+func (_m *LoginAttemptRepoInterface) LockUntil(ctx context.Context, key string, until time.Time) error {
+	ret := _m.Called(ctx, key, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockUntil")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, key, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordFailure provides a mock function with given fields: ctx, key, window
+func (_m *LoginAttemptRepoInterface) RecordFailure(ctx context.Context, key string, window time.Duration) (*domain.LoginAttempt, error) {
+	ret := _m.Called(ctx, key, window)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordFailure")
+	}
+
+	var r0 *domain.LoginAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) (*domain.LoginAttempt, error)); ok {
+		return rf(ctx, key, window)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) *domain.LoginAttempt); ok {
+		r0 = rf(ctx, key, window)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginAttempt)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, key, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reset provides a mock function with given fields: ctx, key
+func (_m *LoginAttemptRepoInterface) Reset(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewLoginAttemptRepoInterface creates a new instance of LoginAttemptRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginAttemptRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginAttemptRepoInterface {
+	mock := &LoginAttemptRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,29 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// LoginMetricsInterface is an autogenerated mock type for the LoginMetricsInterface type
+type LoginMetricsInterface struct {
+	mock.Mock
+}
+
+// ObserveLogin provides a mock function with given fields: result
+func (_m *LoginMetricsInterface) ObserveLogin(result string) {
+	_m.Called(result)
+}
+
+// NewLoginMetricsInterface creates a new instance of LoginMetricsInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLoginMetricsInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LoginMetricsInterface {
+	mock := &LoginMetricsInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
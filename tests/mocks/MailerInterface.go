@@ -0,0 +1,46 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MailerInterface is an autogenerated mock type for the MailerInterface type
+type MailerInterface struct {
+	mock.Mock
+}
+
+// Send provides a mock function with given fields: ctx, to, subject, body
+func (_m *MailerInterface) Send(ctx context.Context, to string, subject string, body string) error {
+	ret := _m.Called(ctx, to, subject, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Send")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, to, subject, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMailerInterface creates a new instance of MailerInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMailerInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MailerInterface {
+	mock := &MailerInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
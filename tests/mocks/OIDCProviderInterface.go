@@ -0,0 +1,77 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OIDCProviderInterface is an autogenerated mock type for the OIDCProviderInterface type
+type OIDCProviderInterface struct {
+	mock.Mock
+}
+
+// AuthCodeURL provides a mock function with given fields: state, codeVerifier
+func (_m *OIDCProviderInterface) AuthCodeURL(state string, codeVerifier string) string {
+	ret := _m.Called(state, codeVerifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthCodeURL")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(state, codeVerifier)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Exchange provides a mock function with given fields: ctx, code, codeVerifier
+func (_m *OIDCProviderInterface) Exchange(ctx context.Context, code string, codeVerifier string) (*domain.OIDCIdentity, error) {
+	ret := _m.Called(ctx, code, codeVerifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exchange")
+	}
+
+	var r0 *domain.OIDCIdentity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.OIDCIdentity, error)); ok {
+		return rf(ctx, code, codeVerifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.OIDCIdentity); ok {
+		r0 = rf(ctx, code, codeVerifier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OIDCIdentity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, code, codeVerifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOIDCProviderInterface creates a new instance of OIDCProviderInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOIDCProviderInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OIDCProviderInterface {
+	mock := &OIDCProviderInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
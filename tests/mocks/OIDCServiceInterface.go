@@ -0,0 +1,89 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OIDCServiceInterface is an autogenerated mock type for the OIDCServiceInterface type
+type OIDCServiceInterface struct {
+	mock.Mock
+}
+
+// FinishLogin provides a mock function with given fields: ctx, code, codeVerifier
+func (_m *OIDCServiceInterface) FinishLogin(ctx context.Context, code string, codeVerifier string) (*domain.LoginResult, error) {
+	ret := _m.Called(ctx, code, codeVerifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishLogin")
+	}
+
+	var r0 *domain.LoginResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.LoginResult, error)); ok {
+		return rf(ctx, code, codeVerifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.LoginResult); ok {
+		r0 = rf(ctx, code, codeVerifier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, code, codeVerifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartLogin provides a mock function with given fields:
+func (_m *OIDCServiceInterface) StartLogin() (*domain.OIDCAuthRequest, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartLogin")
+	}
+
+	var r0 *domain.OIDCAuthRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*domain.OIDCAuthRequest, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *domain.OIDCAuthRequest); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OIDCAuthRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOIDCServiceInterface creates a new instance of OIDCServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOIDCServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OIDCServiceInterface {
+	mock := &OIDCServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,79 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// TaskHistoryRepoInterface is an autogenerated mock type for the TaskHistoryRepoInterface type
+type TaskHistoryRepoInterface struct {
+	mock.Mock
+}
+
+// AddEntry provides a mock function with given fields: ctx, entry
+func (_m *TaskHistoryRepoInterface) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddEntry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.TaskHistoryEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetHistory provides a mock function with given fields: ctx, taskID
+func (_m *TaskHistoryRepoInterface) GetHistory(ctx context.Context, taskID uuid.UUID) ([]domain.TaskHistoryEntry, error) {
+	ret := _m.Called(ctx, taskID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHistory")
+	}
+
+	var r0 []domain.TaskHistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]domain.TaskHistoryEntry, error)); ok {
+		return rf(ctx, taskID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []domain.TaskHistoryEntry); ok {
+		r0 = rf(ctx, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TaskHistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTaskHistoryRepoInterface creates a new instance of TaskHistoryRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTaskHistoryRepoInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TaskHistoryRepoInterface {
+	mock := &TaskHistoryRepoInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
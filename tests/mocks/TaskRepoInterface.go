@@ -3,9 +3,13 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -14,9 +18,9 @@ type TaskRepoInterface struct {
 	mock.Mock
 }
 
-// AddTask provides a mock function with given fields: task
-func (_m *TaskRepoInterface) AddTask(task domain.Task) (*domain.Task, error) {
-	ret := _m.Called(task)
+// AddTask provides a mock function with given fields: ctx, task
+func (_m *TaskRepoInterface) AddTask(ctx context.Context, task domain.Task) (*domain.Task, error) {
+	ret := _m.Called(ctx, task)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddTask")
@@ -24,19 +28,19 @@ func (_m *TaskRepoInterface) AddTask(task domain.Task) (*domain.Task, error) {
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(domain.Task) (*domain.Task, error)); ok {
-		return rf(task)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Task) (*domain.Task, error)); ok {
+		return rf(ctx, task)
 	}
-	if rf, ok := ret.Get(0).(func(domain.Task) *domain.Task); ok {
-		r0 = rf(task)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Task) *domain.Task); ok {
+		r0 = rf(ctx, task)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(domain.Task) error); ok {
-		r1 = rf(task)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Task) error); ok {
+		r1 = rf(ctx, task)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,27 +48,99 @@ func (_m *TaskRepoInterface) AddTask(task domain.Task) (*domain.Task, error) {
 	return r0, r1
 }
 
-// DeleteTask provides a mock function with given fields: id
-func (_m *TaskRepoInterface) DeleteTask(id uuid.UUID) error {
-	ret := _m.Called(id)
+// BulkWriteTasks provides a mock function with given fields: ctx, writes
+func (_m *TaskRepoInterface) BulkWriteTasks(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	ret := _m.Called(ctx, writes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkWriteTasks")
+	}
+
+	var r0 []error
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.TaskWrite) ([]error, error)); ok {
+		return rf(ctx, writes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.TaskWrite) []error); ok {
+		r0 = rf(ctx, writes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.TaskWrite) error); ok {
+		r1 = rf(ctx, writes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTask provides a mock function with given fields: ctx, id, deletedBy, deletedAt
+func (_m *TaskRepoInterface) DeleteTask(ctx context.Context, id uuid.UUID, deletedBy string, deletedAt time.Time) (*domain.Task, error) {
+	ret := _m.Called(ctx, id, deletedBy, deletedAt)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteTask")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
-		r0 = rf(id)
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) (*domain.Task, error)); ok {
+		return rf(ctx, id, deletedBy, deletedAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) *domain.Task); ok {
+		r0 = rf(ctx, id, deletedBy, deletedAt)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, time.Time) error); ok {
+		r1 = rf(ctx, id, deletedBy, deletedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeletedTasks provides a mock function with given fields: ctx
+func (_m *TaskRepoInterface) GetDeletedTasks(ctx context.Context) ([]domain.Task, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedTasks")
+	}
+
+	var r0 []domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetTaskById provides a mock function with given fields: id
-func (_m *TaskRepoInterface) GetTaskById(id uuid.UUID) (*domain.Task, error) {
-	ret := _m.Called(id)
+// GetTaskById provides a mock function with given fields: ctx, id
+func (_m *TaskRepoInterface) GetTaskById(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTaskById")
@@ -72,19 +148,19 @@ func (_m *TaskRepoInterface) GetTaskById(id uuid.UUID) (*domain.Task, error) {
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) (*domain.Task, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Task, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *domain.Task); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Task); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,9 +168,9 @@ func (_m *TaskRepoInterface) GetTaskById(id uuid.UUID) (*domain.Task, error) {
 	return r0, r1
 }
 
-// GetTasks provides a mock function with given fields:
-func (_m *TaskRepoInterface) GetTasks() ([]domain.Task, error) {
-	ret := _m.Called()
+// GetTasks provides a mock function with given fields: ctx
+func (_m *TaskRepoInterface) GetTasks(ctx context.Context) ([]domain.Task, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTasks")
@@ -102,19 +178,49 @@ func (_m *TaskRepoInterface) GetTasks() ([]domain.Task, error) {
 
 	var r0 []domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]domain.Task, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTasksByIDs provides a mock function with given fields: ctx, ids
+func (_m *TaskRepoInterface) GetTasksByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Task, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTasksByIDs")
+	}
+
+	var r0 []domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) ([]domain.Task, error)); ok {
+		return rf(ctx, ids)
 	}
-	if rf, ok := ret.Get(0).(func() []domain.Task); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) []domain.Task); ok {
+		r0 = rf(ctx, ids)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, []uuid.UUID) error); ok {
+		r1 = rf(ctx, ids)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,17 +228,105 @@ func (_m *TaskRepoInterface) GetTasks() ([]domain.Task, error) {
 	return r0, r1
 }
 
-// UpdateTaskByID provides a mock function with given fields: id, updatedTask
-func (_m *TaskRepoInterface) UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error {
-	ret := _m.Called(id, updatedTask)
+// PurgeDeletedTasks provides a mock function with given fields: ctx, deletedBefore
+func (_m *TaskRepoInterface) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeletedTasks")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreTask provides a mock function with given fields: ctx, id
+func (_m *TaskRepoInterface) RestoreTask(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreTask")
+	}
+
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Task, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Task); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTaskByID provides a mock function with given fields: ctx, id, updatedTask
+func (_m *TaskRepoInterface) UpdateTaskByID(ctx context.Context, id uuid.UUID, updatedTask domain.Task) (*domain.Task, error) {
+	ret := _m.Called(ctx, id, updatedTask)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateTaskByID")
 	}
 
+	var r0 *domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.Task) (*domain.Task, error)); ok {
+		return rf(ctx, id, updatedTask)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.Task) *domain.Task); ok {
+		r0 = rf(ctx, id, updatedTask)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, domain.Task) error); ok {
+		r1 = rf(ctx, id, updatedTask)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *TaskRepoInterface) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, domain.Task) error); ok {
-		r0 = rf(id, updatedTask)
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
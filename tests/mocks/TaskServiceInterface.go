@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	mock "github.com/stretchr/testify/mock"
 
@@ -14,9 +16,9 @@ type TaskServiceInterface struct {
 	mock.Mock
 }
 
-// AddTask provides a mock function with given fields: task
-func (_m *TaskServiceInterface) AddTask(task domain.Task) (*domain.Task, error) {
-	ret := _m.Called(task)
+// AddTask provides a mock function with given fields: ctx, username, task
+func (_m *TaskServiceInterface) AddTask(ctx context.Context, username string, task domain.Task) (*domain.Task, error) {
+	ret := _m.Called(ctx, username, task)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddTask")
@@ -24,19 +26,19 @@ func (_m *TaskServiceInterface) AddTask(task domain.Task) (*domain.Task, error)
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(domain.Task) (*domain.Task, error)); ok {
-		return rf(task)
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Task) (*domain.Task, error)); ok {
+		return rf(ctx, username, task)
 	}
-	if rf, ok := ret.Get(0).(func(domain.Task) *domain.Task); ok {
-		r0 = rf(task)
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Task) *domain.Task); ok {
+		r0 = rf(ctx, username, task)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(domain.Task) error); ok {
-		r1 = rf(task)
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Task) error); ok {
+		r1 = rf(ctx, username, task)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,17 +46,47 @@ func (_m *TaskServiceInterface) AddTask(task domain.Task) (*domain.Task, error)
 	return r0, r1
 }
 
-// DeleteTask provides a mock function with given fields: id
-func (_m *TaskServiceInterface) DeleteTask(id uuid.UUID) error {
-	ret := _m.Called(id)
+// ApplyBulkOperations provides a mock function with given fields: ctx, username, request
+func (_m *TaskServiceInterface) ApplyBulkOperations(ctx context.Context, username string, request domain.BulkTaskRequest) (*domain.BulkTaskResponse, error) {
+	ret := _m.Called(ctx, username, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyBulkOperations")
+	}
+
+	var r0 *domain.BulkTaskResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.BulkTaskRequest) (*domain.BulkTaskResponse, error)); ok {
+		return rf(ctx, username, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.BulkTaskRequest) *domain.BulkTaskResponse); ok {
+		r0 = rf(ctx, username, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.BulkTaskResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.BulkTaskRequest) error); ok {
+		r1 = rf(ctx, username, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteTask provides a mock function with given fields: ctx, username, id
+func (_m *TaskServiceInterface) DeleteTask(ctx context.Context, username string, id uuid.UUID) error {
+	ret := _m.Called(ctx, username, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteTask")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) error); ok {
+		r0 = rf(ctx, username, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,9 +94,39 @@ func (_m *TaskServiceInterface) DeleteTask(id uuid.UUID) error {
 	return r0
 }
 
-// GetTaskById provides a mock function with given fields: id
-func (_m *TaskServiceInterface) GetTaskById(id uuid.UUID) (*domain.Task, error) {
-	ret := _m.Called(id)
+// GetDeletedTasks provides a mock function with given fields: ctx
+func (_m *TaskServiceInterface) GetDeletedTasks(ctx context.Context) ([]domain.Task, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedTasks")
+	}
+
+	var r0 []domain.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTaskById provides a mock function with given fields: ctx, id
+func (_m *TaskServiceInterface) GetTaskById(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTaskById")
@@ -72,19 +134,49 @@ func (_m *TaskServiceInterface) GetTaskById(id uuid.UUID) (*domain.Task, error)
 
 	var r0 *domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) (*domain.Task, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Task, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID) *domain.Task); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Task); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTaskHistory provides a mock function with given fields: ctx, id
+func (_m *TaskServiceInterface) GetTaskHistory(ctx context.Context, id uuid.UUID) ([]domain.TaskHistoryEntry, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTaskHistory")
+	}
+
+	var r0 []domain.TaskHistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]domain.TaskHistoryEntry, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []domain.TaskHistoryEntry); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TaskHistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,9 +184,9 @@ func (_m *TaskServiceInterface) GetTaskById(id uuid.UUID) (*domain.Task, error)
 	return r0, r1
 }
 
-// GetTasks provides a mock function with given fields:
-func (_m *TaskServiceInterface) GetTasks() ([]domain.Task, error) {
-	ret := _m.Called()
+// GetTasks provides a mock function with given fields: ctx
+func (_m *TaskServiceInterface) GetTasks(ctx context.Context) ([]domain.Task, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTasks")
@@ -102,19 +194,19 @@ func (_m *TaskServiceInterface) GetTasks() ([]domain.Task, error) {
 
 	var r0 []domain.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]domain.Task, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Task, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() []domain.Task); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Task); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]domain.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -122,17 +214,35 @@ func (_m *TaskServiceInterface) GetTasks() ([]domain.Task, error) {
 	return r0, r1
 }
 
-// UpdateTaskByID provides a mock function with given fields: id, updatedTask
-func (_m *TaskServiceInterface) UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error {
-	ret := _m.Called(id, updatedTask)
+// RestoreTask provides a mock function with given fields: ctx, username, id
+func (_m *TaskServiceInterface) RestoreTask(ctx context.Context, username string, id uuid.UUID) error {
+	ret := _m.Called(ctx, username, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreTask")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) error); ok {
+		r0 = rf(ctx, username, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTaskByID provides a mock function with given fields: ctx, username, id, updatedTask
+func (_m *TaskServiceInterface) UpdateTaskByID(ctx context.Context, username string, id uuid.UUID, updatedTask domain.Task) error {
+	ret := _m.Called(ctx, username, id, updatedTask)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateTaskByID")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, domain.Task) error); ok {
-		r0 = rf(id, updatedTask)
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID, domain.Task) error); ok {
+		r0 = rf(ctx, username, id, updatedTask)
 	} else {
 		r0 = ret.Error(0)
 	}
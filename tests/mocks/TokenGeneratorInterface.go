@@ -0,0 +1,70 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// TokenGeneratorInterface is an autogenerated mock type for the TokenGeneratorInterface type
+type TokenGeneratorInterface struct {
+	mock.Mock
+}
+
+// GenerateSecret provides a mock function with given fields: size
+func (_m *TokenGeneratorInterface) GenerateSecret(size int) (string, error) {
+	ret := _m.Called(size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateSecret")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (string, error)); ok {
+		return rf(size)
+	}
+	if rf, ok := ret.Get(0).(func(int) string); ok {
+		r0 = rf(size)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(size)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HashSecret provides a mock function with given fields: secret
+func (_m *TokenGeneratorInterface) HashSecret(secret string) string {
+	ret := _m.Called(secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HashSecret")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(secret)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// NewTokenGeneratorInterface creates a new instance of TokenGeneratorInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenGeneratorInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenGeneratorInterface {
+	mock := &TokenGeneratorInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
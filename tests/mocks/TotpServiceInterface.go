@@ -0,0 +1,128 @@
+// Code generated by mockery v2.44.1. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// TotpServiceInterface is an autogenerated mock type for the TotpServiceInterface type
+type TotpServiceInterface struct {
+	mock.Mock
+}
+
+// GenerateRecoveryCodes provides a mock function with given fields: count
+func (_m *TotpServiceInterface) GenerateRecoveryCodes(count int) ([]string, error) {
+	ret := _m.Called(count)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateRecoveryCodes")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]string, error)); ok {
+		return rf(count)
+	}
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateSecret provides a mock function with given fields:
+func (_m *TotpServiceInterface) GenerateSecret() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateSecret")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ProvisioningURI provides a mock function with given fields: username, secret
+func (_m *TotpServiceInterface) ProvisioningURI(username string, secret string) string {
+	ret := _m.Called(username, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProvisioningURI")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(username, secret)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// ValidateCode provides a mock function with given fields: secret, code, lastCounter
+func (_m *TotpServiceInterface) ValidateCode(secret string, code string, lastCounter int64) (int64, bool) {
+	ret := _m.Called(secret, code, lastCounter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateCode")
+	}
+
+	var r0 int64
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string, string, int64) (int64, bool)); ok {
+		return rf(secret, code, lastCounter)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, int64) int64); ok {
+		r0 = rf(secret, code, lastCounter)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, int64) bool); ok {
+		r1 = rf(secret, code, lastCounter)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// NewTotpServiceInterface creates a new instance of TotpServiceInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTotpServiceInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TotpServiceInterface {
+	mock := &TotpServiceInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
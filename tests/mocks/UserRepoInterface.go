@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // UserRepoInterface is an autogenerated mock type for the UserRepoInterface type
@@ -12,9 +16,9 @@ type UserRepoInterface struct {
 	mock.Mock
 }
 
-// Count provides a mock function with given fields:
-func (_m *UserRepoInterface) Count() (int64, error) {
-	ret := _m.Called()
+// Count provides a mock function with given fields: ctx
+func (_m *UserRepoInterface) Count(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Count")
@@ -22,17 +26,17 @@ func (_m *UserRepoInterface) Count() (int64, error) {
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (int64, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func() int64); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -40,9 +44,9 @@ func (_m *UserRepoInterface) Count() (int64, error) {
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: username
-func (_m *UserRepoInterface) GetUser(username string) (*domain.User, error) {
-	ret := _m.Called(username)
+// GetUser provides a mock function with given fields: ctx, username
+func (_m *UserRepoInterface) GetUser(ctx context.Context, username string) (*domain.User, error) {
+	ret := _m.Called(ctx, username)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUser")
@@ -50,19 +54,19 @@ func (_m *UserRepoInterface) GetUser(username string) (*domain.User, error) {
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
-		return rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, username)
 	}
-	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
-		r0 = rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, username)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(username)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,17 +74,95 @@ func (_m *UserRepoInterface) GetUser(username string) (*domain.User, error) {
 	return r0, r1
 }
 
-// PromoteUser provides a mock function with given fields: username
-func (_m *UserRepoInterface) PromoteUser(username string) error {
-	ret := _m.Called(username)
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *UserRepoInterface) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByOIDCSubject provides a mock function with given fields: ctx, issuer, subject
+func (_m *UserRepoInterface) GetUserByOIDCSubject(ctx context.Context, issuer string, subject string) (*domain.User, error) {
+	ret := _m.Called(ctx, issuer, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByOIDCSubject")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.User, error)); ok {
+		return rf(ctx, issuer, subject)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, issuer, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, issuer, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkOIDCIdentity provides a mock function with given fields: ctx, username, issuer, subject
+func (_m *UserRepoInterface) LinkOIDCIdentity(ctx context.Context, username string, issuer string, subject string) error {
+	ret := _m.Called(ctx, username, issuer, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkOIDCIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, username, issuer, subject)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PromoteUser provides a mock function with given fields: ctx, username
+func (_m *UserRepoInterface) PromoteUser(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PromoteUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -88,9 +170,9 @@ func (_m *UserRepoInterface) PromoteUser(username string) error {
 	return r0
 }
 
-// RegisterUser provides a mock function with given fields: user
-func (_m *UserRepoInterface) RegisterUser(user *domain.User) (*domain.User, error) {
-	ret := _m.Called(user)
+// RegisterUser provides a mock function with given fields: ctx, user
+func (_m *UserRepoInterface) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterUser")
@@ -98,19 +180,85 @@ func (_m *UserRepoInterface) RegisterUser(user *domain.User) (*domain.User, erro
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*domain.User) (*domain.User, error)); ok {
-		return rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (*domain.User, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *domain.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetEmailVerification provides a mock function with given fields: ctx, username, pendingEmail, tokenHash, expiresAt
+func (_m *UserRepoInterface) SetEmailVerification(ctx context.Context, username string, pendingEmail string, tokenHash string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, username, pendingEmail, tokenHash, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetEmailVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) error); ok {
+		r0 = rf(ctx, username, pendingEmail, tokenHash, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, username, password
+func (_m *UserRepoInterface) UpdatePassword(ctx context.Context, username string, password string) error {
+	ret := _m.Called(ctx, username, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: ctx, username, update
+func (_m *UserRepoInterface) UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (*domain.User, error) {
+	ret := _m.Called(ctx, username, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ProfileUpdate) (*domain.User, error)); ok {
+		return rf(ctx, username, update)
 	}
-	if rf, ok := ret.Get(0).(func(*domain.User) *domain.User); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ProfileUpdate) *domain.User); ok {
+		r0 = rf(ctx, username, update)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*domain.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.ProfileUpdate) error); ok {
+		r1 = rf(ctx, username, update)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,6 +266,78 @@ func (_m *UserRepoInterface) RegisterUser(user *domain.User) (*domain.User, erro
 	return r0, r1
 }
 
+// UpdateRecoveryCodes provides a mock function with given fields: ctx, username, recoveryCodes
+func (_m *UserRepoInterface) UpdateRecoveryCodes(ctx context.Context, username string, recoveryCodes []string) error {
+	ret := _m.Called(ctx, username, recoveryCodes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRecoveryCodes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, username, recoveryCodes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTwoFactor provides a mock function with given fields: ctx, username, secret, enabled, recoveryCodes
+func (_m *UserRepoInterface) UpdateTwoFactor(ctx context.Context, username string, secret string, enabled bool, recoveryCodes []string) error {
+	ret := _m.Called(ctx, username, secret, enabled, recoveryCodes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTwoFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, []string) error); ok {
+		r0 = rf(ctx, username, secret, enabled, recoveryCodes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTOTPCounter provides a mock function with given fields: ctx, username, counter
+func (_m *UserRepoInterface) UseTOTPCounter(ctx context.Context, username string, counter int64) error {
+	ret := _m.Called(ctx, username, counter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseTOTPCounter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, username, counter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, tokenHash, now
+func (_m *UserRepoInterface) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) error {
+	ret := _m.Called(ctx, tokenHash, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, tokenHash, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserRepoInterface creates a new instance of UserRepoInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepoInterface(t interface {
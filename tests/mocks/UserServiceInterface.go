@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,27 +14,119 @@ type UserServiceInterface struct {
 	mock.Mock
 }
 
-// LoginUser provides a mock function with given fields: user
-func (_m *UserServiceInterface) LoginUser(user domain.User) (string, error) {
-	ret := _m.Called(user)
+// ConfirmTwoFactor provides a mock function with given fields: ctx, username, code
+func (_m *UserServiceInterface) ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error) {
+	ret := _m.Called(ctx, username, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmTwoFactor")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]string, error)); ok {
+		return rf(ctx, username, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []string); ok {
+		r0 = rf(ctx, username, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnrollTwoFactor provides a mock function with given fields: ctx, username
+func (_m *UserServiceInterface) EnrollTwoFactor(ctx context.Context, username string) (*domain.TwoFactorEnrollment, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollTwoFactor")
+	}
+
+	var r0 *domain.TwoFactorEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.TwoFactorEnrollment, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.TwoFactorEnrollment); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactorEnrollment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProfile provides a mock function with given fields: ctx, username
+func (_m *UserServiceInterface) GetProfile(ctx context.Context, username string) (*domain.UserProfile, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProfile")
+	}
+
+	var r0 *domain.UserProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.UserProfile, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.UserProfile); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserProfile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginUser provides a mock function with given fields: ctx, user, clientIP
+func (_m *UserServiceInterface) LoginUser(ctx context.Context, user domain.User, clientIP string) (*domain.LoginResult, error) {
+	ret := _m.Called(ctx, user, clientIP)
 
 	if len(ret) == 0 {
 		panic("no return value specified for LoginUser")
 	}
 
-	var r0 string
+	var r0 *domain.LoginResult
 	var r1 error
-	if rf, ok := ret.Get(0).(func(domain.User) (string, error)); ok {
-		return rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User, string) (*domain.LoginResult, error)); ok {
+		return rf(ctx, user, clientIP)
 	}
-	if rf, ok := ret.Get(0).(func(domain.User) string); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User, string) *domain.LoginResult); ok {
+		r0 = rf(ctx, user, clientIP)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginResult)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(domain.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.User, string) error); ok {
+		r1 = rf(ctx, user, clientIP)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -40,17 +134,17 @@ func (_m *UserServiceInterface) LoginUser(user domain.User) (string, error) {
 	return r0, r1
 }
 
-// PromoteUser provides a mock function with given fields: username
-func (_m *UserServiceInterface) PromoteUser(username string) error {
-	ret := _m.Called(username)
+// PromoteUser provides a mock function with given fields: ctx, promotedBy, username
+func (_m *UserServiceInterface) PromoteUser(ctx context.Context, promotedBy string, username string) error {
+	ret := _m.Called(ctx, promotedBy, username)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PromoteUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, promotedBy, username)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -58,9 +152,9 @@ func (_m *UserServiceInterface) PromoteUser(username string) error {
 	return r0
 }
 
-// RegisterUser provides a mock function with given fields: user
-func (_m *UserServiceInterface) RegisterUser(user *domain.User) (*domain.User, error) {
-	ret := _m.Called(user)
+// RegisterUser provides a mock function with given fields: ctx, user, invitationToken
+func (_m *UserServiceInterface) RegisterUser(ctx context.Context, user *domain.User, invitationToken string) (*domain.User, error) {
+	ret := _m.Called(ctx, user, invitationToken)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterUser")
@@ -68,19 +162,131 @@ func (_m *UserServiceInterface) RegisterUser(user *domain.User) (*domain.User, e
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*domain.User) (*domain.User, error)); ok {
-		return rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) (*domain.User, error)); ok {
+		return rf(ctx, user, invitationToken)
 	}
-	if rf, ok := ret.Get(0).(func(*domain.User) *domain.User); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) *domain.User); ok {
+		r0 = rf(ctx, user, invitationToken)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*domain.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User, string) error); ok {
+		r1 = rf(ctx, user, invitationToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResendEmailVerification provides a mock function with given fields: ctx, email
+func (_m *UserServiceInterface) ResendEmailVerification(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResendEmailVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnlockUser provides a mock function with given fields: ctx, unlockedBy, username
+func (_m *UserServiceInterface) UnlockUser(ctx context.Context, unlockedBy string, username string) error {
+	ret := _m.Called(ctx, unlockedBy, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, unlockedBy, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: ctx, username, update
+func (_m *UserServiceInterface) UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (*domain.UserProfile, error) {
+	ret := _m.Called(ctx, username, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProfile")
+	}
+
+	var r0 *domain.UserProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ProfileUpdate) (*domain.UserProfile, error)); ok {
+		return rf(ctx, username, update)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.ProfileUpdate) *domain.UserProfile); ok {
+		r0 = rf(ctx, username, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserProfile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.ProfileUpdate) error); ok {
+		r1 = rf(ctx, username, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, token
+func (_m *UserServiceInterface) VerifyEmail(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyTwoFactorLogin provides a mock function with given fields: ctx, login, clientIP
+func (_m *UserServiceInterface) VerifyTwoFactorLogin(ctx context.Context, login domain.TwoFactorLogin, clientIP string) (string, error) {
+	ret := _m.Called(ctx, login, clientIP)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyTwoFactorLogin")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.TwoFactorLogin, string) (string, error)); ok {
+		return rf(ctx, login, clientIP)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.TwoFactorLogin, string) string); ok {
+		r0 = rf(ctx, login, clientIP)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.TwoFactorLogin, string) error); ok {
+		r1 = rf(ctx, login, clientIP)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCControllerSuite struct {
+	suite.Suite
+	controller  *controllers.OIDCController
+	mockService *mocks.OIDCServiceInterface
+}
+
+func (suite *OIDCControllerSuite) SetupTest() {
+	suite.mockService = new(mocks.OIDCServiceInterface)
+	suite.controller = &controllers.OIDCController{Service: suite.mockService}
+}
+
+func (suite *OIDCControllerSuite) TestLogin_Redirects() {
+	authRequest := &domain.OIDCAuthRequest{URL: "https://idp.example.com/authorize?state=state", State: "state", CodeVerifier: "verifier"}
+	suite.mockService.On("StartLogin").Return(authRequest, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/oidc/login", nil)
+
+	handle(c, suite.controller.Login)
+
+	assert.Equal(suite.T(), http.StatusFound, w.Code)
+	assert.Equal(suite.T(), authRequest.URL, w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	assert.Len(suite.T(), cookies, 2)
+	for _, cookie := range cookies {
+		assert.True(suite.T(), cookie.HttpOnly)
+	}
+}
+
+func (suite *OIDCControllerSuite) TestCallback_Success() {
+	suite.mockService.On("FinishLogin", mock.Anything, "code", "verifier").Return(&domain.LoginResult{Token: "token"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/oidc/callback?code=code&state=state", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_state", Value: "state"})
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_verifier", Value: "verifier"})
+
+	handle(c, suite.controller.Callback)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "token")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *OIDCControllerSuite) TestCallback_StateMismatch() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/oidc/callback?code=code&state=forged", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_state", Value: "state"})
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_verifier", Value: "verifier"})
+
+	handle(c, suite.controller.Callback)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "FinishLogin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCControllerSuite) TestCallback_NotLinked() {
+	suite.mockService.On("FinishLogin", mock.Anything, "code", "verifier").Return(nil, domain.ErrNoLinkedUser)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/oidc/callback?code=code&state=state", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_state", Value: "state"})
+	c.Request.AddCookie(&http.Cookie{Name: "oidc_verifier", Value: "verifier"})
+
+	handle(c, suite.controller.Callback)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func TestOIDCControllerSuite(t *testing.T) {
+	suite.Run(t, new(OIDCControllerSuite))
+}
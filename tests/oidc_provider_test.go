@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	oidcTestClientID    = "task-manager"
+	oidcTestRedirectURL = "http://app.test/oidc/callback"
+)
+
+// a minimal openid connect provider that signs in a fixed user without asking
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+
+	mu sync.Mutex
+	// pkce code challenge of every issued authorization code
+	codes map[string]string
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &mockOIDCProvider{key: key, codes: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	p.claims = jwt.MapClaims{"sub": "subject-1", "preferred_username": "oidcuser"}
+	return p
+}
+
+func (p *mockOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *mockOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// approve the request right away and redirect back with a code
+func (p *mockOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "pkce is required", http.StatusBadRequest)
+		return
+	}
+
+	code := "code-" + query.Get("state")
+	p.mu.Lock()
+	p.codes[code] = query.Get("code_challenge")
+	p.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	p.mu.Lock()
+	challenge, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	hash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(hash[:]) != challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss": p.server.URL,
+		"aud": oidcTestClientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test"
+	signed, _ := idToken.SignedString(p.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+// follow the provider's redirect and return the code and state sent to the callback
+func (p *mockOIDCProvider) approve(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization failed with status %d", resp.StatusCode)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+type OIDCProviderSuite struct {
+	suite.Suite
+	mockProvider *mockOIDCProvider
+	provider     *infrastructure.OIDCProvider
+}
+
+func (suite *OIDCProviderSuite) SetupTest() {
+	suite.mockProvider = newMockOIDCProvider(suite.T())
+
+	provider, err := infrastructure.NewOIDCProvider(suite.mockProvider.server.URL, oidcTestClientID, "secret", oidcTestRedirectURL)
+	suite.Require().NoError(err)
+	suite.provider = provider
+}
+
+func (suite *OIDCProviderSuite) TestExchange_Success() {
+	verifier := "a-code-verifier-that-is-long-enough-for-pkce-0123456789"
+	code, state := suite.mockProvider.approve(suite.T(), suite.provider.AuthCodeURL("state-1", verifier))
+	assert.Equal(suite.T(), "state-1", state)
+
+	identity, err := suite.provider.Exchange(context.Background(), code, verifier)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.mockProvider.server.URL, identity.Issuer)
+	assert.Equal(suite.T(), "subject-1", identity.Subject)
+	assert.Equal(suite.T(), "oidcuser", identity.PreferredUsername)
+}
+
+func (suite *OIDCProviderSuite) TestExchange_WrongCodeVerifier() {
+	verifier := "a-code-verifier-that-is-long-enough-for-pkce-0123456789"
+	code, _ := suite.mockProvider.approve(suite.T(), suite.provider.AuthCodeURL("state-1", verifier))
+
+	_, err := suite.provider.Exchange(context.Background(), code, "another-code-verifier-that-is-long-enough-0123456789")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *OIDCProviderSuite) TestExchange_WrongAudience() {
+	suite.mockProvider.claims["aud"] = "another-client"
+	verifier := "a-code-verifier-that-is-long-enough-for-pkce-0123456789"
+	code, _ := suite.mockProvider.approve(suite.T(), suite.provider.AuthCodeURL("state-1", verifier))
+
+	_, err := suite.provider.Exchange(context.Background(), code, verifier)
+
+	assert.Error(suite.T(), err)
+}
+
+// the whole flow through the controller, provisioning a new user
+func (suite *OIDCProviderSuite) TestLoginFlow_ProvisionsUser() {
+	gin.SetMode(gin.TestMode)
+	mockUserRepo := new(mocks.UserRepoInterface)
+	jwtService := &infrastructure.JwtService{JwtSecret: []byte("test_secret")}
+	service := &usecases.OIDCService{
+		Provider:       suite.provider,
+		UserRepo:       mockUserRepo,
+		JwtService:     jwtService,
+		TokenGenerator: &infrastructure.TokenGenerator{},
+		AutoProvision:  true,
+	}
+	controller := &controllers.OIDCController{Service: service}
+	router := gin.New()
+	router.Use(infrastructure.ErrorMiddleware(testValidator, nil))
+	router.GET("/oidc/login", controller.Login)
+	router.GET("/oidc/callback", controller.Callback)
+
+	mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, suite.mockProvider.server.URL, "subject-1").Return(nil, domain.ErrUserNotFound)
+	mockUserRepo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Username == "oidcuser" && user.OIDCSubject == "subject-1" && user.Password == "" && !user.IsAdmin
+	})).Return(func(_ context.Context, user *domain.User) *domain.User { return user }, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/oidc/login", nil))
+	assert.Equal(suite.T(), http.StatusFound, rec.Code)
+
+	code, state := suite.mockProvider.approve(suite.T(), rec.Header().Get("Location"))
+
+	callback := httptest.NewRequest("GET", "/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	for _, cookie := range rec.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, callback)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	var body map[string]interface{}
+	assert.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), &body))
+	token, err := jwtService.ValidateToken(body["token"].(string))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "oidcuser", token.Claims.(jwt.MapClaims)["username"])
+	mockUserRepo.AssertExpectations(suite.T())
+}
+
+func TestOIDCProviderSuite(t *testing.T) {
+	suite.Run(t, new(OIDCProviderSuite))
+}
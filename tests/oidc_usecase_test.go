@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCServiceTestSuite struct {
+	suite.Suite
+	service        *usecases.OIDCService
+	mockProvider   *mocks.OIDCProviderInterface
+	mockUserRepo   *mocks.UserRepoInterface
+	mockJwtService *mocks.JwtServiceInterface
+	mockGenerator  *mocks.TokenGeneratorInterface
+	identity       *domain.OIDCIdentity
+}
+
+func (suite *OIDCServiceTestSuite) SetupTest() {
+	suite.mockProvider = new(mocks.OIDCProviderInterface)
+	suite.mockUserRepo = new(mocks.UserRepoInterface)
+	suite.mockJwtService = new(mocks.JwtServiceInterface)
+	suite.mockGenerator = new(mocks.TokenGeneratorInterface)
+	suite.service = &usecases.OIDCService{
+		Provider:       suite.mockProvider,
+		UserRepo:       suite.mockUserRepo,
+		JwtService:     suite.mockJwtService,
+		TokenGenerator: suite.mockGenerator,
+	}
+	suite.identity = &domain.OIDCIdentity{Issuer: "https://idp.example.com", Subject: "subject-1", PreferredUsername: "oidcuser"}
+}
+
+// Test StartLogin with a fresh state and code verifier
+func (suite *OIDCServiceTestSuite) TestStartLogin() {
+	suite.mockGenerator.On("GenerateSecret", 32).Return("state", nil).Once()
+	suite.mockGenerator.On("GenerateSecret", 32).Return("verifier", nil).Once()
+	suite.mockProvider.On("AuthCodeURL", "state", "verifier").Return("https://idp.example.com/authorize")
+
+	authRequest, err := suite.service.StartLogin()
+
+	suite.NoError(err)
+	suite.Equal(&domain.OIDCAuthRequest{URL: "https://idp.example.com/authorize", State: "state", CodeVerifier: "verifier"}, authRequest)
+}
+
+// Test FinishLogin for an already linked user
+func (suite *OIDCServiceTestSuite) TestFinishLogin_LinkedUser() {
+	user := &domain.User{Username: "oidcuser", IsAdmin: true}
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(user, nil)
+	suite.mockJwtService.On("GenerateToken", "oidcuser", true).Return("token", nil)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.NoError(err)
+	suite.Equal("token", result.Token)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin for a linked user who hasn't verified the email on this server
+func (suite *OIDCServiceTestSuite) TestFinishLogin_EmailNotVerified() {
+	suite.service.RequireEmailVerification = true
+	user := &domain.User{Username: "oidcuser", Email: "jane@example.com"}
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(user, nil)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrEmailNotVerified)
+	suite.mockJwtService.AssertNotCalled(suite.T(), "GenerateToken", mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin for a linked user with two-factor authentication
+func (suite *OIDCServiceTestSuite) TestFinishLogin_TwoFactorRequired() {
+	user := &domain.User{Username: "oidcuser", TwoFactorEnabled: true}
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(user, nil)
+	suite.mockJwtService.On("GenerateChallengeToken", "oidcuser").Return("challenge", nil)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.NoError(err)
+	suite.True(result.TwoFactorRequired)
+	suite.Equal("challenge", result.ChallengeToken)
+}
+
+// Test FinishLogin for an unknown identity without provisioning
+func (suite *OIDCServiceTestSuite) TestFinishLogin_NotLinked() {
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(nil, domain.ErrUserNotFound)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrNoLinkedUser)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin linking an existing user by its verified email
+func (suite *OIDCServiceTestSuite) TestFinishLogin_LinkByEmail() {
+	suite.service.LinkByEmail = true
+	suite.identity.Email = "Jane@Example.com"
+	suite.identity.EmailVerified = true
+	user := &domain.User{Username: "jane", Email: "jane@example.com", EmailVerified: true}
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(nil, domain.ErrUserNotFound)
+	suite.mockUserRepo.On("GetUserByEmail", mock.Anything, "jane@example.com").Return(user, nil)
+	suite.mockUserRepo.On("LinkOIDCIdentity", mock.Anything, "jane", "https://idp.example.com", "subject-1").Return(nil)
+	suite.mockJwtService.On("GenerateToken", "jane", false).Return("token", nil)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.NoError(err)
+	suite.Equal("token", result.Token)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin not linking a user whose email nobody has verified on this server
+func (suite *OIDCServiceTestSuite) TestFinishLogin_LinkByEmailUnverified() {
+	suite.service.LinkByEmail = true
+	suite.identity.Email = "jane@example.com"
+	suite.identity.EmailVerified = true
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(nil, domain.ErrUserNotFound)
+	suite.mockUserRepo.On("GetUserByEmail", mock.Anything, "jane@example.com").Return(&domain.User{Username: "jane", Email: "jane@example.com"}, nil)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrNoLinkedUser)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "LinkOIDCIdentity", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin not trusting an email the provider hasn't verified
+func (suite *OIDCServiceTestSuite) TestFinishLogin_LinkByEmailUnverifiedByProvider() {
+	suite.service.LinkByEmail = true
+	suite.identity.Email = "jane@example.com"
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(nil, domain.ErrUserNotFound)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrNoLinkedUser)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "GetUserByEmail", mock.Anything, mock.Anything)
+}
+
+// Test FinishLogin provisioning a user whose name is taken by a local account
+func (suite *OIDCServiceTestSuite) TestFinishLogin_UsernameTaken() {
+	suite.service.AutoProvision = true
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(suite.identity, nil)
+	suite.mockUserRepo.On("GetUserByOIDCSubject", mock.Anything, "https://idp.example.com", "subject-1").Return(nil, domain.ErrUserNotFound)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrUsernameExists)
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrUsernameExists)
+}
+
+// Test FinishLogin with a code the provider rejects
+func (suite *OIDCServiceTestSuite) TestFinishLogin_InvalidCode() {
+	suite.mockProvider.On("Exchange", mock.Anything, "code", "verifier").Return(nil, errors.New("invalid_grant"))
+
+	result, err := suite.service.FinishLogin(context.Background(), "code", "verifier")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrInvalidAuthCode)
+}
+
+func TestOIDCServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCServiceTestSuite))
+}
@@ -1,10 +1,12 @@
 package tests
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 )
@@ -49,6 +51,55 @@ func (suite *PasswordServiceSuite) TestComparePassword_Failure() {
 	assert.False(suite.T(), isMatch)
 }
 
+// Test hashers
+
+func (suite *PasswordServiceSuite) TestArgon2id_HashAndVerify() {
+	service := &infrastructure.PasswordService{Hasher: &infrastructure.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}}
+
+	hashedPassword, err := service.HashPassword("mySecureP@ssw0rd")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.True(suite.T(), service.ComparePassword(hashedPassword, "mySecureP@ssw0rd"))
+	assert.False(suite.T(), service.ComparePassword(hashedPassword, "wrongPassword"))
+	assert.False(suite.T(), service.NeedsRehash(hashedPassword))
+}
+
+func (suite *PasswordServiceSuite) TestArgon2id_NeedsRehashWithNewParameters() {
+	oldService := &infrastructure.PasswordService{Hasher: &infrastructure.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}}
+	newService := &infrastructure.PasswordService{Hasher: &infrastructure.Argon2idHasher{Memory: 2048, Iterations: 1, Parallelism: 1}}
+
+	hashedPassword, _ := oldService.HashPassword("mySecureP@ssw0rd")
+
+	// old hashes keep working with their own parameters
+	assert.True(suite.T(), newService.ComparePassword(hashedPassword, "mySecureP@ssw0rd"))
+	assert.True(suite.T(), newService.NeedsRehash(hashedPassword))
+}
+
+func (suite *PasswordServiceSuite) TestBcrypt_NeedsRehashWithNewCost() {
+	service := &infrastructure.PasswordService{Hasher: &infrastructure.BcryptHasher{Cost: bcrypt.MinCost}}
+	hashedPassword, _ := service.HashPassword("mySecureP@ssw0rd")
+
+	assert.False(suite.T(), service.NeedsRehash(hashedPassword))
+	assert.True(suite.T(), (&infrastructure.PasswordService{Hasher: &infrastructure.BcryptHasher{Cost: bcrypt.MinCost + 1}}).NeedsRehash(hashedPassword))
+}
+
+func (suite *PasswordServiceSuite) TestLegacyHasher() {
+	bcryptService := &infrastructure.PasswordService{Hasher: &infrastructure.BcryptHasher{Cost: bcrypt.MinCost}}
+	hashedPassword, _ := bcryptService.HashPassword("mySecureP@ssw0rd")
+
+	service := &infrastructure.PasswordService{
+		Hasher: &infrastructure.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1},
+		Legacy: []infrastructure.PasswordHasher{&infrastructure.BcryptHasher{}},
+	}
+
+	assert.True(suite.T(), service.ComparePassword(hashedPassword, "mySecureP@ssw0rd"))
+	assert.True(suite.T(), service.NeedsRehash(hashedPassword))
+
+	// hashes of unknown algorithms never match
+	assert.False(suite.T(), (&infrastructure.PasswordService{Hasher: &infrastructure.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1}}).ComparePassword(hashedPassword, "mySecureP@ssw0rd"))
+}
+
 func TestPasswordServiceSuite(t *testing.T) {
 	suite.Run(t, new(PasswordServiceSuite))
 }
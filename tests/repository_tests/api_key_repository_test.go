@@ -0,0 +1,96 @@
+package repository_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.APIKeyRepository
+}
+
+func (suite *APIKeyRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("api_keys")
+	suite.repo = repositories.NewAPIKeyRepository(client, "test_db", "api_keys", repositories.DefaultDeadlines())
+}
+
+func (suite *APIKeyRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *APIKeyRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *APIKeyRepositorySuite) TestAddAndGetAPIKeyByHash() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", Name: "ci", KeyHash: "hash", Scopes: []string{"read"}, CreatedAt: time.Now().UTC()}
+
+	_, err := suite.repo.AddAPIKey(context.Background(), apiKey)
+	assert.NoError(suite.T(), err)
+
+	found, err := suite.repo.GetAPIKeyByHash(context.Background(), "hash")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), apiKey.ID, found.ID)
+	assert.Equal(suite.T(), "hash", found.KeyHash)
+
+	_, err = suite.repo.GetAPIKeyByHash(context.Background(), "unknown")
+	assert.ErrorIs(suite.T(), err, domain.ErrAPIKeyNotFound)
+}
+
+func (suite *APIKeyRepositorySuite) TestGetAPIKeys() {
+	_, err := suite.repo.AddAPIKey(context.Background(), &domain.APIKey{ID: uuid.New(), Username: "testuser", KeyHash: "hash1", CreatedAt: time.Now().UTC()})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.AddAPIKey(context.Background(), &domain.APIKey{ID: uuid.New(), Username: "other", KeyHash: "hash2", CreatedAt: time.Now().UTC()})
+	assert.NoError(suite.T(), err)
+
+	apiKeys, err := suite.repo.GetAPIKeys(context.Background(), "testuser")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), apiKeys, 1)
+}
+
+func (suite *APIKeyRepositorySuite) TestRevokeAPIKey() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Username: "testuser", KeyHash: "hash", CreatedAt: time.Now().UTC()}
+	_, err := suite.repo.AddAPIKey(context.Background(), apiKey)
+	assert.NoError(suite.T(), err)
+
+	// only the owner can revoke a key
+	assert.EqualError(suite.T(), suite.repo.RevokeAPIKey(context.Background(), apiKey.ID, "other"), "api key not found")
+
+	assert.NoError(suite.T(), suite.repo.RevokeAPIKey(context.Background(), apiKey.ID, "testuser"))
+	found, err := suite.repo.GetAPIKeyByHash(context.Background(), "hash")
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), found.RevokedAt)
+
+	// a revoked key can't be revoked again
+	assert.EqualError(suite.T(), suite.repo.RevokeAPIKey(context.Background(), apiKey.ID, "testuser"), "api key not found")
+}
+
+func TestAPIKeyRepositorySuite(t *testing.T) {
+	suite.Run(t, new(APIKeyRepositorySuite))
+}
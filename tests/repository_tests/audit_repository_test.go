@@ -0,0 +1,91 @@
+package repository_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.AuditRepository
+}
+
+func (suite *AuditRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("audit_log")
+	suite.repo = repositories.NewAuditRepository(client, "test_db", "audit_log", repositories.DefaultDeadlines())
+}
+
+func (suite *AuditRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *AuditRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *AuditRepositorySuite) add(actor string, action string, at time.Time) {
+	entry := &domain.AuditEntry{ID: uuid.New(), Time: at, Actor: actor, Action: action}
+	assert.NoError(suite.T(), suite.repo.AddEntry(context.Background(), entry))
+}
+
+func (suite *AuditRepositorySuite) TestGetEntries_NewestFirst() {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	suite.add("alice", domain.AuditLoginSucceeded, now.Add(-2*time.Minute))
+	suite.add("bob", domain.AuditLoginFailed, now.Add(-time.Minute))
+	suite.add("alice", domain.AuditTaskCreated, now)
+
+	entries, err := suite.repo.GetEntries(context.Background(), domain.AuditFilter{})
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 3)
+	assert.Equal(suite.T(), domain.AuditTaskCreated, entries[0].Action)
+	assert.Equal(suite.T(), domain.AuditLoginSucceeded, entries[2].Action)
+}
+
+func (suite *AuditRepositorySuite) TestGetEntries_Filters() {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	suite.add("alice", domain.AuditLoginSucceeded, now.Add(-time.Hour))
+	suite.add("alice", domain.AuditLoginSucceeded, now.Add(-time.Minute))
+	suite.add("alice", domain.AuditTaskCreated, now)
+	suite.add("bob", domain.AuditLoginSucceeded, now)
+
+	entries, err := suite.repo.GetEntries(context.Background(), domain.AuditFilter{Actor: "alice", Action: domain.AuditLoginSucceeded, Since: now.Add(-10 * time.Minute)})
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), now.Add(-time.Minute), entries[0].Time)
+
+	limited, err := suite.repo.GetEntries(context.Background(), domain.AuditFilter{Until: now, Limit: 1})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), limited, 1)
+	assert.Equal(suite.T(), now.Add(-time.Minute), limited[0].Time)
+}
+
+func TestAuditRepositorySuite(t *testing.T) {
+	suite.Run(t, new(AuditRepositorySuite))
+}
@@ -0,0 +1,74 @@
+package repository_tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BootstrapRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.BootstrapRepository
+}
+
+func (suite *BootstrapRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("bootstrap")
+	suite.repo = repositories.NewBootstrapRepository(client, "test_db", "bootstrap", repositories.DefaultDeadlines())
+}
+
+func (suite *BootstrapRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *BootstrapRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *BootstrapRepositorySuite) TestClaimFirstAdmin_OnlyOnce() {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claims := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := suite.repo.ClaimFirstAdmin(context.Background(), "testuser")
+			assert.NoError(suite.T(), err)
+			if claimed {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), 1, claims)
+}
+
+func TestBootstrapRepositorySuite(t *testing.T) {
+	suite.Run(t, new(BootstrapRepositorySuite))
+}
@@ -0,0 +1,109 @@
+package repository_tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.IdempotencyRepository
+}
+
+func (suite *IdempotencyRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("idempotency_keys")
+	suite.repo = repositories.NewIdempotencyRepository(client, "test_db", "idempotency_keys", repositories.DefaultDeadlines())
+}
+
+func (suite *IdempotencyRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *IdempotencyRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *IdempotencyRepositorySuite) record(username string, key string, expiresIn time.Duration) *domain.IdempotencyRecord {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &domain.IdempotencyRecord{ID: uuid.New(), Username: username, Key: key, RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(expiresIn)}
+}
+
+func (suite *IdempotencyRepositorySuite) TestReserve() {
+	first := suite.record("admin", "key-1", time.Hour)
+
+	stored, err := suite.repo.Reserve(context.Background(), first)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), stored)
+
+	// keys are per user
+	stored, err = suite.repo.Reserve(context.Background(), suite.record("other", "key-1", time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), stored)
+
+	stored, err = suite.repo.Reserve(context.Background(), suite.record("admin", "key-1", time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), first, stored)
+}
+
+// an expired record is replaced even before MongoDB removes it
+func (suite *IdempotencyRepositorySuite) TestReserve_Expired() {
+	_, err := suite.repo.Reserve(context.Background(), suite.record("admin", "key-1", -time.Minute))
+	assert.NoError(suite.T(), err)
+
+	stored, err := suite.repo.Reserve(context.Background(), suite.record("admin", "key-1", time.Hour))
+
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), stored)
+}
+
+// a completed record is kept until its new expiry, not the one of its reservation
+func (suite *IdempotencyRepositorySuite) TestCompleteAndDelete() {
+	record := suite.record("admin", "key-1", time.Minute)
+	_, err := suite.repo.Reserve(context.Background(), record)
+	assert.NoError(suite.T(), err)
+	record.Status = http.StatusCreated
+	record.ContentType = "application/json"
+	record.Headers = map[string]string{"Location": "/tasks/1"}
+	record.Body = []byte(`{"id":"1"}`)
+	record.ExpiresAt = record.CreatedAt.Add(time.Hour)
+
+	assert.NoError(suite.T(), suite.repo.Complete(context.Background(), record))
+	stored, err := suite.repo.Reserve(context.Background(), suite.record("admin", "key-1", time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), record, stored)
+
+	assert.NoError(suite.T(), suite.repo.Delete(context.Background(), record.ID))
+	stored, err = suite.repo.Reserve(context.Background(), suite.record("admin", "key-1", time.Hour))
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), stored)
+}
+
+func TestIdempotencyRepositorySuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepositorySuite))
+}
@@ -0,0 +1,87 @@
+package repository_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.InvitationRepository
+}
+
+func (suite *InvitationRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("invitations")
+	suite.repo = repositories.NewInvitationRepository(client, "test_db", "invitations", repositories.DefaultDeadlines())
+}
+
+func (suite *InvitationRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *InvitationRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *InvitationRepositorySuite) TestUseInvitation_SingleUse() {
+	invitation := &domain.Invitation{ID: uuid.New(), TokenHash: "hash", CreatedAt: time.Now().UTC(), ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	_, err := suite.repo.AddInvitation(context.Background(), invitation)
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), suite.repo.UseInvitation(context.Background(), invitation.ID, "first", time.Now().UTC()))
+	assert.EqualError(suite.T(), suite.repo.UseInvitation(context.Background(), invitation.ID, "second", time.Now().UTC()), "invitation not found")
+
+	found, err := suite.repo.GetInvitationByHash(context.Background(), "hash")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "first", found.UsedBy)
+
+	// a released invitation can be used again
+	assert.NoError(suite.T(), suite.repo.ReleaseInvitation(context.Background(), invitation.ID))
+	assert.NoError(suite.T(), suite.repo.UseInvitation(context.Background(), invitation.ID, "second", time.Now().UTC()))
+}
+
+func (suite *InvitationRepositorySuite) TestUseInvitation_Expired() {
+	invitation := &domain.Invitation{ID: uuid.New(), TokenHash: "hash", CreatedAt: time.Now().UTC(), ExpiresAt: time.Now().UTC().Add(-time.Minute)}
+	_, err := suite.repo.AddInvitation(context.Background(), invitation)
+	assert.NoError(suite.T(), err)
+
+	assert.EqualError(suite.T(), suite.repo.UseInvitation(context.Background(), invitation.ID, "testuser", time.Now().UTC()), "invitation not found")
+}
+
+func (suite *InvitationRepositorySuite) TestDeleteInvitation() {
+	invitation := &domain.Invitation{ID: uuid.New(), TokenHash: "hash", CreatedAt: time.Now().UTC(), ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	_, err := suite.repo.AddInvitation(context.Background(), invitation)
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), suite.repo.DeleteInvitation(context.Background(), invitation.ID))
+	assert.EqualError(suite.T(), suite.repo.DeleteInvitation(context.Background(), invitation.ID), "invitation not found")
+}
+
+func TestInvitationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(InvitationRepositorySuite))
+}
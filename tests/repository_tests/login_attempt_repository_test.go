@@ -0,0 +1,107 @@
+package repository_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.LoginAttemptRepository
+}
+
+func (suite *LoginAttemptRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("login_attempts")
+	suite.repo = repositories.NewLoginAttemptRepository(client, "test_db", "login_attempts", repositories.DefaultDeadlines())
+}
+
+func (suite *LoginAttemptRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *LoginAttemptRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *LoginAttemptRepositorySuite) TestGetAttempt_Empty() {
+	attempt, err := suite.repo.GetAttempt(context.Background(), "user:testuser")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "user:testuser", attempt.Key)
+	assert.Zero(suite.T(), attempt.Failures)
+}
+
+func (suite *LoginAttemptRepositorySuite) TestRecordFailure() {
+	_, err := suite.repo.RecordFailure(context.Background(), "user:testuser", time.Minute)
+	assert.NoError(suite.T(), err)
+
+	attempt, err := suite.repo.RecordFailure(context.Background(), "user:testuser", time.Minute)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, attempt.Failures)
+
+	// failures outside the window are forgotten
+	attempt, err = suite.repo.RecordFailure(context.Background(), "user:testuser", 0)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, attempt.Failures)
+}
+
+// lockouts longer than the window keep growing up to MaxLockout instead of starting over
+func (suite *LoginAttemptRepositorySuite) TestConsecutiveLockouts() {
+	policy := usecases.LockoutPolicy{MaxFailures: 1, BaseLockout: 20 * time.Millisecond, MaxLockout: 160 * time.Millisecond, Window: 30 * time.Millisecond}
+	var lockouts []time.Duration
+	for range 5 {
+		attempt, err := suite.repo.RecordFailure(context.Background(), "user:testuser", policy.Window)
+		assert.NoError(suite.T(), err)
+		lockout := policy.LockoutFor(attempt.Failures)
+		lockouts = append(lockouts, lockout)
+		assert.NoError(suite.T(), suite.repo.LockUntil(context.Background(), "user:testuser", attempt.LastFailure.Add(lockout)))
+		// the next attempt comes right after the lockout
+		time.Sleep(lockout + time.Millisecond)
+	}
+
+	assert.Equal(suite.T(), []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond, 160 * time.Millisecond, 160 * time.Millisecond}, lockouts)
+}
+
+func (suite *LoginAttemptRepositorySuite) TestLockUntilAndReset() {
+	until := time.Now().UTC().Add(time.Minute).Truncate(time.Millisecond)
+
+	_, err := suite.repo.RecordFailure(context.Background(), "ip:127.0.0.1", time.Minute)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.LockUntil(context.Background(), "ip:127.0.0.1", until))
+
+	attempt, err := suite.repo.GetAttempt(context.Background(), "ip:127.0.0.1")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), until.Equal(attempt.LockedUntil))
+
+	assert.NoError(suite.T(), suite.repo.Reset(context.Background(), "ip:127.0.0.1"))
+	attempt, err = suite.repo.GetAttempt(context.Background(), "ip:127.0.0.1")
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), attempt.Failures)
+}
+
+func TestLoginAttemptRepositorySuite(t *testing.T) {
+	suite.Run(t, new(LoginAttemptRepositorySuite))
+}
@@ -0,0 +1,79 @@
+package repository_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaskHistoryRepositorySuite struct {
+	suite.Suite
+	client     *mongo.Client
+	collection *mongo.Collection
+	repo       *repositories.TaskHistoryRepository
+}
+
+func (suite *TaskHistoryRepositorySuite) SetupSuite() {
+	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+
+	suite.client = client
+	suite.collection = client.Database("test_db").Collection("task_history")
+	suite.repo = repositories.NewTaskHistoryRepository(client, "test_db", "task_history", repositories.DefaultDeadlines())
+}
+
+func (suite *TaskHistoryRepositorySuite) TearDownSuite() {
+	err := suite.client.Disconnect(context.Background())
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *TaskHistoryRepositorySuite) TearDownTest() {
+	_, err := suite.collection.DeleteMany(context.Background(), bson.D{})
+	if err != nil {
+		suite.T().Fatal(err)
+	}
+}
+
+func (suite *TaskHistoryRepositorySuite) TestGetHistory_NewestFirst() {
+	taskID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	created := &domain.TaskHistoryEntry{ID: uuid.New(), TaskID: taskID, Time: now.Add(-time.Hour), Actor: "admin", Action: domain.TaskCreated,
+		Changes: []domain.TaskFieldChange{{Field: "title", NewValue: "Report"}}}
+	updated := &domain.TaskHistoryEntry{ID: uuid.New(), TaskID: taskID, Time: now, Actor: "admin", Action: domain.TaskUpdated,
+		Changes: []domain.TaskFieldChange{{Field: "due_date", OldValue: "2030-01-10T00:00:00Z", NewValue: "2030-01-17T00:00:00Z"}}}
+	other := &domain.TaskHistoryEntry{ID: uuid.New(), TaskID: uuid.New(), Time: now, Actor: "admin", Action: domain.TaskCreated}
+	for _, entry := range []*domain.TaskHistoryEntry{created, updated, other} {
+		assert.NoError(suite.T(), suite.repo.AddEntry(context.Background(), entry))
+	}
+
+	history, err := suite.repo.GetHistory(context.Background(), taskID)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []domain.TaskHistoryEntry{*updated, *created}, history)
+}
+
+func (suite *TaskHistoryRepositorySuite) TestGetHistory_Empty() {
+	history, err := suite.repo.GetHistory(context.Background(), uuid.New())
+
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), history)
+	assert.NotNil(suite.T(), history)
+}
+
+func TestTaskHistoryRepositorySuite(t *testing.T) {
+	suite.Run(t, new(TaskHistoryRepositorySuite))
+}
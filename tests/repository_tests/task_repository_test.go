@@ -31,7 +31,7 @@ func (suite *TaskRepositorySuite) SetupSuite() {
 
 	suite.client = client
 	suite.collection = client.Database("test_db").Collection("tasks")
-	suite.repo = repositories.NewTaskRepository(client, "test_db", "tasks")
+	suite.repo = repositories.NewTaskRepository(client, "test_db", "tasks", repositories.DefaultDeadlines())
 }
 
 func (suite *TaskRepositorySuite) TearDownSuite() {
@@ -66,7 +66,7 @@ func (suite *TaskRepositorySuite) TestAddTask() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	addedTask, err := suite.repo.AddTask(task)
+	addedTask, err := suite.repo.AddTask(context.Background(), task)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), task, *addedTask)
 }
@@ -87,13 +87,13 @@ func (suite *TaskRepositorySuite) TestGetTasks() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	_, err := suite.repo.AddTask(task1)
+	_, err := suite.repo.AddTask(context.Background(), task1)
 	assert.NoError(suite.T(), err)
 
-	_, err = suite.repo.AddTask(task2)
+	_, err = suite.repo.AddTask(context.Background(), task2)
 	assert.NoError(suite.T(), err)
 
-	tasks, err := suite.repo.GetTasks()
+	tasks, err := suite.repo.GetTasks(context.Background())
 	assert.NoError(suite.T(), err)
 	assert.Len(suite.T(), tasks, 2)
 }
@@ -107,10 +107,10 @@ func (suite *TaskRepositorySuite) TestGetTaskById() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	_, err := suite.repo.AddTask(task)
+	_, err := suite.repo.AddTask(context.Background(), task)
 	assert.NoError(suite.T(), err)
 
-	foundTask, err := suite.repo.GetTaskById(task.ID)
+	foundTask, err := suite.repo.GetTaskById(context.Background(), task.ID)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), task.ID, foundTask.ID)
 }
@@ -124,7 +124,7 @@ func (suite *TaskRepositorySuite) TestUpdateTaskByID() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	_, err := suite.repo.AddTask(task)
+	_, err := suite.repo.AddTask(context.Background(), task)
 	assert.NoError(suite.T(), err)
 
 	updatedTask := domain.Task{
@@ -134,10 +134,12 @@ func (suite *TaskRepositorySuite) TestUpdateTaskByID() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	err = suite.repo.UpdateTaskByID(task.ID, updatedTask)
+	before, err := suite.repo.UpdateTaskByID(context.Background(), task.ID, updatedTask)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), task.Title, before.Title)
+	assert.Equal(suite.T(), task.Status, before.Status)
 
-	foundTask, err := suite.repo.GetTaskById(task.ID)
+	foundTask, err := suite.repo.GetTaskById(context.Background(), task.ID)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), updatedTask.Title, foundTask.Title)
 	assert.Equal(suite.T(), updatedTask.Description, foundTask.Description)
@@ -153,15 +155,204 @@ func (suite *TaskRepositorySuite) TestDeleteTask() {
 		DueDate:     time.Now().UTC(),
 	}
 
-	_, err := suite.repo.AddTask(task)
+	_, err := suite.repo.AddTask(context.Background(), task)
 	assert.NoError(suite.T(), err)
 
-	err = suite.repo.DeleteTask(task.ID)
+	deletedTask, err := suite.repo.DeleteTask(context.Background(), task.ID, "admin", time.Now().UTC())
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), task.Title, deletedTask.Title)
+	assert.Nil(suite.T(), deletedTask.DeletedAt)
+
+	_, err = suite.repo.GetTaskById(context.Background(), task.ID)
+	assert.ErrorIs(suite.T(), err, domain.ErrTaskNotFound)
+	tasks, err := suite.repo.GetTasks(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), tasks)
+
+	// a task can only be deleted once
+	_, err = suite.repo.DeleteTask(context.Background(), task.ID, "admin", time.Now().UTC())
+	assert.ErrorIs(suite.T(), err, domain.ErrTaskNotFound)
+	_, err = suite.repo.UpdateTaskByID(context.Background(), task.ID, task)
+	assert.ErrorIs(suite.T(), err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositorySuite) TestGetDeletedTasks() {
+	older := domain.Task{ID: uuid.New(), Title: "Older", Status: "pending"}
+	newer := domain.Task{ID: uuid.New(), Title: "Newer", Status: "pending"}
+	kept := domain.Task{ID: uuid.New(), Title: "Kept", Status: "pending"}
+	for _, task := range []domain.Task{older, newer, kept} {
+		_, err := suite.repo.AddTask(context.Background(), task)
+		assert.NoError(suite.T(), err)
+	}
+	now := time.Now().UTC()
+	_, err := suite.repo.DeleteTask(context.Background(), older.ID, "admin", now.Add(-time.Hour))
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.DeleteTask(context.Background(), newer.ID, "other", now)
+	assert.NoError(suite.T(), err)
+
+	deletedTasks, err := suite.repo.GetDeletedTasks(context.Background())
+
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), deletedTasks, 2) {
+		assert.Equal(suite.T(), newer.ID, deletedTasks[0].ID)
+		assert.Equal(suite.T(), "other", deletedTasks[0].DeletedBy)
+		assert.WithinDuration(suite.T(), now, *deletedTasks[0].DeletedAt, time.Millisecond)
+		assert.Equal(suite.T(), older.ID, deletedTasks[1].ID)
+	}
+}
+
+func (suite *TaskRepositorySuite) TestRestoreTask() {
+	task := domain.Task{ID: uuid.New(), Title: "Test Task", Status: "pending"}
+	_, err := suite.repo.AddTask(context.Background(), task)
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.DeleteTask(context.Background(), task.ID, "admin", time.Now().UTC())
+	assert.NoError(suite.T(), err)
+
+	deletedTask, err := suite.repo.RestoreTask(context.Background(), task.ID)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "admin", deletedTask.DeletedBy)
+	foundTask, err := suite.repo.GetTaskById(context.Background(), task.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundTask.DeletedAt)
+	assert.Empty(suite.T(), foundTask.DeletedBy)
+
+	// only tasks in the trash can be restored
+	_, err = suite.repo.RestoreTask(context.Background(), task.ID)
+	assert.ErrorIs(suite.T(), err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskRepositorySuite) TestPurgeDeletedTasks() {
+	expired := domain.Task{ID: uuid.New(), Title: "Expired", Status: "pending"}
+	recent := domain.Task{ID: uuid.New(), Title: "Recent", Status: "pending"}
+	active := domain.Task{ID: uuid.New(), Title: "Active", Status: "pending"}
+	for _, task := range []domain.Task{expired, recent, active} {
+		_, err := suite.repo.AddTask(context.Background(), task)
+		assert.NoError(suite.T(), err)
+	}
+	now := time.Now().UTC()
+	_, err := suite.repo.DeleteTask(context.Background(), expired.ID, "admin", now.Add(-48*time.Hour))
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.DeleteTask(context.Background(), recent.ID, "admin", now)
+	assert.NoError(suite.T(), err)
+
+	purged, err := suite.repo.PurgeDeletedTasks(context.Background(), now.Add(-24*time.Hour))
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), purged)
+	deletedTasks, err := suite.repo.GetDeletedTasks(context.Background())
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), deletedTasks, 1) {
+		assert.Equal(suite.T(), recent.ID, deletedTasks[0].ID)
+	}
+	_, err = suite.repo.GetTaskById(context.Background(), active.ID)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *TaskRepositorySuite) TestGetTasksByIDs() {
+	task := domain.Task{ID: uuid.New(), Title: "Task", Status: "pending"}
+	deletedTask := domain.Task{ID: uuid.New(), Title: "Deleted", Status: "pending"}
+	other := domain.Task{ID: uuid.New(), Title: "Other", Status: "pending"}
+	for _, t := range []domain.Task{task, deletedTask, other} {
+		_, err := suite.repo.AddTask(context.Background(), t)
+		assert.NoError(suite.T(), err)
+	}
+	_, err := suite.repo.DeleteTask(context.Background(), deletedTask.ID, "admin", time.Now().UTC())
+	assert.NoError(suite.T(), err)
+
+	tasks, err := suite.repo.GetTasksByIDs(context.Background(), []uuid.UUID{task.ID, deletedTask.ID, uuid.New()})
+
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), tasks, 1) {
+		assert.Equal(suite.T(), task.ID, tasks[0].ID)
+	}
+}
+
+func (suite *TaskRepositorySuite) TestBulkWriteTasks() {
+	updated := domain.Task{ID: uuid.New(), Title: "Updated", Description: "Description", Status: "pending"}
+	deletedTask := domain.Task{ID: uuid.New(), Title: "Deleted", Status: "pending"}
+	for _, t := range []domain.Task{updated, deletedTask} {
+		_, err := suite.repo.AddTask(context.Background(), t)
+		assert.NoError(suite.T(), err)
+	}
+	created := domain.Task{ID: uuid.New(), Title: "Created", Status: "pending"}
+	deletedAt := time.Now().UTC()
+	writes := []domain.TaskWrite{
+		{Op: domain.BulkCreate, Task: created},
+		{Op: domain.BulkSetStatus, Task: domain.Task{ID: updated.ID, Status: "completed"}},
+		{Op: domain.BulkDelete, Task: domain.Task{ID: deletedTask.ID, DeletedAt: &deletedAt, DeletedBy: "admin"}},
+		// the id is taken
+		{Op: domain.BulkCreate, Task: updated},
+	}
+
+	writeErrs, err := suite.repo.BulkWriteTasks(context.Background(), writes)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), writeErrs[0])
+	assert.NoError(suite.T(), writeErrs[1])
+	assert.NoError(suite.T(), writeErrs[2])
+	assert.Error(suite.T(), writeErrs[3])
+	tasks, err := suite.repo.GetTasksByIDs(context.Background(), []uuid.UUID{created.ID, updated.ID, deletedTask.ID})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), tasks, 2)
+	found, err := suite.repo.GetTaskById(context.Background(), updated.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "completed", found.Status)
+	assert.Equal(suite.T(), "Updated", found.Title)
+	deletedTasks, err := suite.repo.GetDeletedTasks(context.Background())
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), deletedTasks, 1) {
+		assert.Equal(suite.T(), "admin", deletedTasks[0].DeletedBy)
+	}
+}
+
+// writes to tasks that were trashed or purged after they were read fail, the others are applied
+func (suite *TaskRepositorySuite) TestBulkWriteTasks_TaskGone() {
+	trashed := domain.Task{ID: uuid.New(), Title: "Trashed", Status: "pending"}
+	kept := domain.Task{ID: uuid.New(), Title: "Kept", Status: "pending"}
+	deleted := domain.Task{ID: uuid.New(), Title: "Deleted", Status: "pending"}
+	for _, t := range []domain.Task{trashed, kept, deleted} {
+		_, err := suite.repo.AddTask(context.Background(), t)
+		assert.NoError(suite.T(), err)
+	}
+	_, err := suite.repo.DeleteTask(context.Background(), trashed.ID, "admin", time.Now().UTC())
+	assert.NoError(suite.T(), err)
+	deletedAt := time.Now().UTC()
+	writes := []domain.TaskWrite{
+		{Op: domain.BulkSetStatus, Task: domain.Task{ID: trashed.ID, Status: "completed"}},
+		{Op: domain.BulkDelete, Task: domain.Task{ID: uuid.New(), DeletedAt: &deletedAt, DeletedBy: "admin"}},
+		{Op: domain.BulkSetStatus, Task: domain.Task{ID: kept.ID, Status: "completed"}},
+		{Op: domain.BulkDelete, Task: domain.Task{ID: deleted.ID, DeletedAt: &deletedAt, DeletedBy: "admin"}},
+	}
+
+	writeErrs, err := suite.repo.BulkWriteTasks(context.Background(), writes)
+
+	assert.NoError(suite.T(), err)
+	assert.ErrorIs(suite.T(), writeErrs[0], domain.ErrTaskNotFound)
+	assert.ErrorIs(suite.T(), writeErrs[1], domain.ErrTaskNotFound)
+	assert.NoError(suite.T(), writeErrs[2])
+	assert.NoError(suite.T(), writeErrs[3])
+	found, err := suite.repo.GetTaskById(context.Background(), kept.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "completed", found.Status)
+}
+
+// a canceled request stops the query
+func (suite *TaskRepositorySuite) TestGetTasks_CanceledContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := suite.repo.GetTasks(ctx)
 
-	_, err = suite.repo.GetTaskById(task.ID)
 	assert.Error(suite.T(), err)
-	assert.Equal(suite.T(), "task Not Found", err.Error())
+}
+
+func (suite *TaskRepositorySuite) TestGetTaskById_Deadline() {
+	repo := repositories.NewTaskRepository(suite.client, "test_db", "tasks", repositories.Deadlines{Read: time.Nanosecond})
+
+	_, err := repo.GetTaskById(context.Background(), uuid.New())
+
+	assert.True(suite.T(), mongo.IsTimeout(err))
 }
 
 func TestTaskRepositorySuite(t *testing.T) {
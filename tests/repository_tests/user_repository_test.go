@@ -3,6 +3,7 @@ package repository_tests
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
@@ -32,7 +33,7 @@ func (suite *UserRepositorySuite) SetupSuite() {
 	// Set up a test database
 	suite.client = client
 	suite.collection = client.Database("test_db").Collection("users")
-	suite.repo = repositories.NewUserRepository(client, "test_db", "users")
+	suite.repo = repositories.NewUserRepository(client, "test_db", "users", repositories.DefaultDeadlines())
 }
 
 func (suite *UserRepositorySuite) TearDownSuite() {
@@ -59,7 +60,7 @@ func (suite *UserRepositorySuite) TestRegisterUser_Success() {
 		IsAdmin:  false,
 	}
 
-	createdUser, err := suite.repo.RegisterUser(user)
+	createdUser, err := suite.repo.RegisterUser(context.Background(), user)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), user.Username, createdUser.Username)
 	assert.Equal(suite.T(), user.Password, createdUser.Password)
@@ -73,12 +74,12 @@ func (suite *UserRepositorySuite) TestRegisterUser_UsernameExists() {
 		IsAdmin:  false,
 	}
 
-	_, err := suite.repo.RegisterUser(user)
+	_, err := suite.repo.RegisterUser(context.Background(), user)
 	assert.NoError(suite.T(), err)
 
 	user.ID = uuid.New()
 	// Try to register the same user again
-	_, err = suite.repo.RegisterUser(user)
+	_, err = suite.repo.RegisterUser(context.Background(), user)
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "username already exists", err.Error())
 }
@@ -91,22 +92,178 @@ func (suite *UserRepositorySuite) TestGetUser_Success() {
 		IsAdmin:  false,
 	}
 
-	_, err := suite.repo.RegisterUser(user)
+	_, err := suite.repo.RegisterUser(context.Background(), user)
 	assert.NoError(suite.T(), err)
 
 	// Retrieve the user
-	retrievedUser, err := suite.repo.GetUser(user.Username)
+	retrievedUser, err := suite.repo.GetUser(context.Background(), user.Username)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), user.Username, retrievedUser.Username)
 	assert.Equal(suite.T(), user.Password, retrievedUser.Password)
 }
 
 func (suite *UserRepositorySuite) TestGetUser_NotFound() {
-	_, err := suite.repo.GetUser("nonexistentuser")
+	_, err := suite.repo.GetUser(context.Background(), "nonexistentuser")
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "user not found", err.Error())
 }
 
+func (suite *UserRepositorySuite) TestGetUserByOIDCSubject() {
+	user := &domain.User{
+		ID:          uuid.New(),
+		Username:    "oidcuser",
+		OIDCIssuer:  "https://idp.example.com",
+		OIDCSubject: "subject-1",
+	}
+
+	_, err := suite.repo.RegisterUser(context.Background(), user)
+	assert.NoError(suite.T(), err)
+
+	retrievedUser, err := suite.repo.GetUserByOIDCSubject(context.Background(), "https://idp.example.com", "subject-1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), user.Username, retrievedUser.Username)
+
+	_, err = suite.repo.GetUserByOIDCSubject(context.Background(), "https://other.example.com", "subject-1")
+	assert.ErrorIs(suite.T(), err, domain.ErrUserNotFound)
+
+	// the same identity can't be linked twice
+	_, err = suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "another", OIDCIssuer: "https://idp.example.com", OIDCSubject: "subject-1"})
+	assert.ErrorIs(suite.T(), err, domain.ErrOIDCIdentityLinked)
+}
+
+func (suite *UserRepositorySuite) TestLinkOIDCIdentity() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane"})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "john"})
+	assert.NoError(suite.T(), err)
+
+	err = suite.repo.LinkOIDCIdentity(context.Background(), "jane", "https://idp.example.com", "subject-1")
+	assert.NoError(suite.T(), err)
+	linkedUser, err := suite.repo.GetUserByOIDCSubject(context.Background(), "https://idp.example.com", "subject-1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "jane", linkedUser.Username)
+
+	// a linked user keeps its identity
+	err = suite.repo.LinkOIDCIdentity(context.Background(), "jane", "https://idp.example.com", "subject-2")
+	assert.ErrorIs(suite.T(), err, domain.ErrOIDCIdentityLinked)
+	// and an identity belongs to one user
+	err = suite.repo.LinkOIDCIdentity(context.Background(), "john", "https://idp.example.com", "subject-1")
+	assert.ErrorIs(suite.T(), err, domain.ErrOIDCIdentityLinked)
+}
+
+// a totp code is only accepted once, and never after a newer one
+func (suite *UserRepositorySuite) TestUseTOTPCounter() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane"})
+	assert.NoError(suite.T(), err)
+
+	assert.NoError(suite.T(), suite.repo.UseTOTPCounter(context.Background(), "jane", 1000))
+	assert.ErrorIs(suite.T(), suite.repo.UseTOTPCounter(context.Background(), "jane", 1000), domain.ErrInvalidTwoFactorCode)
+	assert.ErrorIs(suite.T(), suite.repo.UseTOTPCounter(context.Background(), "jane", 999), domain.ErrInvalidTwoFactorCode)
+	assert.NoError(suite.T(), suite.repo.UseTOTPCounter(context.Background(), "jane", 1001))
+
+	user, err := suite.repo.GetUser(context.Background(), "jane")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1001), user.TOTPLastCounter)
+}
+
+func (suite *UserRepositorySuite) TestRegisterUser_EmailExists() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "another", Email: "jane@example.com"})
+	assert.ErrorIs(suite.T(), err, domain.ErrEmailExists)
+}
+
+func (suite *UserRepositorySuite) TestVerifyEmail() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.SetEmailVerification(context.Background(), "jane", "", "hash", time.Now().Add(time.Hour)))
+
+	assert.NoError(suite.T(), suite.repo.VerifyEmail(context.Background(), "hash", time.Now()))
+	user, err := suite.repo.GetUserByEmail(context.Background(), "jane@example.com")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), user.EmailVerified)
+
+	// the token is single use
+	assert.EqualError(suite.T(), suite.repo.VerifyEmail(context.Background(), "hash", time.Now()), "invalid verification token")
+}
+
+func (suite *UserRepositorySuite) TestVerifyEmail_PendingEmail() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", EmailVerified: true})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.SetEmailVerification(context.Background(), "jane", "new@example.com", "hash", time.Now().Add(time.Hour)))
+
+	// the current email stays verified until the new one is confirmed
+	user, err := suite.repo.GetUser(context.Background(), "jane")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "jane@example.com", user.Email)
+	assert.True(suite.T(), user.EmailVerified)
+	assert.Equal(suite.T(), "new@example.com", user.PendingEmail)
+
+	assert.NoError(suite.T(), suite.repo.VerifyEmail(context.Background(), "hash", time.Now()))
+	user, err = suite.repo.GetUser(context.Background(), "jane")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "new@example.com", user.Email)
+	assert.True(suite.T(), user.EmailVerified)
+	assert.Empty(suite.T(), user.PendingEmail)
+}
+
+func (suite *UserRepositorySuite) TestVerifyEmail_PendingEmailTaken() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", EmailVerified: true})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.SetEmailVerification(context.Background(), "jane", "new@example.com", "hash", time.Now().Add(time.Hour)))
+	_, err = suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "john", Email: "new@example.com"})
+	assert.NoError(suite.T(), err)
+
+	assert.ErrorIs(suite.T(), suite.repo.VerifyEmail(context.Background(), "hash", time.Now()), domain.ErrEmailExists)
+	user, err := suite.repo.GetUser(context.Background(), "jane")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "jane@example.com", user.Email)
+}
+
+func (suite *UserRepositorySuite) TestVerifyEmail_Expired() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.repo.SetEmailVerification(context.Background(), "jane", "", "hash", time.Now().Add(-time.Minute)))
+
+	assert.EqualError(suite.T(), suite.repo.VerifyEmail(context.Background(), "hash", time.Now()), "invalid verification token")
+}
+
+func (suite *UserRepositorySuite) TestUpdateProfile() {
+	_, err := suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", EmailVerified: true, DisplayName: "Jane"})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.RegisterUser(context.Background(), &domain.User{ID: uuid.New(), Username: "john", Email: "john@example.com"})
+	assert.NoError(suite.T(), err)
+
+	timezone, displayName := "Europe/Berlin", ""
+	user, err := suite.repo.UpdateProfile(context.Background(), "jane", domain.ProfileUpdate{Timezone: &timezone, DisplayName: &displayName})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Europe/Berlin", user.Timezone)
+	assert.Empty(suite.T(), user.DisplayName)
+	assert.True(suite.T(), user.EmailVerified)
+
+	// a new email has to be verified again
+	email := "jane@example.org"
+	user, err = suite.repo.UpdateProfile(context.Background(), "jane", domain.ProfileUpdate{Email: &email})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "jane@example.org", user.Email)
+	assert.False(suite.T(), user.EmailVerified)
+
+	taken := "john@example.com"
+	_, err = suite.repo.UpdateProfile(context.Background(), "jane", domain.ProfileUpdate{Email: &taken})
+	assert.ErrorIs(suite.T(), err, domain.ErrEmailExists)
+
+	// cleared emails don't collide in the unique index
+	cleared := ""
+	_, err = suite.repo.UpdateProfile(context.Background(), "jane", domain.ProfileUpdate{Email: &cleared})
+	assert.NoError(suite.T(), err)
+	_, err = suite.repo.UpdateProfile(context.Background(), "john", domain.ProfileUpdate{Email: &cleared})
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.repo.UpdateProfile(context.Background(), "nobody", domain.ProfileUpdate{Timezone: &timezone})
+	assert.ErrorIs(suite.T(), err, domain.ErrUserNotFound)
+}
+
 func (suite *UserRepositorySuite) TestPromoteUser_Success() {
 	user := &domain.User{
 		ID:       uuid.New(),
@@ -115,21 +272,21 @@ func (suite *UserRepositorySuite) TestPromoteUser_Success() {
 		IsAdmin:  false,
 	}
 
-	_, err := suite.repo.RegisterUser(user)
+	_, err := suite.repo.RegisterUser(context.Background(), user)
 	assert.NoError(suite.T(), err)
 
 	// Promote the user
-	err = suite.repo.PromoteUser(user.Username)
+	err = suite.repo.PromoteUser(context.Background(), user.Username)
 	assert.NoError(suite.T(), err)
 
 	// Retrieve the user and check the isAdmin field
-	updatedUser, err := suite.repo.GetUser(user.Username)
+	updatedUser, err := suite.repo.GetUser(context.Background(), user.Username)
 	assert.NoError(suite.T(), err)
 	assert.True(suite.T(), updatedUser.IsAdmin)
 }
 
 func (suite *UserRepositorySuite) TestPromoteUser_NotFound() {
-	err := suite.repo.PromoteUser("nonexistentuser")
+	err := suite.repo.PromoteUser(context.Background(), "nonexistentuser")
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "username not found", err.Error())
 }
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/router"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// the whole HTTP stack with real routing and JWTs, only the use cases are mocked
+type RouterSuite struct {
+	suite.Suite
+	router            *gin.Engine
+	jwtService        *infrastructure.JwtService
+	mockTaskService   *mocks.TaskServiceInterface
+	mockAPIKeyService *mocks.APIKeyServiceInterface
+	mockAuditService  *mocks.AuditServiceInterface
+	mockIdempotency   *mocks.IdempotencyServiceInterface
+	// requests seen by the extra middleware
+	seen int
+	// the client IP of the last request
+	clientIP string
+}
+
+func (suite *RouterSuite) SetupTest() {
+	suite.setupRouter(nil)
+}
+
+func (suite *RouterSuite) setupRouter(trustedProxies []string) {
+	suite.jwtService = &infrastructure.JwtService{JwtSecret: []byte(testJWTSecret)}
+	suite.mockTaskService = new(mocks.TaskServiceInterface)
+	suite.mockAPIKeyService = new(mocks.APIKeyServiceInterface)
+	suite.mockAuditService = new(mocks.AuditServiceInterface)
+	suite.mockIdempotency = new(mocks.IdempotencyServiceInterface)
+	suite.seen = 0
+
+	suite.router = router.SetupRouter(router.Dependencies{
+		JwtService:           suite.jwtService,
+		APIKeyService:        suite.mockAPIKeyService,
+		IdempotencyService:   suite.mockIdempotency,
+		Validator:            testValidator,
+		TaskController:       &controllers.TaskController{Service: suite.mockTaskService},
+		UserController:       &controllers.UserController{Service: new(mocks.UserServiceInterface)},
+		APIKeyController:     &controllers.APIKeyController{Service: suite.mockAPIKeyService},
+		InvitationController: &controllers.InvitationController{Service: new(mocks.InvitationServiceInterface)},
+		AuditController:      &controllers.AuditController{Service: suite.mockAuditService},
+		HealthController:     &controllers.HealthController{},
+		TrustedProxies:       trustedProxies,
+		Middlewares: []gin.HandlerFunc{func(c *gin.Context) {
+			suite.seen++
+			suite.clientIP = c.ClientIP()
+			c.Next()
+		}},
+	})
+}
+
+func (suite *RouterSuite) token(username string, isAdmin bool) string {
+	token, err := suite.jwtService.GenerateToken(username, isAdmin)
+	suite.Require().NoError(err)
+	return token
+}
+
+func (suite *RouterSuite) serve(method string, path string, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func (suite *RouterSuite) TestGetTasks_WithToken() {
+	tasks := []domain.Task{{ID: uuid.New(), Title: "Task 1", Status: "pending"}}
+	suite.mockTaskService.On("GetTasks", mock.Anything).Return(tasks, nil)
+
+	w := suite.serve(http.MethodGet, "/tasks", "", bearer(suite.token("user", false)))
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), "Task 1")
+	suite.NotEmpty(w.Header().Get(infrastructure.RequestIDHeader))
+	suite.Equal(1, suite.seen)
+}
+
+// clients can't pick the IP the login throttling counts under
+func (suite *RouterSuite) TestClientIP_UntrustedProxy() {
+	suite.serve(http.MethodGet, "/healthz", "", http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+
+	suite.Equal("192.0.2.1", suite.clientIP)
+}
+
+func (suite *RouterSuite) TestClientIP_TrustedProxy() {
+	suite.setupRouter([]string{"192.0.2.0/24"})
+
+	suite.serve(http.MethodGet, "/healthz", "", http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+
+	suite.Equal("203.0.113.7", suite.clientIP)
+}
+
+func (suite *RouterSuite) TestGetTasks_WithoutToken() {
+	w := suite.serve(http.MethodGet, "/tasks", "", nil)
+
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	suite.Equal(http.StatusUnauthorized, decodeProblem(suite.T(), w).Status)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "GetTasks", mock.Anything)
+}
+
+// a token signed with another secret is rejected
+func (suite *RouterSuite) TestGetTasks_ForeignToken() {
+	other := &infrastructure.JwtService{JwtSecret: []byte("another secret that is long enough")}
+	token, err := other.GenerateToken("user", true)
+	suite.Require().NoError(err)
+
+	w := suite.serve(http.MethodGet, "/tasks", "", bearer(token))
+
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "GetTasks", mock.Anything)
+}
+
+func (suite *RouterSuite) TestAddTask_RequiresAdmin() {
+	body := `{"title": "Task", "status": "pending"}`
+
+	w := suite.serve(http.MethodPost, "/tasks", body, bearer(suite.token("user", false)))
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "AddTask", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *RouterSuite) TestAudit_RequiresAdmin() {
+	w := suite.serve(http.MethodGet, "/audit", "", bearer(suite.token("user", false)))
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.mockAuditService.AssertNotCalled(suite.T(), "GetEntries", mock.Anything, mock.Anything)
+}
+
+// entries can't be added, changed or removed through the API
+func (suite *RouterSuite) TestAudit_ReadOnly() {
+	suite.mockAuditService.On("GetEntries", mock.Anything, domain.AuditFilter{}).Return([]domain.AuditEntry{}, nil)
+	admin := bearer(suite.token("admin", true))
+
+	suite.Equal(http.StatusOK, suite.serve(http.MethodGet, "/audit", "", admin).Code)
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		suite.Equal(http.StatusNotFound, suite.serve(method, "/audit", "{}", admin).Code, method)
+	}
+}
+
+func (suite *RouterSuite) TestTrash_RequiresAdmin() {
+	user := bearer(suite.token("user", false))
+
+	suite.Equal(http.StatusForbidden, suite.serve(http.MethodGet, "/tasks/trash", "", user).Code)
+	suite.Equal(http.StatusForbidden, suite.serve(http.MethodPost, "/tasks/"+uuid.NewString()+"/restore", "", user).Code)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "GetDeletedTasks", mock.Anything)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "GetTaskById", mock.Anything, mock.Anything)
+}
+
+func (suite *RouterSuite) TestBulk_RequiresAdmin() {
+	body := `{"operations": [{"op": "delete", "id": "` + uuid.NewString() + `"}]}`
+
+	w := suite.serve(http.MethodPost, "/tasks/bulk", body, bearer(suite.token("user", false)))
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.mockTaskService.AssertNotCalled(suite.T(), "ApplyBulkOperations", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// the trash isn't mistaken for a task id
+func (suite *RouterSuite) TestTrash_Admin() {
+	suite.mockTaskService.On("GetDeletedTasks", mock.Anything).Return([]domain.Task{}, nil)
+
+	w := suite.serve(http.MethodGet, "/tasks/trash", "", bearer(suite.token("admin", true)))
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.mockTaskService.AssertExpectations(suite.T())
+}
+
+func (suite *RouterSuite) TestAddTask_Admin() {
+	task := &domain.Task{ID: uuid.New(), Title: "Task", Status: "pending", DueDate: time.Now().Add(time.Hour)}
+	suite.mockTaskService.On("AddTask", mock.Anything, "admin", mock.AnythingOfType("domain.Task")).Return(task, nil)
+	body := `{"title": "Task", "description": "Description", "status": "pending", "due_date": "2030-01-01T00:00:00Z"}`
+
+	w := suite.serve(http.MethodPost, "/tasks", body, bearer(suite.token("admin", true)))
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.mockTaskService.AssertExpectations(suite.T())
+}
+
+// a retried task creation gets the first response instead of a new task
+func (suite *RouterSuite) TestAddTask_IdempotencyKey() {
+	record := &domain.IdempotencyRecord{ID: uuid.New(), Status: http.StatusCreated, ContentType: "application/json; charset=utf-8", Body: []byte(`{"id":"1"}`)}
+	suite.mockIdempotency.On("Begin", mock.Anything, "admin", "key-1", mock.AnythingOfType("string")).Return(record, nil)
+	header := bearer(suite.token("admin", true))
+	header.Set(infrastructure.IdempotencyKeyHeader, "key-1")
+
+	w := suite.serve(http.MethodPost, "/tasks", `{"title": "Task"}`, header)
+
+	suite.Equal(http.StatusCreated, w.Code)
+	suite.Equal(`{"id":"1"}`, w.Body.String())
+	suite.mockTaskService.AssertNotCalled(suite.T(), "AddTask", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// validation errors are translated by the validator given to the router
+func (suite *RouterSuite) TestAddTask_ValidationErrorTranslated() {
+	header := bearer(suite.token("admin", true))
+	header.Set("Accept-Language", "es")
+
+	body := `{"description": "Description", "status": "pending", "due_date": "2030-01-01T00:00:00Z"}`
+
+	w := suite.serve(http.MethodPost, "/tasks", body, header)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	problem := decodeProblem(suite.T(), w)
+	suite.Require().Len(problem.InvalidParams, 1)
+	suite.Equal("title es un campo requerido", problem.InvalidParams[0].Reason)
+}
+
+func (suite *RouterSuite) TestGetTasks_APIKey() {
+	apiKey := &domain.APIKey{ID: uuid.New(), Scopes: []string{domain.APIKeyScopeRead}}
+	user := &domain.User{Username: "user"}
+	suite.mockAPIKeyService.On("AuthenticateAPIKey", mock.Anything, "key").Return(apiKey, user, nil)
+	suite.mockTaskService.On("GetTasks", mock.Anything).Return([]domain.Task{}, nil)
+
+	w := suite.serve(http.MethodGet, "/tasks", "", http.Header{"X-Api-Key": {"key"}})
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.mockTaskService.AssertExpectations(suite.T())
+}
+
+func (suite *RouterSuite) TestUnknownRoute() {
+	w := suite.serve(http.MethodGet, "/unknown", "", nil)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+	suite.Equal(http.StatusNotFound, decodeProblem(suite.T(), w).Status)
+}
+
+// without an oidc controller the oidc routes don't exist
+func (suite *RouterSuite) TestOIDCDisabled() {
+	w := suite.serve(http.MethodGet, "/oidc/login", "", nil)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+// the probes need no credentials
+func (suite *RouterSuite) TestHealthEndpoints() {
+	suite.Equal(http.StatusOK, suite.serve(http.MethodGet, "/healthz", "", nil).Code)
+	suite.Equal(http.StatusOK, suite.serve(http.MethodGet, "/readyz", "", nil).Code)
+}
+
+func TestRouterSuite(t *testing.T) {
+	suite.Run(t, new(RouterSuite))
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerSuite struct {
+	suite.Suite
+	listener net.Listener
+	// closed when the slow handler started
+	started chan struct{}
+	// closed to let the slow handler finish
+	release chan struct{}
+}
+
+func (suite *ServerSuite) SetupTest() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.listener = listener
+	suite.started = make(chan struct{})
+	suite.release = make(chan struct{})
+}
+
+// serve a handler that waits for release or for its request to be canceled
+func (suite *ServerSuite) serve(ctx context.Context, shutdownTimeout time.Duration) chan error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(suite.started)
+		select {
+		case <-suite.release:
+			w.Write([]byte("done"))
+		case <-r.Context().Done():
+		}
+	})
+	server := infrastructure.NewServer(mux, infrastructure.DefaultServerConfig())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- infrastructure.Serve(ctx, server, suite.listener, shutdownTimeout)
+	}()
+	return served
+}
+
+func (suite *ServerSuite) get(path string) chan *http.Response {
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + suite.listener.Addr().String() + path)
+		if err != nil {
+			responses <- nil
+			return
+		}
+		responses <- resp
+	}()
+	return responses
+}
+
+func (suite *ServerSuite) TestServe_DrainsInFlightRequests() {
+	ctx, cancel := context.WithCancel(context.Background())
+	served := suite.serve(ctx, 5*time.Second)
+	responses := suite.get("/slow")
+	<-suite.started
+
+	cancel()
+	// shutdown waits for the request
+	select {
+	case <-served:
+		suite.Fail("server stopped before the request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(suite.release)
+	resp := <-responses
+	suite.Require().NotNil(resp)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Equal("done", string(body))
+	suite.NoError(<-served)
+
+	_, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.Error(err)
+}
+
+func (suite *ServerSuite) TestServe_ShutdownTimeout() {
+	ctx, cancel := context.WithCancel(context.Background())
+	served := suite.serve(ctx, 50*time.Millisecond)
+	responses := suite.get("/slow")
+	<-suite.started
+
+	cancel()
+
+	suite.ErrorIs(<-served, context.DeadlineExceeded)
+	// the request was cut off
+	<-responses
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// a fake smtp server that accepts one message per connection and records it
+type fakeSMTPServer struct {
+	listener net.Listener
+	messages chan fakeSMTPMessage
+}
+
+type fakeSMTPMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTPServer{listener: listener, messages: make(chan fakeSMTPMessage, 1)}
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var message fakeSMTPMessage
+	reply("220 localhost fake smtp")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.to = append(message.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			message.data = data.String()
+			s.messages <- message
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+type SMTPMailerSuite struct {
+	suite.Suite
+	server *fakeSMTPServer
+	mailer *infrastructure.SMTPMailer
+}
+
+func (suite *SMTPMailerSuite) SetupTest() {
+	suite.server = newFakeSMTPServer(suite.T())
+	suite.mailer = &infrastructure.SMTPMailer{Host: "127.0.0.1", Port: suite.server.port(), From: "tasks@example.com"}
+}
+
+func (suite *SMTPMailerSuite) TestSend_Success() {
+	err := suite.mailer.Send(context.Background(), "jane@example.com", "Confirm your email address", "Hi jane,\n\nopen this link")
+	assert.NoError(suite.T(), err)
+
+	message := <-suite.server.messages
+	assert.Equal(suite.T(), "tasks@example.com", message.from)
+	assert.Equal(suite.T(), []string{"jane@example.com"}, message.to)
+	assert.Contains(suite.T(), message.data, "Subject: Confirm your email address\r\n")
+	assert.Contains(suite.T(), message.data, "To: jane@example.com\r\n")
+	assert.Contains(suite.T(), message.data, "Hi jane,\r\n\r\nopen this link")
+}
+
+func (suite *SMTPMailerSuite) TestSend_HeaderInjection() {
+	err := suite.mailer.Send(context.Background(), "jane@example.com\r\nBcc: evil@example.com", "subject", "body")
+	assert.EqualError(suite.T(), err, "invalid email header")
+}
+
+func (suite *SMTPMailerSuite) TestSend_ServerUnavailable() {
+	suite.server.listener.Close()
+	mailer := &infrastructure.SMTPMailer{Host: "127.0.0.1", Port: suite.server.port(), From: "tasks@example.com"}
+
+	err := mailer.Send(context.Background(), "jane@example.com", "subject", "body")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SMTPMailerSuite) TestSend_ServerNotResponding() {
+	// accepts connections but never sends the greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	mailer := &infrastructure.SMTPMailer{Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port, From: "tasks@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = mailer.Send(ctx, "jane@example.com", "subject", "body")
+
+	assert.Error(suite.T(), err)
+	assert.Less(suite.T(), time.Since(start), 5*time.Second)
+}
+
+func TestSMTPMailerSuite(t *testing.T) {
+	suite.Run(t, new(SMTPMailerSuite))
+}
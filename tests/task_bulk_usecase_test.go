@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskBulkTestSuite struct {
+	suite.Suite
+	service       *usecases.TaskService
+	mockRepo      *mocks.TaskRepoInterface
+	mockAuditRepo *mocks.AuditRepoInterface
+	// the task every test starts with
+	existing domain.Task
+	dueDate  time.Time
+}
+
+func (suite *TaskBulkTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.TaskRepoInterface)
+	suite.mockAuditRepo = new(mocks.AuditRepoInterface)
+	suite.mockAuditRepo.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(nil).Maybe()
+	suite.service = &usecases.TaskService{TaskRepo: suite.mockRepo, Audit: suite.mockAuditRepo}
+	suite.dueDate = time.Now().Add(24 * time.Hour).UTC()
+	suite.existing = domain.Task{ID: uuid.New(), Title: "Existing", Description: "Description", Status: "pending", DueDate: suite.dueDate}
+}
+
+// the writes passed to the repository
+func (suite *TaskBulkTestSuite) writes() []domain.TaskWrite {
+	for _, call := range suite.mockRepo.Calls {
+		if call.Method == "BulkWriteTasks" {
+			return call.Arguments.Get(1).([]domain.TaskWrite)
+		}
+	}
+	return nil
+}
+
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations() {
+	missing := uuid.New()
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkCreate, Task: &domain.Task{Title: "New", Description: "Description", Status: "pending", DueDate: suite.dueDate}},
+		{Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: "completed"},
+		{Op: domain.BulkDelete, ID: missing},
+	}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID, missing}).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{nil, nil}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal(2, response.Applied)
+	suite.Equal(1, response.Failed)
+	writes := suite.writes()
+	suite.Require().Len(writes, 2)
+	suite.Equal(domain.BulkCreate, writes[0].Op)
+	suite.NotEqual(uuid.Nil, writes[0].Task.ID)
+	suite.Equal(domain.BulkSetStatus, writes[1].Op)
+	suite.Equal("completed", writes[1].Task.Status)
+	suite.Equal("Existing", writes[1].Task.Title)
+
+	suite.Equal(domain.BulkTaskResult{Index: 0, Op: domain.BulkCreate, ID: writes[0].Task.ID, Status: domain.BulkApplied}, response.Results[0])
+	suite.Equal(domain.BulkTaskResult{Index: 1, Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: domain.BulkApplied}, response.Results[1])
+	suite.Equal(domain.BulkTaskResult{Index: 2, Op: domain.BulkDelete, ID: missing, Status: domain.BulkFailed, Error: "task not found"}, response.Results[2])
+	// the applied changes are audited like single ones
+	suite.mockAuditRepo.AssertNumberOfCalls(suite.T(), "AddEntry", 2)
+}
+
+// the checks of single changes apply to each operation
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_InvalidOperations() {
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkCreate, Task: &domain.Task{Title: "Late", Description: "Description", Status: "pending", DueDate: time.Now().AddDate(0, 0, -2)}},
+		{Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: "done"},
+		{Op: domain.BulkDelete, ID: suite.existing.ID},
+	}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID}).Return([]domain.Task{suite.existing}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal(0, response.Applied)
+	suite.Equal(3, response.Failed)
+	suite.Equal("due date is in the past", response.Results[0].Error)
+	suite.Equal([]domain.InvalidParam{{Name: "due_date", Reason: "must not be in the past", Rule: domain.RuleNotPast}}, response.Results[0].InvalidParams)
+	suite.Equal(domain.ErrInvalidTaskStatus.Message, response.Results[1].Error)
+	suite.Equal(domain.ErrTaskInBatchTwice.Message, response.Results[2].Error)
+	suite.mockRepo.AssertNotCalled(suite.T(), "BulkWriteTasks", mock.Anything, mock.Anything)
+}
+
+// a write that fails doesn't fail the others, its cause isn't shown
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_WriteError() {
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkDelete, ID: suite.existing.ID},
+		{Op: domain.BulkCreate, Task: &domain.Task{Title: "New", Description: "Description", Status: "pending", DueDate: suite.dueDate}},
+	}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID}).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{nil, errors.New("E11000 duplicate key")}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal(domain.BulkApplied, response.Results[0].Status)
+	suite.Equal(domain.BulkFailed, response.Results[1].Status)
+	suite.Equal("internal error", response.Results[1].Error)
+	deleted := suite.writes()[0].Task
+	suite.Equal("admin", deleted.DeletedBy)
+	suite.NotNil(deleted.DeletedAt)
+}
+
+// a task trashed after the batch read it is reported as not found, not as applied
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_TaskGoneBeforeWrite() {
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: "completed"},
+	}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID}).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{fmt.Errorf("task %s: %w", suite.existing.ID, domain.ErrTaskNotFound)}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal(0, response.Applied)
+	suite.Equal(1, response.Failed)
+	suite.Equal(domain.BulkTaskResult{Index: 0, Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: domain.BulkFailed, Error: "task not found"}, response.Results[0])
+	suite.mockAuditRepo.AssertNotCalled(suite.T(), "AddEntry", mock.Anything, mock.Anything)
+}
+
+// errors that aren't domain errors are hidden from the client and logged
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_WriteErrorLogged() {
+	var logs bytes.Buffer
+	suite.service.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkSetStatus, ID: suite.existing.ID, Status: "completed"},
+	}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID}).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{errors.New("document failed validation")}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal("internal error", response.Results[0].Error)
+	suite.Contains(logs.String(), "bulk operation failed")
+	suite.Contains(logs.String(), "document failed validation")
+}
+
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_RepositoryError() {
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{{Op: domain.BulkDelete, ID: suite.existing.ID}}}
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Nil(response)
+	suite.EqualError(err, "connection refused")
+}
+
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_Atomic() {
+	request := domain.BulkTaskRequest{Atomic: true, Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkUpdate, ID: suite.existing.ID, Task: &domain.Task{Title: "Updated", Description: "Description", Status: "in progress", DueDate: suite.dueDate}},
+	}}
+	suite.mockRepo.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	})
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, []uuid.UUID{suite.existing.ID}).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{nil}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.True(response.Atomic)
+	suite.Equal(1, response.Applied)
+	suite.Equal(suite.existing.ID, suite.writes()[0].Task.ID)
+	suite.Equal("Updated", suite.writes()[0].Task.Title)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// one failed operation of an atomic batch keeps the others from being applied
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_AtomicFailure() {
+	request := domain.BulkTaskRequest{Atomic: true, Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkCreate, Task: &domain.Task{Title: "New", Description: "Description", Status: "pending", DueDate: suite.dueDate}},
+		{Op: domain.BulkSetStatus, ID: uuid.New(), Status: "completed"},
+	}}
+	suite.mockRepo.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	})
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, mock.Anything).Return([]domain.Task{}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Equal(0, response.Applied)
+	suite.Equal(1, response.Failed)
+	suite.Equal(domain.BulkTaskResult{Index: 0, Op: domain.BulkCreate, Status: domain.BulkSkipped}, response.Results[0])
+	suite.Equal(domain.BulkFailed, response.Results[1].Status)
+	suite.mockRepo.AssertNotCalled(suite.T(), "BulkWriteTasks", mock.Anything, mock.Anything)
+	suite.mockAuditRepo.AssertNotCalled(suite.T(), "AddEntry", mock.Anything, mock.Anything)
+}
+
+// a failed write rolls back the writes of the batch
+func (suite *TaskBulkTestSuite) TestApplyBulkOperations_AtomicWriteError() {
+	request := domain.BulkTaskRequest{Atomic: true, Operations: []domain.BulkTaskOperation{
+		{Op: domain.BulkDelete, ID: suite.existing.ID},
+		{Op: domain.BulkCreate, Task: &domain.Task{Title: "New", Description: "Description", Status: "pending", DueDate: suite.dueDate}},
+	}}
+	var transactionErr error
+	suite.mockRepo.On("WithTransaction", mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		transactionErr = fn(ctx)
+		return transactionErr
+	})
+	suite.mockRepo.On("GetTasksByIDs", mock.Anything, mock.Anything).Return([]domain.Task{suite.existing}, nil)
+	suite.mockRepo.On("BulkWriteTasks", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).Return([]error{nil, errors.New("E11000 duplicate key")}, nil)
+
+	response, err := suite.service.ApplyBulkOperations(context.Background(), "admin", request)
+
+	suite.Require().NoError(err)
+	suite.Error(transactionErr)
+	suite.Equal(domain.BulkSkipped, response.Results[0].Status)
+	suite.Equal(domain.BulkFailed, response.Results[1].Status)
+	suite.Equal(uuid.Nil, response.Results[1].ID)
+	suite.mockAuditRepo.AssertNotCalled(suite.T(), "AddEntry", mock.Anything, mock.Anything)
+}
+
+func TestTaskBulkTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskBulkTestSuite))
+}
@@ -1,12 +1,14 @@
 package tests
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,12 +41,13 @@ func (suite *TaskControllerSuite) TestGetTasks_Success() {
 		{ID: uuid.New(), Title: "Task 2", Description: "Description 2", Status: "done", DueDate: time.Now().UTC().Truncate(0)},
 	}
 
-	suite.mockService.On("GetTasks").Return(tasks, nil)
+	suite.mockService.On("GetTasks", mock.Anything).Return(tasks, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/tasks", nil)
 
-	suite.controller.GetTasks(c)
+	handle(c, suite.controller.GetTasks)
 
 	suite.Equal(http.StatusOK, w.Code)
 	var gotTasks []domain.Task
@@ -58,13 +61,14 @@ func (suite *TaskControllerSuite) TestGetTaskById_Success() {
 	id := uuid.New()
 	task := &domain.Task{ID: id, Title: "Task 1", Description: "Description 1", Status: "pending", DueDate: time.Now().UTC().Truncate(0)}
 
-	suite.mockService.On("GetTaskById", id).Return(task, nil)
+	suite.mockService.On("GetTaskById", mock.Anything, id).Return(task, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/"+id.String(), nil)
 
-	suite.controller.GetTaskById(c)
+	handle(c, suite.controller.GetTaskById)
 
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 	var gotTask domain.Task
@@ -74,29 +78,100 @@ func (suite *TaskControllerSuite) TestGetTaskById_Success() {
 	suite.mockService.AssertExpectations(suite.T())
 }
 
+func (suite *TaskControllerSuite) TestGetTaskHistory_Success() {
+	id := uuid.New()
+	history := []domain.TaskHistoryEntry{{
+		ID:      uuid.New(),
+		TaskID:  id,
+		Time:    time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC),
+		Actor:   "admin",
+		Action:  domain.TaskUpdated,
+		Changes: []domain.TaskFieldChange{{Field: "due_date", OldValue: "2030-01-10T00:00:00Z", NewValue: "2030-01-17T00:00:00Z"}},
+	}}
+	suite.mockService.On("GetTaskHistory", mock.Anything, id).Return(history, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/"+id.String()+"/history", nil)
+
+	handle(c, suite.controller.GetTaskHistory)
+
+	suite.Equal(http.StatusOK, w.Code)
+	var gotHistory []domain.TaskHistoryEntry
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &gotHistory))
+	suite.Equal(history, gotHistory)
+}
+
+func (suite *TaskControllerSuite) TestGetTaskHistory_InvalidUUID() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "not-a-uuid"}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/not-a-uuid/history", nil)
+
+	handle(c, suite.controller.GetTaskHistory)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "GetTaskHistory", mock.Anything, mock.Anything)
+}
+
 func (suite *TaskControllerSuite) TestGetTaskById_NotFound() {
 	id := uuid.New()
 
-	suite.mockService.On("GetTaskById", id).Return(nil, errors.New("Task Not Found"))
+	suite.mockService.On("GetTaskById", mock.Anything, id).Return(nil, domain.ErrTaskNotFound)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/"+id.String(), nil)
 
-	suite.controller.GetTaskById(c)
+	handle(c, suite.controller.GetTaskById)
 
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
 }
 
+func (suite *TaskControllerSuite) TestGetTaskById_WrappedNotFound() {
+	id := uuid.New()
+
+	suite.mockService.On("GetTaskById", mock.Anything, id).Return(nil, fmt.Errorf("task %s: %w", id, domain.ErrTaskNotFound))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/"+id.String(), nil)
+
+	handle(c, suite.controller.GetTaskById)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	// the wrapping context stays out of the response
+	assert.Equal(suite.T(), "task not found", decodeProblem(suite.T(), w).Detail)
+}
+
+func (suite *TaskControllerSuite) TestGetTaskById_InternalError() {
+	id := uuid.New()
+
+	suite.mockService.On("GetTaskById", mock.Anything, id).Return(nil, errors.New("connection refused"))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("GET", "/tasks/"+id.String(), nil)
+
+	handle(c, suite.controller.GetTaskById)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
+
 func (suite *TaskControllerSuite) TestGetTaskById_InvalidUUID() {
     invalidUUID := "invalid-uuid"
 
     w := httptest.NewRecorder()
     c, _ := gin.CreateTestContext(w)
     c.Params = gin.Params{gin.Param{Key: "id", Value: invalidUUID}}
+    c.Request, _ = http.NewRequest("GET", "/tasks/"+invalidUUID, nil)
 
-    suite.controller.GetTaskById(c)
+    handle(c, suite.controller.GetTaskById)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
     assert.Contains(suite.T(), w.Body.String(), "invalid task ID")
@@ -106,17 +181,53 @@ func (suite *TaskControllerSuite) TestGetTaskById_InvalidUUID() {
 func (suite *TaskControllerSuite) TestAddTask_Success() {
 	task := &domain.Task{ID: uuid.New(), Title: "New Task", Description: "New Description", Status: "pending", DueDate: time.Now().UTC()}
 
-	suite.mockService.On("AddTask", mock.AnythingOfType("domain.Task")).Return(task, nil)
+	suite.mockService.On("AddTask", mock.Anything, mock.Anything, mock.AnythingOfType("domain.Task")).Return(task, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 
 	c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title": "New Task", "description": "New Description", "status": "pending", "due_date": "`+task.DueDate.Format(time.RFC3339)+`"}`))
 	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Host = "localhost:8080"
 
-	suite.controller.AddTask(c)
+	handle(c, suite.controller.AddTask)
 
 	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	// the location points at the id the service gave the task
+	assert.Equal(suite.T(), "http://localhost:8080/tasks/"+task.ID.String(), w.Header().Get("Location"))
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+// a task that wasn't created has no location
+func (suite *TaskControllerSuite) TestAddTask_ServiceError() {
+	suite.mockService.On("AddTask", mock.Anything, mock.Anything, mock.AnythingOfType("domain.Task")).Return(nil, domain.ErrInvalidTaskStatus)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title": "New Task", "description": "New Description", "status": "pending", "due_date": "2030-01-10T00:00:00Z"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.AddTask)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Empty(suite.T(), w.Header().Get("Location"))
+}
+
+// the service works with the request's context, so a client that goes away cancels it
+func (suite *TaskControllerSuite) TestGetTasks_RequestContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	suite.mockService.On("GetTasks", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == context.Canceled
+	})).Return(nil, context.Canceled)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequestWithContext(ctx, "GET", "/tasks", nil)
+
+	handle(c, suite.controller.GetTasks)
+
+	suite.Equal(http.StatusInternalServerError, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
 }
 
@@ -129,11 +240,11 @@ func (suite *TaskControllerSuite) TestAddTask_InvalidJSON() {
     c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(invalidJSON))
     c.Request.Header.Set("Content-Type", "application/json")
 
-    suite.controller.AddTask(c)
+    handle(c, suite.controller.AddTask)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 	fmt.Println(w.Body.String())
-    assert.Contains(suite.T(), w.Body.String(), "Invalid JSON")  
+    assert.Contains(suite.T(), w.Body.String(), "invalid JSON")  
 }
 
 func (suite *TaskControllerSuite) TestAddTask_ValidationErrors() {
@@ -146,11 +257,10 @@ func (suite *TaskControllerSuite) TestAddTask_ValidationErrors() {
     c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBuffer(taskJSON))
     c.Request.Header.Set("Content-Type", "application/json")
 
-    suite.controller.AddTask(c)
+    handle(c, suite.controller.AddTask)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-    assert.Contains(suite.T(), w.Body.String(), "Title is required.")
-    assert.Contains(suite.T(), w.Body.String(), "Description is required.")
+    assert.ElementsMatch(suite.T(), []domain.InvalidParam{{Name: "title", Reason: "title is a required field"}, {Name: "description", Reason: "description is a required field"}}, decodeProblem(suite.T(), w).InvalidParams)
 }
 
 
@@ -158,7 +268,7 @@ func (suite *TaskControllerSuite) TestUpdateTaskByID_Success() {
 	id := uuid.New()
 	task := domain.Task{ID: id, Title: "Updated Task", Description: "Updated Description", Status: "completed", DueDate: time.Now().UTC()}
 
-	suite.mockService.On("UpdateTaskByID", id, mock.AnythingOfType("domain.Task")).Return(nil)
+	suite.mockService.On("UpdateTaskByID", mock.Anything, mock.Anything, id, mock.AnythingOfType("domain.Task")).Return(nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -168,7 +278,7 @@ func (suite *TaskControllerSuite) TestUpdateTaskByID_Success() {
 	c.Request, _ = http.NewRequest("PUT", "/tasks/"+id.String(), bytes.NewBuffer(taskJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.UpdateTaskByID(c)
+	handle(c, suite.controller.UpdateTaskByID)
 	c.Writer.WriteHeaderNow()
 	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
@@ -183,7 +293,7 @@ func (suite *TaskControllerSuite) TestUpdateTaskByID_InvalidUUID() {
 
     c.Request, _ = http.NewRequest("PUT", "/tasks/"+invalidUUID, nil)
 
-    suite.controller.UpdateTaskByID(c)
+    handle(c, suite.controller.UpdateTaskByID)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
     assert.Contains(suite.T(), w.Body.String(), "invalid task ID")
@@ -200,10 +310,10 @@ func (suite *TaskControllerSuite) TestUpdateTaskByID_InvalidJSON() {
     c.Request, _ = http.NewRequest("PUT", "/tasks/"+id.String(), bytes.NewBufferString(invalidJSON))
     c.Request.Header.Set("Content-Type", "application/json")
 
-    suite.controller.UpdateTaskByID(c)
+    handle(c, suite.controller.UpdateTaskByID)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-    assert.Contains(suite.T(), w.Body.String(), "Invalid JSON") 
+    assert.Contains(suite.T(), w.Body.String(), "invalid JSON") 
 }
 
 func (suite *TaskControllerSuite) TestUpdateTaskByID_ValidationErrors() {
@@ -219,44 +329,237 @@ func (suite *TaskControllerSuite) TestUpdateTaskByID_ValidationErrors() {
     c.Request, _ = http.NewRequest("PUT", "/tasks/"+id.String(), bytes.NewBuffer(taskJSON))
     c.Request.Header.Set("Content-Type", "application/json")
 
-    suite.controller.UpdateTaskByID(c)
+    handle(c, suite.controller.UpdateTaskByID)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-    assert.Contains(suite.T(), w.Body.String(), "Title is required.")
-    assert.Contains(suite.T(), w.Body.String(), "Description is required.")
+    assert.ElementsMatch(suite.T(), []domain.InvalidParam{{Name: "title", Reason: "title is a required field"}, {Name: "description", Reason: "description is a required field"}}, decodeProblem(suite.T(), w).InvalidParams)
 }
 
 
 func (suite *TaskControllerSuite) TestDeleteTask_Success() {
 	id := uuid.New()
 
-	suite.mockService.On("DeleteTask", id).Return(nil)
+	suite.mockService.On("DeleteTask", mock.Anything, mock.Anything, id).Return(nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("DELETE", "/tasks/"+id.String(), nil)
 
-	suite.controller.DeleteTask(c)
+	handle(c, suite.controller.DeleteTask)
 	c.Writer.WriteHeaderNow()
 
 	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
 }
 
+func (suite *TaskControllerSuite) TestGetDeletedTasks_Success() {
+	deletedAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	deletedTasks := []domain.Task{{ID: uuid.New(), Title: "Task", Status: "pending", DeletedAt: &deletedAt, DeletedBy: "admin"}}
+	suite.mockService.On("GetDeletedTasks", mock.Anything).Return(deletedTasks, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/tasks/trash", nil)
+
+	handle(c, suite.controller.GetDeletedTasks)
+
+	suite.Equal(http.StatusOK, w.Code)
+	var gotTasks []domain.Task
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &gotTasks))
+	suite.Equal(deletedTasks, gotTasks)
+}
+
+func (suite *TaskControllerSuite) TestRestoreTask_Success() {
+	id := uuid.New()
+	suite.mockService.On("RestoreTask", mock.Anything, "admin", id).Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", "admin")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("POST", "/tasks/"+id.String()+"/restore", nil)
+
+	handle(c, suite.controller.RestoreTask)
+	c.Writer.WriteHeaderNow()
+
+	suite.Equal(http.StatusNoContent, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+// a task that isn't in the trash can't be restored
+func (suite *TaskControllerSuite) TestRestoreTask_NotFound() {
+	id := uuid.New()
+	suite.mockService.On("RestoreTask", mock.Anything, mock.Anything, id).Return(domain.ErrTaskNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("POST", "/tasks/"+id.String()+"/restore", nil)
+
+	handle(c, suite.controller.RestoreTask)
+
+	suite.Equal(http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskControllerSuite) TestApplyBulkOperations_Success() {
+	id := uuid.New()
+	response := &domain.BulkTaskResponse{Applied: 1, Results: []domain.BulkTaskResult{{Index: 0, Op: domain.BulkDelete, ID: id, Status: domain.BulkApplied}}}
+	request := domain.BulkTaskRequest{Operations: []domain.BulkTaskOperation{{Op: domain.BulkDelete, ID: id}}}
+	suite.mockService.On("ApplyBulkOperations", mock.Anything, "admin", request).Return(response, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("username", "admin")
+	c.Request, _ = http.NewRequest("POST", "/tasks/bulk", strings.NewReader(`{"operations": [{"op": "delete", "id": "`+id.String()+`"}]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.ApplyBulkOperations)
+
+	suite.Equal(http.StatusOK, w.Code)
+	var gotResponse domain.BulkTaskResponse
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &gotResponse))
+	suite.Equal(*response, gotResponse)
+}
+
+// invalid operations are named by their position in the batch
+func (suite *TaskControllerSuite) TestApplyBulkOperations_ValidationErrors() {
+	body := `{"operations": [
+		{"op": "delete", "id": "` + uuid.NewString() + `"},
+		{"op": "create", "task": {"description": "Description", "status": "pending", "due_date": "2030-01-01T00:00:00Z"}},
+		{"op": "set_status"},
+		{"op": "archive"}
+	]}`
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/tasks/bulk", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.ApplyBulkOperations)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	var names []string
+	for _, param := range decodeProblem(suite.T(), w).InvalidParams {
+		names = append(names, param.Name)
+	}
+	suite.ElementsMatch([]string{"operations[1].task.title", "operations[2].id", "operations[2].status", "operations[3].op", "operations[3].id"}, names)
+	suite.mockService.AssertNotCalled(suite.T(), "ApplyBulkOperations", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerSuite) TestApplyBulkOperations_Empty() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/tasks/bulk", strings.NewReader(`{"operations": []}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.ApplyBulkOperations)
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Equal([]domain.InvalidParam{{Name: "operations", Reason: "operations must contain at least 1 item"}}, decodeProblem(suite.T(), w).InvalidParams)
+}
+
 func (suite *TaskControllerSuite) TestDeleteTask_InvalidUUID() {
     invalidUUID := "invalid-uuid"
 
     w := httptest.NewRecorder()
     c, _ := gin.CreateTestContext(w)
     c.Params = gin.Params{gin.Param{Key: "id", Value: invalidUUID}}
+    c.Request, _ = http.NewRequest("DELETE", "/tasks/"+invalidUUID, nil)
 
-    suite.controller.DeleteTask(c)
+    handle(c, suite.controller.DeleteTask)
 
     assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
     assert.Contains(suite.T(), w.Body.String(), "invalid task ID")
 }
 
 
+func (suite *TaskControllerSuite) TestUpdateTaskByID_InvalidStatus() {
+	id := uuid.New()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("PUT", "/tasks/"+id.String(), bytes.NewBufferString(`{"title": "Task", "description": "Description", "status": "done", "due_date": "2024-08-01T00:00:00Z"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.UpdateTaskByID)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), []domain.InvalidParam{{Name: "status", Reason: "status must be pending, in progress or completed"}}, decodeProblem(suite.T(), w).InvalidParams)
+	suite.mockService.AssertNotCalled(suite.T(), "UpdateTaskByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskControllerSuite) TestAddTask_TitleTooLong() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title": "`+strings.Repeat("a", 201)+`", "description": "Description", "status": "pending", "due_date": "2030-08-01T00:00:00Z"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.AddTask)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), []domain.InvalidParam{{Name: "title", Reason: "title must be a maximum of 200 characters in length"}}, decodeProblem(suite.T(), w).InvalidParams)
+}
+
+// reasons are translated for the Accept-Language header, English is the fallback
+func (suite *TaskControllerSuite) TestAddTask_ValidationErrorsTranslated() {
+	languages := map[string]string{
+		"es-MX,es;q=0.9": "title es un campo requerido",
+		"fr;q=0.8, en;q=0.5": "title est un champ obligatoire",
+		"de": "title is a required field",
+	}
+
+	for acceptLanguage, reason := range languages {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"description": "Description", "status": "pending", "due_date": "2030-08-01T00:00:00Z"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+
+		handle(c, suite.controller.AddTask)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		assert.Equal(suite.T(), []domain.InvalidParam{{Name: "title", Reason: reason}}, decodeProblem(suite.T(), w).InvalidParams, acceptLanguage)
+	}
+}
+
+// due date errors of the service are translated too
+func (suite *TaskControllerSuite) TestAddTask_DueDateInPast() {
+	suite.mockService.On("AddTask", mock.Anything, mock.Anything, mock.AnythingOfType("domain.Task")).Return(nil, &domain.ValidationError{
+		Detail: "due date is in the past",
+		Params: []domain.InvalidParam{{Name: "due_date", Reason: "must not be in the past", Rule: domain.RuleNotPast}},
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title": "Task", "description": "Description", "status": "pending", "due_date": "2020-08-01T00:00:00Z"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Accept-Language", "fr")
+
+	handle(c, suite.controller.AddTask)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	problem := decodeProblem(suite.T(), w)
+	assert.Equal(suite.T(), "due date is in the past", problem.Detail)
+	assert.Equal(suite.T(), []domain.InvalidParam{{Name: "due_date", Reason: "due_date ne doit pas être dans le passé"}}, problem.InvalidParams)
+}
+
+func (suite *TaskControllerSuite) TestDeleteTask_NotFound() {
+	id := uuid.New()
+	suite.mockService.On("DeleteTask", mock.Anything, mock.Anything, id).Return(domain.ErrTaskNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: id.String()}}
+	c.Request, _ = http.NewRequest("DELETE", "/tasks/"+id.String(), nil)
+
+	handle(c, suite.controller.DeleteTask)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
 func TestTaskControllerSuite(t *testing.T) {
 	suite.Run(t, new(TaskControllerSuite))
 }
@@ -1,7 +1,7 @@
 package tests
 
 import (
-	"errors"
+	"context"
 	"testing"
 	"time"
 
@@ -33,9 +33,9 @@ func (suite *TaskServiceTestSuite) TestGetTasks() {
 		{ID: uuid.New(), Title: "Test Task 2", Status: "completed",  Description: "Test Description 2", DueDate: time.Now().UTC().Add(24 * time.Hour)},
 	}
 
-	suite.mockRepo.On("GetTasks").Return(mockTasks, nil)
+	suite.mockRepo.On("GetTasks", mock.Anything).Return(mockTasks, nil)
 
-	tasks, err := suite.service.GetTasks()
+	tasks, err := suite.service.GetTasks(context.Background())
 
 	suite.NoError(err)
 	suite.Equal(mockTasks, tasks)
@@ -47,9 +47,9 @@ func (suite *TaskServiceTestSuite) TestGetTaskById() {
 	taskID := uuid.New()
 	mockTask := &domain.Task{ID: taskID, Title: "Test Task", Status: "pending", Description: "Test Description", DueDate: time.Now().UTC()}
 
-	suite.mockRepo.On("GetTaskById", taskID).Return(mockTask, nil)
+	suite.mockRepo.On("GetTaskById", mock.Anything, taskID).Return(mockTask, nil)
 
-	task, err := suite.service.GetTaskById(taskID)
+	task, err := suite.service.GetTaskById(context.Background(), taskID)
 
 	suite.NoError(err)
 	suite.Equal(mockTask, task)
@@ -60,12 +60,12 @@ func (suite *TaskServiceTestSuite) TestGetTaskById() {
 func (suite *TaskServiceTestSuite) TestGetTaskById_InvalidID() {
 	invalidID := uuid.New()
 
-	suite.mockRepo.On("GetTaskById", invalidID).Return(nil, errors.New("task not found"))
+	suite.mockRepo.On("GetTaskById", mock.Anything, invalidID).Return(nil, domain.ErrTaskNotFound)
 
-	task, err := suite.service.GetTaskById(invalidID)
+	task, err := suite.service.GetTaskById(context.Background(), invalidID)
 
 	suite.Nil(task)
-	suite.EqualError(err, "task not found")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
 	suite.mockRepo.AssertExpectations(suite.T())
 }
 
@@ -74,12 +74,42 @@ func (suite *TaskServiceTestSuite) TestUpdateTaskByID_ValidStatus() {
 	taskID := uuid.New()
 	updatedTask := domain.Task{ID: taskID, Title: "Updated Task", Status: "in progress", Description: "Updated Description", DueDate: time.Now().UTC()}
 
-	suite.mockRepo.On("UpdateTaskByID", taskID, updatedTask).Return(nil)
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, taskID, updatedTask).Return(&domain.Task{ID: taskID, Status: "pending", DueDate: updatedTask.DueDate}, nil)
 
-	err := suite.service.UpdateTaskByID(taskID, updatedTask)
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask)
 
 	suite.NoError(err)
 	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockRepo.AssertNotCalled(suite.T(), "GetTaskById", mock.Anything, taskID)
+}
+
+// overdue tasks can be updated if the due date isn't changed
+func (suite *TaskServiceTestSuite) TestUpdateTaskByID_OverdueTask() {
+	taskID := uuid.New()
+	dueDate := time.Now().UTC().AddDate(0, 0, -7)
+	updatedTask := domain.Task{ID: taskID, Title: "Updated Task", Status: "completed", Description: "Updated Description", DueDate: dueDate}
+
+	suite.mockRepo.On("GetTaskById", mock.Anything, taskID).Return(&domain.Task{ID: taskID, Status: "pending", DueDate: dueDate}, nil)
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, taskID, updatedTask).Return(&domain.Task{ID: taskID, Status: "pending", DueDate: dueDate}, nil)
+
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask)
+
+	suite.NoError(err)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TaskServiceTestSuite) TestUpdateTaskByID_DueDateMovedToPast() {
+	taskID := uuid.New()
+	updatedTask := domain.Task{ID: taskID, Title: "Updated Task", Status: "pending", Description: "Updated Description", DueDate: time.Now().UTC().AddDate(0, 0, -7)}
+
+	suite.mockRepo.On("GetTaskById", mock.Anything, taskID).Return(&domain.Task{ID: taskID, Status: "pending", DueDate: time.Now().UTC()}, nil)
+
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask)
+
+	var validationErr *domain.ValidationError
+	suite.ErrorAs(err, &validationErr)
+	suite.Equal(domain.RuleNotPast, validationErr.Params[0].Rule)
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateTaskByID", mock.Anything, taskID, updatedTask)
 }
 
 // TestUpdateTaskByID_InvalidStatus tests the UpdateTaskByID method with an invalid status
@@ -87,10 +117,10 @@ func (suite *TaskServiceTestSuite) TestUpdateTaskByID_InvalidStatus() {
 	taskID := uuid.New()
 	updatedTask := domain.Task{ID: taskID, Title: "Updated Task", Status: "unknown",  Description: "Updated Description", DueDate: time.Now().UTC()}
 
-	err := suite.service.UpdateTaskByID(taskID, updatedTask)
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask)
 
-	suite.EqualError(err, "status error")
-	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateTaskByID", taskID, updatedTask)
+	suite.ErrorIs(err, domain.ErrInvalidTaskStatus)
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateTaskByID", mock.Anything, taskID, updatedTask)
 }
 
 // TestUpdateTaskByID_InvalidID tests the UpdateTaskByID method with an invalid ID
@@ -98,36 +128,163 @@ func (suite *TaskServiceTestSuite) TestUpdateTaskByID_InvalidID() {
 	invalidID := uuid.New()
 	updatedTask := domain.Task{ID: invalidID, Title: "Updated Task", Status: "in progress", Description: "Updated Description", DueDate: time.Now().UTC()}
 
-	suite.mockRepo.On("UpdateTaskByID", invalidID, updatedTask).Return(errors.New("task not found"))
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, invalidID, updatedTask).Return(nil, domain.ErrTaskNotFound)
 
-	err := suite.service.UpdateTaskByID(invalidID, updatedTask)
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", invalidID, updatedTask)
 
-	suite.EqualError(err, "task not found")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
 	suite.mockRepo.AssertExpectations(suite.T())
 }
 
 
+// the fields that changed are kept, with who changed them, compared to the task as
+// the update found it
+func (suite *TaskServiceTestSuite) TestUpdateTaskByID_RecordsHistory() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	taskID := uuid.New()
+	oldDueDate := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	existing := &domain.Task{ID: taskID, Title: "Report", Description: "Write it", Status: "pending", DueDate: oldDueDate}
+	updatedTask := domain.Task{Title: "Report", Description: "Write it", Status: "in progress", DueDate: oldDueDate.AddDate(0, 0, 7)}
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, taskID, updatedTask).Return(existing, nil)
+	mockHistory.On("AddEntry", mock.Anything, mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+		return entry.TaskID == taskID && entry.Actor == "admin" && entry.Action == domain.TaskUpdated && !entry.Time.IsZero()
+	})).Return(nil)
+
+	err := suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask)
+
+	suite.NoError(err)
+	mockHistory.AssertExpectations(suite.T())
+	entry := mockHistory.Calls[0].Arguments.Get(1).(*domain.TaskHistoryEntry)
+	suite.Equal([]domain.TaskFieldChange{
+		{Field: "due_date", OldValue: "2030-01-10T00:00:00Z", NewValue: "2030-01-17T00:00:00Z"},
+		{Field: "status", OldValue: "pending", NewValue: "in progress"},
+	}, entry.Changes)
+}
+
+// an update that changes nothing leaves no history
+func (suite *TaskServiceTestSuite) TestUpdateTaskByID_NoChanges() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	taskID := uuid.New()
+	existing := &domain.Task{ID: taskID, Title: "Report", Description: "Write it", Status: "pending", DueDate: time.Now().UTC()}
+	updatedTask := domain.Task{Title: "Report", Description: "Write it", Status: "pending", DueDate: existing.DueDate}
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, taskID, updatedTask).Return(existing, nil)
+
+	suite.NoError(suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask))
+
+	mockHistory.AssertNotCalled(suite.T(), "AddEntry", mock.Anything, mock.Anything)
+}
+
+// trash fields sent with an update are ignored, they don't show up as changes
+func (suite *TaskServiceTestSuite) TestUpdateTaskByID_IgnoresDeletedAt() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	mockAudit := new(mocks.AuditRepoInterface)
+	suite.service.History = mockHistory
+	suite.service.Audit = mockAudit
+	taskID := uuid.New()
+	deletedAt := time.Now().UTC()
+	existing := &domain.Task{ID: taskID, Title: "Report", Description: "Write it", Status: "pending", DueDate: time.Now().UTC()}
+	updatedTask := domain.Task{Title: "Report", Description: "Write it", Status: "pending", DueDate: existing.DueDate, DeletedAt: &deletedAt, DeletedBy: "admin"}
+	suite.mockRepo.On("UpdateTaskByID", mock.Anything, taskID, mock.MatchedBy(func(task domain.Task) bool {
+		return task.DeletedAt == nil && task.DeletedBy == ""
+	})).Return(existing, nil)
+	mockAudit.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.AuditEntry")).Return(nil)
+
+	suite.NoError(suite.service.UpdateTaskByID(context.Background(), "admin", taskID, updatedTask))
+
+	mockHistory.AssertNotCalled(suite.T(), "AddEntry", mock.Anything, mock.Anything)
+	entry := mockAudit.Calls[0].Arguments.Get(1).(*domain.AuditEntry)
+	suite.Nil(entry.After.DeletedAt)
+	suite.Empty(entry.After.DeletedBy)
+}
+
+func (suite *TaskServiceTestSuite) TestAddTask_RecordsHistory() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	task := domain.Task{ID: uuid.New(), Title: "Report", Description: "Write it", Status: "pending", DueDate: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)}
+	suite.mockRepo.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).Return(&task, nil)
+	mockHistory.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.TaskHistoryEntry")).Return(nil)
+
+	_, err := suite.service.AddTask(context.Background(), "admin", task)
+
+	suite.NoError(err)
+	entry := mockHistory.Calls[0].Arguments.Get(1).(*domain.TaskHistoryEntry)
+	suite.Equal(task.ID, entry.TaskID)
+	suite.Equal(domain.TaskCreated, entry.Action)
+	suite.Equal([]domain.TaskFieldChange{
+		{Field: "title", NewValue: "Report"},
+		{Field: "description", NewValue: "Write it"},
+		{Field: "due_date", NewValue: "2030-01-10T00:00:00Z"},
+		{Field: "status", NewValue: "pending"},
+	}, entry.Changes)
+}
+
+func (suite *TaskServiceTestSuite) TestGetTaskHistory() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	taskID := uuid.New()
+	history := []domain.TaskHistoryEntry{{ID: uuid.New(), TaskID: taskID, Actor: "admin", Action: domain.TaskUpdated}}
+	suite.mockRepo.On("GetTaskById", mock.Anything, taskID).Return(&domain.Task{ID: taskID}, nil)
+	mockHistory.On("GetHistory", mock.Anything, taskID).Return(history, nil)
+
+	found, err := suite.service.GetTaskHistory(context.Background(), taskID)
+
+	suite.NoError(err)
+	suite.Equal(history, found)
+}
+
+func (suite *TaskServiceTestSuite) TestGetTaskHistory_TaskNotFound() {
+	taskID := uuid.New()
+	suite.mockRepo.On("GetTaskById", mock.Anything, taskID).Return(nil, domain.ErrTaskNotFound)
+
+	found, err := suite.service.GetTaskHistory(context.Background(), taskID)
+
+	suite.Nil(found)
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
 // TestDeleteTask tests the DeleteTask method
 func (suite *TaskServiceTestSuite) TestDeleteTask() {
 	taskID := uuid.New()
 
-	suite.mockRepo.On("DeleteTask", taskID).Return(nil)
+	suite.mockRepo.On("DeleteTask", mock.Anything, taskID, "admin", mock.AnythingOfType("time.Time")).Return(&domain.Task{ID: taskID, Title: "Test Task"}, nil)
 
-	err := suite.service.DeleteTask(taskID)
+	err := suite.service.DeleteTask(context.Background(), "admin", taskID)
 
 	suite.NoError(err)
 	suite.mockRepo.AssertExpectations(suite.T())
 }
 
+// deleting a task moves it to the trash and keeps who deleted it in its history
+func (suite *TaskServiceTestSuite) TestDeleteTask_RecordsHistory() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	taskID := uuid.New()
+	suite.mockRepo.On("DeleteTask", mock.Anything, taskID, "admin", mock.AnythingOfType("time.Time")).Return(&domain.Task{ID: taskID, Title: "Test Task"}, nil)
+	mockHistory.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.TaskHistoryEntry")).Return(nil)
+
+	suite.Require().NoError(suite.service.DeleteTask(context.Background(), "admin", taskID))
+
+	deletedAt := suite.mockRepo.Calls[0].Arguments.Get(3).(time.Time)
+	entry := mockHistory.Calls[0].Arguments.Get(1).(*domain.TaskHistoryEntry)
+	suite.Equal(domain.TaskDeleted, entry.Action)
+	suite.Equal("admin", entry.Actor)
+	suite.Equal([]domain.TaskFieldChange{
+		{Field: "deleted_at", NewValue: deletedAt.Format(time.RFC3339)},
+		{Field: "deleted_by", NewValue: "admin"},
+	}, entry.Changes)
+}
+
 // TestDeleteTask_InvalidID tests the DeleteTask method with an invalid ID
 func (suite *TaskServiceTestSuite) TestDeleteTask_InvalidID() {
 	invalidID := uuid.New()
 
-	suite.mockRepo.On("DeleteTask", invalidID).Return(errors.New("task not found"))
+	suite.mockRepo.On("DeleteTask", mock.Anything, invalidID, "admin", mock.AnythingOfType("time.Time")).Return(nil, domain.ErrTaskNotFound)
 
-	err := suite.service.DeleteTask(invalidID)
+	err := suite.service.DeleteTask(context.Background(), "admin", invalidID)
 
-	suite.EqualError(err, "task not found")
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
 	suite.mockRepo.AssertExpectations(suite.T())
 }
 
@@ -136,9 +293,9 @@ func (suite *TaskServiceTestSuite) TestDeleteTask_InvalidID() {
 func (suite *TaskServiceTestSuite) TestAddTask_ValidStatus() {
 	task := domain.Task{Title: "New Task", Status: "pending", Description: "Updated Description", DueDate: time.Now().UTC()}
 
-	suite.mockRepo.On("AddTask", mock.AnythingOfType("domain.Task")).Return(&task, nil)
+	suite.mockRepo.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).Return(&task, nil)
 
-	newTask, err := suite.service.AddTask(task)
+	newTask, err := suite.service.AddTask(context.Background(), "admin", task)
 
 	suite.NoError(err)
 	suite.Equal(task.Title, newTask.Title)
@@ -152,11 +309,98 @@ func (suite *TaskServiceTestSuite) TestAddTask_ValidStatus() {
 func (suite *TaskServiceTestSuite) TestAddTask_InvalidStatus() {
 	task := domain.Task{Title: "New Task", Status: "unknown"}
 
-	newTask, err := suite.service.AddTask(task)
+	newTask, err := suite.service.AddTask(context.Background(), "admin", task)
 
 	suite.Nil(newTask)
-	suite.EqualError(err, "status error")
-	suite.mockRepo.AssertNotCalled(suite.T(), "AddTask", task)
+	suite.ErrorIs(err, domain.ErrInvalidTaskStatus)
+	suite.mockRepo.AssertNotCalled(suite.T(), "AddTask", mock.Anything, task)
+}
+
+func (suite *TaskServiceTestSuite) TestAddTask_DueDateInPast() {
+	task := domain.Task{Title: "New Task", Status: "pending", Description: "Description", DueDate: time.Now().UTC().AddDate(0, 0, -2)}
+
+	newTask, err := suite.service.AddTask(context.Background(), "admin", task)
+
+	suite.Nil(newTask)
+	var validationErr *domain.ValidationError
+	suite.ErrorAs(err, &validationErr)
+	suite.Equal([]domain.InvalidParam{{Name: "due_date", Reason: "must not be in the past", Rule: domain.RuleNotPast}}, validationErr.Params)
+	suite.mockRepo.AssertNotCalled(suite.T(), "AddTask", mock.Anything, mock.Anything)
+}
+
+// a date without a time for today is still accepted
+func (suite *TaskServiceTestSuite) TestAddTask_DueToday() {
+	now := time.Now().UTC()
+	task := domain.Task{Title: "New Task", Status: "pending", Description: "Description", DueDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+
+	suite.mockRepo.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).Return(&task, nil)
+
+	_, err := suite.service.AddTask(context.Background(), "admin", task)
+
+	suite.NoError(err)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TaskServiceTestSuite) TestGetDeletedTasks() {
+	deletedAt := time.Now().UTC()
+	deletedTasks := []domain.Task{{ID: uuid.New(), Title: "Test Task", DeletedAt: &deletedAt, DeletedBy: "admin"}}
+	suite.mockRepo.On("GetDeletedTasks", mock.Anything).Return(deletedTasks, nil)
+
+	tasks, err := suite.service.GetDeletedTasks(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(deletedTasks, tasks)
+}
+
+func (suite *TaskServiceTestSuite) TestRestoreTask() {
+	mockHistory := new(mocks.TaskHistoryRepoInterface)
+	suite.service.History = mockHistory
+	taskID := uuid.New()
+	deletedAt := time.Now().UTC()
+	suite.mockRepo.On("RestoreTask", mock.Anything, taskID).Return(&domain.Task{ID: taskID, Title: "Test Task", DeletedAt: &deletedAt, DeletedBy: "other"}, nil)
+	mockHistory.On("AddEntry", mock.Anything, mock.AnythingOfType("*domain.TaskHistoryEntry")).Return(nil)
+
+	err := suite.service.RestoreTask(context.Background(), "admin", taskID)
+
+	suite.NoError(err)
+	entry := mockHistory.Calls[0].Arguments.Get(1).(*domain.TaskHistoryEntry)
+	suite.Equal(domain.TaskRestored, entry.Action)
+	suite.Equal("admin", entry.Actor)
+	suite.Equal([]domain.TaskFieldChange{
+		{Field: "deleted_at", OldValue: deletedAt.Format(time.RFC3339)},
+		{Field: "deleted_by", OldValue: "other"},
+	}, entry.Changes)
+}
+
+func (suite *TaskServiceTestSuite) TestRestoreTask_NotInTrash() {
+	taskID := uuid.New()
+	suite.mockRepo.On("RestoreTask", mock.Anything, taskID).Return(nil, domain.ErrTaskNotFound)
+
+	err := suite.service.RestoreTask(context.Background(), "admin", taskID)
+
+	suite.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (suite *TaskServiceTestSuite) TestPurgeDeletedTasks() {
+	suite.service.TrashRetention = 24 * time.Hour
+	suite.mockRepo.On("PurgeDeletedTasks", mock.Anything, mock.MatchedBy(func(deletedBefore time.Time) bool {
+		return deletedBefore.Before(time.Now().Add(-23 * time.Hour))
+	})).Return(int64(2), nil)
+
+	purged, err := suite.service.PurgeDeletedTasks(context.Background())
+
+	suite.NoError(err)
+	suite.Equal(int64(2), purged)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// without a retention the trash is never emptied
+func (suite *TaskServiceTestSuite) TestPurgeDeletedTasks_NoRetention() {
+	purged, err := suite.service.PurgeDeletedTasks(context.Background())
+
+	suite.NoError(err)
+	suite.Zero(purged)
+	suite.mockRepo.AssertNotCalled(suite.T(), "PurgeDeletedTasks", mock.Anything, mock.Anything)
 }
 
 // TestSuite entry point
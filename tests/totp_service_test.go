@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TotpServiceSuite struct {
+	suite.Suite
+	service *infrastructure.TotpService
+}
+
+func (suite *TotpServiceSuite) SetupTest() {
+	suite.service = &infrastructure.TotpService{Issuer: "Task Manager"}
+}
+
+// Test GenerateCode against the RFC 6238 SHA1 test vectors
+
+func (suite *TotpServiceSuite) TestGenerateCode_RFCVectors() {
+	// base32 of the ascii key "12345678901234567890"
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := suite.service.GenerateCode(secret, time.Unix(59, 0))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "287082", code)
+
+	code, err = suite.service.GenerateCode(secret, time.Unix(1111111109, 0))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "081804", code)
+}
+
+// Test ValidateCode
+
+func (suite *TotpServiceSuite) TestValidateCode_CurrentCode() {
+	secret, err := suite.service.GenerateSecret()
+	assert.NoError(suite.T(), err)
+
+	code, _ := suite.service.GenerateCode(secret, time.Now())
+	counter, valid := suite.service.ValidateCode(secret, code, 0)
+	assert.True(suite.T(), valid)
+	assert.Equal(suite.T(), time.Now().Unix()/30, counter)
+}
+
+// a code is accepted once, RFC 6238 section 5.2
+func (suite *TotpServiceSuite) TestValidateCode_UsedCode() {
+	secret, _ := suite.service.GenerateSecret()
+	code, _ := suite.service.GenerateCode(secret, time.Now())
+
+	counter, valid := suite.service.ValidateCode(secret, code, 0)
+	assert.True(suite.T(), valid)
+	_, valid = suite.service.ValidateCode(secret, code, counter)
+	assert.False(suite.T(), valid)
+
+	// nor is an older code once a newer one was used
+	older, _ := suite.service.GenerateCode(secret, time.Now().Add(-30*time.Second))
+	_, valid = suite.service.ValidateCode(secret, older, counter)
+	assert.False(suite.T(), valid)
+}
+
+func (suite *TotpServiceSuite) TestValidateCode_AllowsOnePeriodOfDrift() {
+	secret, _ := suite.service.GenerateSecret()
+
+	code, _ := suite.service.GenerateCode(secret, time.Now().Add(-30*time.Second))
+	_, valid := suite.service.ValidateCode(secret, code, 0)
+	assert.True(suite.T(), valid)
+
+	code, _ = suite.service.GenerateCode(secret, time.Now().Add(-5*time.Minute))
+	_, valid = suite.service.ValidateCode(secret, code, 0)
+	assert.False(suite.T(), valid)
+}
+
+func (suite *TotpServiceSuite) TestValidateCode_Malformed() {
+	secret, _ := suite.service.GenerateSecret()
+
+	_, valid := suite.service.ValidateCode(secret, "12345", 0)
+	assert.False(suite.T(), valid)
+	_, valid = suite.service.ValidateCode("not base32!", "123456", 0)
+	assert.False(suite.T(), valid)
+}
+
+// Test ProvisioningURI
+
+func (suite *TotpServiceSuite) TestProvisioningURI() {
+	uri, err := url.Parse(suite.service.ProvisioningURI("testuser", "SECRET"))
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "otpauth", uri.Scheme)
+	assert.Equal(suite.T(), "totp", uri.Host)
+	assert.Equal(suite.T(), "/Task Manager:testuser", uri.Path)
+	assert.Equal(suite.T(), "SECRET", uri.Query().Get("secret"))
+	assert.Equal(suite.T(), "Task Manager", uri.Query().Get("issuer"))
+}
+
+// Test GenerateRecoveryCodes
+
+func (suite *TotpServiceSuite) TestGenerateRecoveryCodes() {
+	codes, err := suite.service.GenerateRecoveryCodes(10)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Len(suite.T(), code, 11)
+		assert.Equal(suite.T(), 1, strings.Count(code, "-"))
+		assert.False(suite.T(), seen[code])
+		seen[code] = true
+	}
+}
+
+func TestTotpServiceSuite(t *testing.T) {
+	suite.Run(t, new(TotpServiceSuite))
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracers obtained from the global provider stick to the first one installed,
+// so all tests share one recorder and look at the spans of their own trace
+var spanRecorder = tracetest.NewSpanRecorder()
+
+func init() {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+}
+
+type TracingSuite struct {
+	suite.Suite
+}
+
+// the ended spans of a trace by name
+func tracedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spanRecorder.Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+	return spans
+}
+
+// a request is traced from the handler through the use case
+func (suite *TracingSuite) TestRequestSpans() {
+	mockRepo := new(mocks.TaskRepoInterface)
+	id := uuid.New()
+	mockRepo.On("GetTaskById", mock.Anything, id).Return(&domain.Task{ID: id, Title: "Task"}, nil)
+	controller := &controllers.TaskController{Service: &usecases.TaskService{TaskRepo: mockRepo}}
+
+	router := gin.New()
+	router.Use(otelgin.Middleware("task-manager"))
+	router.GET("/tasks/:id", controller.GetTaskById)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+id.String(), nil))
+	suite.Equal(http.StatusOK, w.Code)
+
+	// the repository got the context of the use case span
+	ctx := mockRepo.Calls[0].Arguments.Get(0).(context.Context)
+	traceID := trace.SpanContextFromContext(ctx).TraceID()
+	spans := tracedSpans(traceID)
+	suite.Require().Contains(spans, "/tasks/:id")
+	suite.Require().Contains(spans, "TaskService.GetTaskById")
+	suite.Equal(spans["/tasks/:id"].SpanContext().SpanID(), spans["TaskService.GetTaskById"].Parent().SpanID())
+}
+
+// failed use cases can be found by their span status, rejected requests only carry the error
+func (suite *TracingSuite) TestUseCaseErrors() {
+	mockRepo := new(mocks.TaskRepoInterface)
+	failing, missing := uuid.New(), uuid.New()
+	mockRepo.On("GetTaskById", mock.Anything, failing).Return(nil, errors.New("connection refused"))
+	mockRepo.On("GetTaskById", mock.Anything, missing).Return(nil, domain.ErrTaskNotFound)
+	service := &usecases.TaskService{TaskRepo: mockRepo}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, err := service.GetTaskById(ctx, failing)
+	suite.Require().Error(err)
+	spans := tracedSpans(parent.SpanContext().TraceID())
+	suite.Require().Contains(spans, "TaskService.GetTaskById")
+	span := spans["TaskService.GetTaskById"]
+	suite.Equal(codes.Error, span.Status().Code)
+	suite.Equal("connection refused", span.Status().Description)
+	suite.Require().Len(span.Events(), 1)
+	suite.Equal("exception", span.Events()[0].Name)
+
+	ctx, parent = otel.Tracer("test").Start(context.Background(), "request")
+	_, err = service.GetTaskById(ctx, missing)
+	suite.Require().ErrorIs(err, domain.ErrTaskNotFound)
+	span = tracedSpans(parent.SpanContext().TraceID())["TaskService.GetTaskById"]
+	suite.Equal(codes.Unset, span.Status().Code)
+	suite.Len(span.Events(), 1)
+}
+
+func (suite *TracingSuite) TestMongoMonitor() {
+	monitor := infrastructure.NewMongoMonitor(otel.GetTracerProvider())
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "TaskService.GetTasks")
+
+	monitor.Started(ctx, &event.CommandStartedEvent{
+		Command:      mustMarshal(suite.T(), bson.D{{Key: "find", Value: "tasks"}}),
+		DatabaseName: "task-management",
+		CommandName:  "find",
+		RequestID:    1,
+		ConnectionID: "localhost:27017[-1]",
+	})
+	monitor.Failed(ctx, &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "localhost:27017[-1]"},
+		Failure:              "connection reset",
+	})
+	parent.End()
+
+	spans := tracedSpans(parent.SpanContext().TraceID())
+	suite.Require().Contains(spans, "tasks.find")
+	span := spans["tasks.find"]
+	suite.Equal(parent.SpanContext().SpanID(), span.Parent().SpanID())
+	suite.Equal(trace.SpanKindClient, span.SpanKind())
+	suite.Equal(codes.Error, span.Status().Code)
+	suite.Equal("connection reset", span.Status().Description)
+	suite.Contains(span.Attributes(), attribute.String("db.system", "mongodb"))
+	suite.Contains(span.Attributes(), attribute.String("db.collection.name", "tasks"))
+}
+
+func (suite *TracingSuite) TestSetupTracing_Stdout() {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+	var output bytes.Buffer
+
+	shutdown, err := infrastructure.SetupTracing(context.Background(), infrastructure.TracingConfig{Exporter: "stdout", ServiceName: "task-manager-test", Output: &output})
+	suite.Require().NoError(err)
+	_, span := otel.Tracer("test").Start(context.Background(), "exported span")
+	span.End()
+	suite.Require().NoError(shutdown(context.Background()))
+
+	suite.Contains(output.String(), "exported span")
+	suite.Contains(output.String(), "task-manager-test")
+}
+
+func (suite *TracingSuite) TestSetupTracing_None() {
+	previous := otel.GetTracerProvider()
+
+	shutdown, err := infrastructure.SetupTracing(context.Background(), infrastructure.TracingConfig{Exporter: "none"})
+
+	suite.NoError(err)
+	suite.NoError(shutdown(context.Background()))
+	suite.Equal(previous, otel.GetTracerProvider())
+}
+
+func (suite *TracingSuite) TestSetupTracing_UnknownExporter() {
+	_, err := infrastructure.SetupTracing(context.Background(), infrastructure.TracingConfig{Exporter: "zipkin"})
+
+	suite.ErrorContains(err, `unknown trace exporter "zipkin"`)
+}
+
+func mustMarshal(t *testing.T, doc bson.D) bson.Raw {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingSuite))
+}
@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/delivery/controllers"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
 	"github.com/gin-gonic/gin"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,7 +34,7 @@ func (suite *UserControllerSuite) SetupTest() {
 
 func (suite *UserControllerSuite) TestRegisterUser_Success() {
 	user := domain.User{Username: "testuser", Password: "password123"}
-	suite.mockService.On("RegisterUser", &user).Return(&user, nil)
+	suite.mockService.On("RegisterUser", mock.Anything, &user, "").Return(&user, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -40,16 +43,49 @@ func (suite *UserControllerSuite) TestRegisterUser_Success() {
 	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.RegisterUser(c)
+	handle(c, suite.controller.RegisterUser)
 
 	assert.Equal(suite.T(), http.StatusCreated, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "testuser")
 	suite.mockService.AssertExpectations(suite.T())
 }
 
+func (suite *UserControllerSuite) TestRegisterUser_WithInvitation() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+	suite.mockService.On("RegisterUser", mock.Anything, &user, "inv_token").Return(&user, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBufferString(`{"username": "testuser", "password": "password123", "invitation_token": "inv_token"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.RegisterUser)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestRegisterUser_Closed() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+	suite.mockService.On("RegisterUser", mock.Anything, &user, "").Return(nil, domain.ErrRegistrationClosed)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	userJSON, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBuffer(userJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.RegisterUser)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
 func (suite *UserControllerSuite) TestRegisterUser_UsernameAlreadyExists() {
 	user := domain.User{Username: "existinguser", Password: "password123"}
-	suite.mockService.On("RegisterUser", &user).Return(nil, fmt.Errorf("username already exists"))
+	suite.mockService.On("RegisterUser", mock.Anything, &user, "").Return(nil, domain.ErrUsernameExists)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -58,7 +94,7 @@ func (suite *UserControllerSuite) TestRegisterUser_UsernameAlreadyExists() {
 	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.RegisterUser(c)
+	handle(c, suite.controller.RegisterUser)
 
 	assert.Equal(suite.T(), http.StatusConflict, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "username already exists")
@@ -74,15 +110,15 @@ func (suite *UserControllerSuite) TestRegisterUser_InvalidJSON() {
 	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBufferString(invalidJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.RegisterUser(c)
+	handle(c, suite.controller.RegisterUser)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-	assert.Contains(suite.T(), w.Body.String(), "Invalid JSON")
+	assert.Contains(suite.T(), w.Body.String(), "invalid JSON")
 }
 
 func (suite *UserControllerSuite) TestRegisterUser_ValidationErrors() {
 	user := domain.User{Password: "password123"} // missing username
-	suite.mockService.On("RegisterUser", &user).Return(nil, nil)
+	suite.mockService.On("RegisterUser", mock.Anything, &user, "").Return(nil, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -91,10 +127,10 @@ func (suite *UserControllerSuite) TestRegisterUser_ValidationErrors() {
 	c.Request, _ = http.NewRequest("POST", "/register", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.RegisterUser(c)
+	handle(c, suite.controller.RegisterUser)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-	assert.Contains(suite.T(), w.Body.String(), "username is required.")
+	assert.Equal(suite.T(), []domain.InvalidParam{{Name: "username", Reason: "username is a required field"}}, decodeProblem(suite.T(), w).InvalidParams)
 }
 
 // Test Login
@@ -102,7 +138,7 @@ func (suite *UserControllerSuite) TestRegisterUser_ValidationErrors() {
 func (suite *UserControllerSuite) TestLogin_Success() {
 	user := domain.User{Username: "testuser", Password: "password123"}
 	token := "some-valid-token"
-	suite.mockService.On("LoginUser", user).Return(token, nil)
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(&domain.LoginResult{Token: token}, nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -111,7 +147,7 @@ func (suite *UserControllerSuite) TestLogin_Success() {
 	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.Login(c)
+	handle(c, suite.controller.Login)
 
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "User logged in successfully")
@@ -121,7 +157,7 @@ func (suite *UserControllerSuite) TestLogin_Success() {
 
 func (suite *UserControllerSuite) TestLogin_UserNotFound() {
 	user := domain.User{Username: "nonexistent", Password: "password123"}
-	suite.mockService.On("LoginUser", user).Return("", fmt.Errorf("user not found"))
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(nil, domain.ErrUserNotFound)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -130,7 +166,7 @@ func (suite *UserControllerSuite) TestLogin_UserNotFound() {
 	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.Login(c)
+	handle(c, suite.controller.Login)
 
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "user not found")
@@ -139,7 +175,7 @@ func (suite *UserControllerSuite) TestLogin_UserNotFound() {
 
 func (suite *UserControllerSuite) TestLogin_InvalidCredentials() {
 	user := domain.User{Username: "testuser", Password: "wrongpassword"}
-	suite.mockService.On("LoginUser", user).Return("", fmt.Errorf("invalid credentials"))
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(nil, domain.ErrInvalidCredentials)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -148,13 +184,118 @@ func (suite *UserControllerSuite) TestLogin_InvalidCredentials() {
 	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.Login(c)
+	handle(c, suite.controller.Login)
 
 	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "invalid credentials")
 	suite.mockService.AssertExpectations(suite.T())
 }
 
+func (suite *UserControllerSuite) TestLogin_TooManyAttempts() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(nil, &usecases.TooManyAttemptsError{RetryAfter: 90 * time.Second})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	userJSON, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.Login)
+
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	assert.Equal(suite.T(), "90", w.Header().Get("Retry-After"))
+	assert.Contains(suite.T(), w.Body.String(), "too many failed login attempts")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestLogin_TwoFactorRequired() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(&domain.LoginResult{ChallengeToken: "challenge-token", TwoFactorRequired: true}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	userJSON, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.Login)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "challenge-token")
+	assert.NotContains(suite.T(), w.Body.String(), `"token"`)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestLoginTwoFactor_Success() {
+	login := domain.TwoFactorLogin{ChallengeToken: "challenge-token", Code: "123456"}
+	suite.mockService.On("VerifyTwoFactorLogin", mock.Anything, login, mock.AnythingOfType("string")).Return("some-valid-token", nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	loginJSON, _ := json.Marshal(login)
+	c.Request, _ = http.NewRequest("POST", "/login/2fa", bytes.NewBuffer(loginJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.LoginTwoFactor)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "some-valid-token")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestLoginTwoFactor_InvalidCode() {
+	login := domain.TwoFactorLogin{ChallengeToken: "challenge-token", Code: "000000"}
+	suite.mockService.On("VerifyTwoFactorLogin", mock.Anything, login, mock.AnythingOfType("string")).Return("", domain.ErrInvalidTwoFactorCode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	loginJSON, _ := json.Marshal(login)
+	c.Request, _ = http.NewRequest("POST", "/login/2fa", bytes.NewBuffer(loginJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.LoginTwoFactor)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestEnrollTwoFactor_Success() {
+	enrollment := &domain.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/test"}
+	suite.mockService.On("EnrollTwoFactor", mock.Anything, "testuser").Return(enrollment, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/2fa/enroll", nil)
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.EnrollTwoFactor)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "otpauth://totp/test")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestConfirmTwoFactor_Success() {
+	suite.mockService.On("ConfirmTwoFactor", mock.Anything, "testuser", "123456").Return([]string{"abcde-fghij"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/2fa/verify", bytes.NewBufferString(`{"code": "123456"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.ConfirmTwoFactor)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "abcde-fghij")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
 func (suite *UserControllerSuite) TestLogin_InvalidJSON() {
 	invalidJSON := "{invalid json"
 
@@ -164,7 +305,7 @@ func (suite *UserControllerSuite) TestLogin_InvalidJSON() {
 	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBufferString(invalidJSON))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	suite.controller.Login(c)
+	handle(c, suite.controller.Login)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 	assert.Contains(suite.T(), w.Body.String(), "invalid character")
@@ -174,13 +315,14 @@ func (suite *UserControllerSuite) TestLogin_InvalidJSON() {
 
 func (suite *UserControllerSuite) TestPromoteUser_Success() {
 	username := "testuser"
-	suite.mockService.On("PromoteUser", username).Return(nil)
+	suite.mockService.On("PromoteUser", mock.Anything, "admin", username).Return(nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request, _ = http.NewRequest("PUT", "/promote?username="+username, nil)
+	c.Set("username", "admin")
 
-	suite.controller.PromoteUser(c)
+	handle(c, suite.controller.PromoteUser)
 	c.Writer.WriteHeaderNow()
 	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
@@ -188,16 +330,154 @@ func (suite *UserControllerSuite) TestPromoteUser_Success() {
 
 func (suite *UserControllerSuite) TestPromoteUser_InternalServerError() {
 	username := "testuser"
-	suite.mockService.On("PromoteUser", username).Return(fmt.Errorf("internal error"))
+	suite.mockService.On("PromoteUser", mock.Anything, mock.Anything, username).Return(fmt.Errorf("internal error"))
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request, _ = http.NewRequest("PUT", "/promote?username="+username, nil)
 
-	suite.controller.PromoteUser(c)
+	handle(c, suite.controller.PromoteUser)
 
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
-	assert.Contains(suite.T(), w.Body.String(), "internal error")
+	// internal errors are logged, not shown
+	assert.NotContains(suite.T(), w.Body.String(), "internal error")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+// Test UnlockUser
+
+func (suite *UserControllerSuite) TestUnlockUser_Success() {
+	username := "testuser"
+	suite.mockService.On("UnlockUser", mock.Anything, "admin", username).Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/unlock?username="+username, nil)
+	c.Set("username", "admin")
+
+	handle(c, suite.controller.UnlockUser)
+	c.Writer.WriteHeaderNow()
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestUnlockUser_NotFound() {
+	username := "nonexistent"
+	suite.mockService.On("UnlockUser", mock.Anything, mock.Anything, username).Return(domain.ErrUserNotFound)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/unlock?username="+username, nil)
+
+	handle(c, suite.controller.UnlockUser)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestLogin_PasswordLoginDisabled() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+	suite.mockService.On("LoginUser", mock.Anything, user, mock.AnythingOfType("string")).Return(nil, domain.ErrPasswordLoginDisabled)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	userJSON, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest("POST", "/login", bytes.NewBuffer(userJSON))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.Login)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestVerifyEmail_InvalidToken() {
+	suite.mockService.On("VerifyEmail", mock.Anything, "token").Return(domain.ErrInvalidVerificationToken)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/verify-email?token=token", nil)
+
+	handle(c, suite.controller.VerifyEmail)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestResendEmailVerification() {
+	suite.mockService.On("ResendEmailVerification", mock.Anything, "jane@example.com").Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/verify-email/resend", bytes.NewBufferString(`{"email": "jane@example.com"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handle(c, suite.controller.ResendEmailVerification)
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestGetProfile() {
+	profile := &domain.UserProfile{Username: "testuser", Timezone: "Europe/Berlin"}
+	suite.mockService.On("GetProfile", mock.Anything, "testuser").Return(profile, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/me", nil)
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.GetProfile)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "Europe/Berlin")
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestUpdateProfile_Success() {
+	suite.mockService.On("UpdateProfile", mock.Anything, "testuser", mock.MatchedBy(func(update domain.ProfileUpdate) bool {
+		return update.Timezone != nil && *update.Timezone == "Europe/Berlin" && update.AvatarURL != nil && *update.AvatarURL == "" && update.Email == nil
+	})).Return(&domain.UserProfile{Username: "testuser", Timezone: "Europe/Berlin"}, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/me", bytes.NewBufferString(`{"timezone": "Europe/Berlin", "avatar_url": ""}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.UpdateProfile)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerSuite) TestUpdateProfile_InvalidTimezone() {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/me", bytes.NewBufferString(`{"timezone": "Mars/Olympus"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.UpdateProfile)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), []domain.InvalidParam{{Name: "timezone", Reason: "timezone must be an IANA time zone"}}, decodeProblem(suite.T(), w).InvalidParams)
+	suite.mockService.AssertNotCalled(suite.T(), "UpdateProfile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserControllerSuite) TestUpdateProfile_EmailExists() {
+	suite.mockService.On("UpdateProfile", mock.Anything, "testuser", mock.AnythingOfType("domain.ProfileUpdate")).Return(nil, domain.ErrEmailExists)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/me", bytes.NewBufferString(`{"email": "jane@example.com"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("username", "testuser")
+
+	handle(c, suite.controller.UpdateProfile)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
 	suite.mockService.AssertExpectations(suite.T())
 }
 
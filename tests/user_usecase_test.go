@@ -1,13 +1,22 @@
 package tests
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/repositories"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/tests/mocks"
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -18,6 +27,7 @@ type UserServiceTestSuite struct {
 	mockJwtService *mocks.JwtServiceInterface
 	mockPwdService *mocks.PasswordServiceInterface
 	mockUserRepo   *mocks.UserRepoInterface
+	mockBootstrap  *mocks.BootstrapRepoInterface
 }
 
 // Setup test environment
@@ -25,8 +35,10 @@ func (suite *UserServiceTestSuite) SetupTest() {
 	suite.mockJwtService = new(mocks.JwtServiceInterface)
 	suite.mockPwdService = new(mocks.PasswordServiceInterface)
 	suite.mockUserRepo = new(mocks.UserRepoInterface)
+	suite.mockBootstrap = new(mocks.BootstrapRepoInterface)
 	suite.service = &usecases.UserService{
 		UserRepo:        suite.mockUserRepo,
+		Bootstrap:       suite.mockBootstrap,
 		PasswordService: suite.mockPwdService,
 		JwtService:      suite.mockJwtService,
 	}
@@ -40,13 +52,16 @@ func (suite *UserServiceTestSuite) TestRegisterUser_NewUser() {
 	}
 
 	// Mocking the Count method to return 0 (first user)
-	suite.mockUserRepo.On("Count").Return(int64(0), nil)
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(0), nil)
 	// Mocking the HashPassword method to return a hashed password
 	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
 	// Mocking the RegisterUser method to return the registered user
-	suite.mockUserRepo.On("RegisterUser", mock.AnythingOfType("*domain.User")).Return(&user, nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&user, nil)
+	// the first user wins the claim and is promoted
+	suite.mockBootstrap.On("ClaimFirstAdmin", mock.Anything, "testuser").Return(true, nil)
+	suite.mockUserRepo.On("PromoteUser", mock.Anything, "testuser").Return(nil)
 
-	registeredUser, err := suite.service.RegisterUser(&user)
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "")
 	fmt.Println(registeredUser)
 
 	suite.NoError(err)
@@ -59,6 +74,351 @@ func (suite *UserServiceTestSuite) TestRegisterUser_NewUser() {
 	suite.mockPwdService.AssertExpectations(suite.T())
 }
 
+// Test RegisterUser losing the first admin claim to a concurrent registration
+func (suite *UserServiceTestSuite) TestRegisterUser_FirstAdminClaimLost() {
+	user := domain.User{Username: "testuser", Password: "password123"}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(0), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&user, nil)
+	suite.mockBootstrap.On("ClaimFirstAdmin", mock.Anything, "testuser").Return(false, nil)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "")
+
+	suite.NoError(err)
+	suite.False(registeredUser.IsAdmin)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "PromoteUser", mock.Anything, "testuser")
+}
+
+// Test RegisterUser ignoring is_admin sent by the client
+func (suite *UserServiceTestSuite) TestRegisterUser_IgnoresClientAdminFlag() {
+	user := domain.User{Username: "testuser", Password: "password123", IsAdmin: true}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return !u.IsAdmin })).Return(&user, nil)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "")
+
+	suite.NoError(err)
+	suite.False(registeredUser.IsAdmin)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// Test RegisterUser when registration is closed
+func (suite *UserServiceTestSuite) TestRegisterUser_Closed() {
+	suite.service.RegistrationMode = usecases.RegistrationClosed
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "")
+
+	suite.Nil(registeredUser)
+	suite.ErrorIs(err, domain.ErrRegistrationClosed)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+}
+
+// Test RegisterUser with an admin invitation
+func (suite *UserServiceTestSuite) TestRegisterUser_WithInvitation() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), Username: "testuser", IsAdmin: true, ExpiresAt: time.Now().Add(time.Hour)}
+	user := domain.User{Username: "testuser", Password: "password123"}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+	mockInvitationRepo.On("UseInvitation", mock.Anything, invitation.ID, "testuser", mock.AnythingOfType("time.Time")).Return(nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return u.IsAdmin })).Return(&user, nil)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "inv_token")
+
+	suite.NoError(err)
+	suite.True(registeredUser.IsAdmin)
+	mockInvitationRepo.AssertExpectations(suite.T())
+}
+
+// Test RegisterUser without an invitation when registration is invite only
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationRequired() {
+	suite.useInvitations(usecases.RegistrationInvite)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "")
+
+	suite.Nil(registeredUser)
+	suite.ErrorIs(err, domain.ErrInvitationRequired)
+}
+
+// Test RegisterUser with an expired invitation
+func (suite *UserServiceTestSuite) TestRegisterUser_ExpiredInvitation() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "inv_token")
+
+	suite.Nil(registeredUser)
+	suite.ErrorIs(err, domain.ErrInvalidInvitation)
+	mockInvitationRepo.AssertNotCalled(suite.T(), "UseInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test RegisterUser with an invitation for another username
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationForAnotherUsername() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), Username: "invited", ExpiresAt: time.Now().Add(time.Hour)}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+
+	_, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "inv_token")
+
+	suite.ErrorIs(err, domain.ErrInvitationForOtherUser)
+}
+
+// Test RegisterUser with an invitation for another email
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationForAnotherEmail() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), Email: "invited@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+
+	_, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123", Email: "other@example.com"}, "inv_token")
+
+	suite.ErrorIs(err, domain.ErrInvitationForOtherEmail)
+	mockInvitationRepo.AssertNotCalled(suite.T(), "UseInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test RegisterUser accepting the invited email in another case
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationForEmail() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), Email: "invited@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+	user := domain.User{Username: "testuser", Password: "password123", Email: " Invited@Example.com"}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+	mockInvitationRepo.On("UseInvitation", mock.Anything, invitation.ID, "testuser", mock.AnythingOfType("time.Time")).Return(nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&user, nil)
+
+	_, err := suite.service.RegisterUser(context.Background(), &user, "inv_token")
+
+	suite.NoError(err)
+	mockInvitationRepo.AssertExpectations(suite.T())
+}
+
+// Test RegisterUser logging an invitation that can't be released
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationReleaseFails() {
+	var logs bytes.Buffer
+	suite.service.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+	mockInvitationRepo.On("UseInvitation", mock.Anything, invitation.ID, "testuser", mock.AnythingOfType("time.Time")).Return(nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrUsernameExists)
+	mockInvitationRepo.On("ReleaseInvitation", mock.Anything, invitation.ID).Return(errors.New("connection refused"))
+
+	_, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "inv_token")
+
+	suite.ErrorIs(err, domain.ErrUsernameExists)
+	suite.Contains(logs.String(), "could not release invitation")
+	suite.Contains(logs.String(), invitation.ID.String())
+}
+
+// Test RegisterUser releasing the invitation when the username is taken
+func (suite *UserServiceTestSuite) TestRegisterUser_InvitationReleasedOnFailure() {
+	mockInvitationRepo, mockGenerator := suite.useInvitations(usecases.RegistrationInvite)
+	invitation := &domain.Invitation{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	mockGenerator.On("HashSecret", "inv_token").Return("hashed-token")
+	mockInvitationRepo.On("GetInvitationByHash", mock.Anything, "hashed-token").Return(invitation, nil)
+	mockInvitationRepo.On("UseInvitation", mock.Anything, invitation.ID, "testuser", mock.AnythingOfType("time.Time")).Return(nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil, domain.ErrUsernameExists)
+	mockInvitationRepo.On("ReleaseInvitation", mock.Anything, invitation.ID).Return(nil)
+
+	_, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "inv_token")
+
+	suite.ErrorIs(err, domain.ErrUsernameExists)
+	mockInvitationRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserServiceTestSuite) useInvitations(mode usecases.RegistrationMode) (*mocks.InvitationRepoInterface, *mocks.TokenGeneratorInterface) {
+	mockInvitationRepo := new(mocks.InvitationRepoInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	suite.service.RegistrationMode = mode
+	suite.service.Invitations = mockInvitationRepo
+	suite.service.TokenGenerator = mockGenerator
+	return mockInvitationRepo, mockGenerator
+}
+
+// Test RegisterUser sending a verification email
+func (suite *UserServiceTestSuite) TestRegisterUser_SendsVerificationEmail() {
+	mockMailer := new(mocks.MailerInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	suite.service.Mailer = mockMailer
+	suite.service.TokenGenerator = mockGenerator
+	suite.service.VerificationURL = "https://tasks.example.com/verify-email"
+	suite.service.RequireEmailVerification = true
+	user := domain.User{Username: "testuser", Password: "password123", Email: " Jane@Example.com", EmailVerified: true}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Email == "jane@example.com" && !u.EmailVerified
+	})).Return(func(_ context.Context, u *domain.User) *domain.User { return u }, nil)
+	mockGenerator.On("GenerateSecret", 32).Return("token", nil)
+	mockGenerator.On("HashSecret", "token").Return("hashed-token")
+	suite.mockUserRepo.On("SetEmailVerification", mock.Anything, "testuser", "", "hashed-token", mock.AnythingOfType("time.Time")).Return(nil)
+	mockMailer.On("Send", mock.Anything, "jane@example.com", "Confirm your email address", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "https://tasks.example.com/verify-email?token=token")
+	})).Return(nil)
+
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "")
+
+	suite.NoError(err)
+	suite.False(registeredUser.EmailVerified)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	mockMailer.AssertExpectations(suite.T())
+}
+
+// Test RegisterUser logging a verification email that couldn't be sent
+func (suite *UserServiceTestSuite) TestRegisterUser_VerificationEmailFails() {
+	mockMailer := new(mocks.MailerInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	var logs bytes.Buffer
+	suite.service.Mailer = mockMailer
+	suite.service.TokenGenerator = mockGenerator
+	suite.service.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	user := domain.User{Username: "testuser", Password: "password123", Email: "jane@example.com"}
+
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
+	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.Anything).Return(func(_ context.Context, u *domain.User) *domain.User { return u }, nil)
+	mockGenerator.On("GenerateSecret", 32).Return("token", nil)
+	mockGenerator.On("HashSecret", "token").Return("hashed-token")
+	suite.mockUserRepo.On("SetEmailVerification", mock.Anything, "testuser", "", "hashed-token", mock.AnythingOfType("time.Time")).Return(nil)
+	mockMailer.On("Send", mock.Anything, "jane@example.com", "Confirm your email address", mock.AnythingOfType("string")).Return(errors.New("connection refused"))
+
+	_, err := suite.service.RegisterUser(context.Background(), &user, "")
+
+	// the account exists, the link can be sent again
+	suite.NoError(err)
+	suite.Contains(logs.String(), "could not send verification email")
+	suite.Contains(logs.String(), "connection refused")
+}
+
+// Test RegisterUser without an email when verification is required
+func (suite *UserServiceTestSuite) TestRegisterUser_EmailRequired() {
+	suite.service.RequireEmailVerification = true
+
+	_, err := suite.service.RegisterUser(context.Background(), &domain.User{Username: "testuser", Password: "password123"}, "")
+
+	suite.ErrorIs(err, domain.ErrEmailRequired)
+}
+
+// Test LoginUser with an unverified email
+func (suite *UserServiceTestSuite) TestLoginUser_EmailNotVerified() {
+	suite.service.RequireEmailVerification = true
+	existingUser := &domain.User{Username: "testuser", Password: "hashedpassword123", Email: "jane@example.com"}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(existingUser, nil)
+	suite.mockPwdService.On("ComparePassword", "hashedpassword123", "password123").Return(true)
+
+	result, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrEmailNotVerified)
+	suite.mockJwtService.AssertNotCalled(suite.T(), "GenerateToken", mock.Anything, mock.Anything)
+}
+
+// Test CheckAccount restricting users until their email is verified
+func (suite *UserServiceTestSuite) TestCheckAccount() {
+	suite.NoError(suite.service.CheckAccount(context.Background(), "testuser"))
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "GetUser", mock.Anything, mock.Anything)
+
+	suite.service.RequireEmailVerification = true
+	suite.mockUserRepo.On("GetUser", mock.Anything, "unverified").Return(&domain.User{Username: "unverified", Email: "new@example.com"}, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "verified").Return(&domain.User{Username: "verified", Email: "jane@example.com", EmailVerified: true}, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "deleted").Return(nil, domain.ErrUserNotFound)
+
+	suite.ErrorIs(suite.service.CheckAccount(context.Background(), "unverified"), domain.ErrEmailNotVerified)
+	suite.NoError(suite.service.CheckAccount(context.Background(), "verified"))
+	suite.ErrorIs(suite.service.CheckAccount(context.Background(), "deleted"), domain.ErrInvalidJWT)
+}
+
+// Test VerifyEmail
+func (suite *UserServiceTestSuite) TestVerifyEmail() {
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	suite.service.TokenGenerator = mockGenerator
+	mockGenerator.On("HashSecret", "token").Return("hashed-token")
+	suite.mockUserRepo.On("VerifyEmail", mock.Anything, "hashed-token", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := suite.service.VerifyEmail(context.Background(), "token")
+
+	suite.NoError(err)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// Test ResendEmailVerification for an unknown email
+func (suite *UserServiceTestSuite) TestResendEmailVerification_UnknownEmail() {
+	mockMailer := new(mocks.MailerInterface)
+	suite.service.Mailer = mockMailer
+	suite.mockUserRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+	err := suite.service.ResendEmailVerification(context.Background(), "Nobody@example.com")
+
+	suite.NoError(err)
+	mockMailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test BootstrapAdmin creating the initial admin
+func (suite *UserServiceTestSuite) TestBootstrapAdmin_CreatesAdmin() {
+	suite.mockUserRepo.On("GetUser", mock.Anything, "admin").Return(nil, domain.ErrUserNotFound)
+	suite.mockPwdService.On("HashPassword", "adminpassword").Return("hashedadminpassword", nil)
+	suite.mockBootstrap.On("ClaimFirstAdmin", mock.Anything, "admin").Return(true, nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Username == "admin" && u.IsAdmin && u.Password == "hashedadminpassword"
+	})).Return(&domain.User{Username: "admin", IsAdmin: true}, nil)
+
+	err := suite.service.BootstrapAdmin(context.Background(), "admin", "adminpassword")
+
+	suite.NoError(err)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockBootstrap.AssertExpectations(suite.T())
+}
+
+// Test BootstrapAdmin on a restart, when the admin already exists
+func (suite *UserServiceTestSuite) TestBootstrapAdmin_AlreadyExists() {
+	suite.mockUserRepo.On("GetUser", mock.Anything, "admin").Return(&domain.User{Username: "admin", IsAdmin: true}, nil)
+
+	err := suite.service.BootstrapAdmin(context.Background(), "admin", "adminpassword")
+
+	suite.NoError(err)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything, mock.Anything)
+}
+
+// Test BootstrapAdmin refusing to promote a user registered under the admin's name
+func (suite *UserServiceTestSuite) TestBootstrapAdmin_TakenByUser() {
+	suite.mockUserRepo.On("GetUser", mock.Anything, "admin").Return(&domain.User{Username: "admin"}, nil)
+
+	err := suite.service.BootstrapAdmin(context.Background(), "admin", "adminpassword")
+
+	suite.EqualError(err, "initial admin username is taken by a non-admin user")
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "PromoteUser", mock.Anything, mock.Anything)
+}
+
 // Test RegisterUser with an existing user
 func (suite *UserServiceTestSuite) TestRegisterUser_ExistingUser() {
 	user := domain.User{
@@ -67,13 +427,13 @@ func (suite *UserServiceTestSuite) TestRegisterUser_ExistingUser() {
 	}
 
 	// Mocking the Count method to return 1 (not the first user)
-	suite.mockUserRepo.On("Count").Return(int64(1), nil)
+	suite.mockUserRepo.On("Count", mock.Anything).Return(int64(1), nil)
 	// Mocking the HashPassword method to return a hashed password
 	suite.mockPwdService.On("HashPassword", "password123").Return("hashedpassword123", nil)
 	// Mocking the RegisterUser method to return the registered user
-	suite.mockUserRepo.On("RegisterUser", mock.AnythingOfType("*domain.User")).Return(&user, nil)
+	suite.mockUserRepo.On("RegisterUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(&user, nil)
 
-	registeredUser, err := suite.service.RegisterUser(&user)
+	registeredUser, err := suite.service.RegisterUser(context.Background(), &user, "")
 
 	suite.NoError(err)
 	suite.NotNil(registeredUser)
@@ -93,22 +453,59 @@ func (suite *UserServiceTestSuite) TestLoginUser_ValidCredentials() {
 	}
 
 	// Mocking the GetUser method to return the existing user
-	suite.mockUserRepo.On("GetUser", "testuser").Return(&user, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
 	// Mocking the ComparePassword method to return true
 	suite.mockPwdService.On("ComparePassword", user.Password, "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", user.Password).Return(false)
 	// Mocking the GenerateToken method to return a JWT token
 	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
 
-	token, err := suite.service.LoginUser(user)
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
 
 	suite.NoError(err)
-	suite.Equal("valid.jwt.token", token)
+	suite.Equal("valid.jwt.token", result.Token)
 
 	suite.mockUserRepo.AssertExpectations(suite.T())
 	suite.mockPwdService.AssertExpectations(suite.T())
 	suite.mockJwtService.AssertExpectations(suite.T())
 }
 
+// Test LoginUser rehashing a password stored with outdated parameters
+func (suite *UserServiceTestSuite) TestLoginUser_RehashesPassword() {
+	existingUser := &domain.User{Username: "testuser", Password: "$2a$10$oldhash"}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(existingUser, nil)
+	suite.mockPwdService.On("ComparePassword", "$2a$10$oldhash", "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", "$2a$10$oldhash").Return(true)
+	suite.mockPwdService.On("HashPassword", "password123").Return("$argon2id$newhash", nil)
+	suite.mockUserRepo.On("UpdatePassword", mock.Anything, "testuser", "$argon2id$newhash").Return(nil)
+	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+
+	result, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", result.Token)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockPwdService.AssertExpectations(suite.T())
+}
+
+// Test LoginUser still logging in when the upgraded hash can't be stored
+func (suite *UserServiceTestSuite) TestLoginUser_RehashFailure() {
+	existingUser := &domain.User{Username: "testuser", Password: "$2a$10$oldhash"}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(existingUser, nil)
+	suite.mockPwdService.On("ComparePassword", "$2a$10$oldhash", "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", "$2a$10$oldhash").Return(true)
+	suite.mockPwdService.On("HashPassword", "password123").Return("$argon2id$newhash", nil)
+	suite.mockUserRepo.On("UpdatePassword", mock.Anything, "testuser", "$argon2id$newhash").Return(errors.New("write concern error"))
+	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+
+	result, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", result.Token)
+}
+
 // Test LoginUser with invalid credentials
 func (suite *UserServiceTestSuite) TestLoginUser_InvalidCredentials() {
 	user := domain.User{
@@ -117,25 +514,348 @@ func (suite *UserServiceTestSuite) TestLoginUser_InvalidCredentials() {
 	}
 
 	// Mocking the GetUser method to return the existing user
-	suite.mockUserRepo.On("GetUser", "testuser").Return(&user, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
 	// Mocking the ComparePassword method to return false
 	suite.mockPwdService.On("ComparePassword", user.Password, "wrongpassword").Return(false)
 
-	token, err := suite.service.LoginUser(user)
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
 
-	suite.EqualError(err, "invalid credentials")
-	suite.Empty(token)
+	suite.ErrorIs(err, domain.ErrInvalidCredentials)
+	suite.Nil(result)
 
 	suite.mockUserRepo.AssertExpectations(suite.T())
 	suite.mockPwdService.AssertExpectations(suite.T())
 }
 
+// Test LoginUser counting logins by result
+func (suite *UserServiceTestSuite) TestLoginUser_Metrics() {
+	mockMetrics := new(mocks.LoginMetricsInterface)
+	suite.service.Metrics = mockMetrics
+	existingUser := &domain.User{Username: "testuser", Password: "hashed"}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(existingUser, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "unknown").Return(nil, domain.ErrUserNotFound)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "broken").Return(nil, errors.New("connection refused"))
+	suite.mockPwdService.On("ComparePassword", "hashed", "password123").Return(true)
+	suite.mockPwdService.On("ComparePassword", "hashed", "wrongpassword").Return(false)
+	suite.mockPwdService.On("NeedsRehash", "hashed").Return(false)
+	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+	mockMetrics.On("ObserveLogin", usecases.LoginSucceeded).Once()
+	mockMetrics.On("ObserveLogin", usecases.LoginInvalidCredentials).Twice()
+	mockMetrics.On("ObserveLogin", usecases.LoginError).Once()
+
+	suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+	suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "wrongpassword"}, "127.0.0.1")
+	suite.service.LoginUser(context.Background(), domain.User{Username: "unknown", Password: "password123"}, "127.0.0.1")
+	suite.service.LoginUser(context.Background(), domain.User{Username: "broken", Password: "password123"}, "127.0.0.1")
+
+	mockMetrics.AssertExpectations(suite.T())
+}
+
+// Test LoginUser while the username is locked out
+func (suite *UserServiceTestSuite) TestLoginUser_LockedOut() {
+	user := domain.User{
+		Username: "testuser",
+		Password: "password123",
+	}
+	mockAttempts := new(mocks.LoginAttemptRepoInterface)
+	suite.service.LoginAttempts = mockAttempts
+
+	// the username is locked for another minute, the ip is not
+	mockAttempts.On("GetAttempt", mock.Anything, "user:testuser").Return(&domain.LoginAttempt{Key: "user:testuser", Failures: 5, LockedUntil: time.Now().Add(time.Minute)}, nil)
+	mockAttempts.On("GetAttempt", mock.Anything, "ip:127.0.0.1").Return(&domain.LoginAttempt{Key: "ip:127.0.0.1"}, nil)
+
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
+
+	var lockoutErr *usecases.TooManyAttemptsError
+	suite.ErrorAs(err, &lockoutErr)
+	suite.InDelta(time.Minute.Seconds(), lockoutErr.RetryAfter.Seconds(), 1)
+	suite.Nil(result)
+
+	// the password is never checked while locked out
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "GetUser", mock.Anything, "testuser")
+	mockAttempts.AssertExpectations(suite.T())
+}
+
+// Test LoginUser locking the username and ip on reaching the failure limit
+func (suite *UserServiceTestSuite) TestLoginUser_FailureTriggersLockout() {
+	user := domain.User{
+		Username: "testuser",
+		Password: "wrongpassword",
+	}
+	mockAttempts := new(mocks.LoginAttemptRepoInterface)
+	suite.service.LoginAttempts = mockAttempts
+	policy := usecases.DefaultLockoutPolicy()
+	lastFailure := time.Now()
+
+	mockAttempts.On("GetAttempt", mock.Anything, mock.AnythingOfType("string")).Return(&domain.LoginAttempt{}, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	suite.mockPwdService.On("ComparePassword", user.Password, "wrongpassword").Return(false)
+	mockAttempts.On("RecordFailure", mock.Anything, "user:testuser", policy.Window).Return(&domain.LoginAttempt{Key: "user:testuser", Failures: policy.MaxFailures, LastFailure: lastFailure}, nil)
+	mockAttempts.On("RecordFailure", mock.Anything, "ip:127.0.0.1", policy.Window).Return(&domain.LoginAttempt{Key: "ip:127.0.0.1", Failures: 1, LastFailure: lastFailure}, nil)
+	mockAttempts.On("LockUntil", mock.Anything, "user:testuser", lastFailure.Add(policy.BaseLockout)).Return(nil)
+
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
+
+	suite.ErrorIs(err, domain.ErrInvalidCredentials)
+	suite.Nil(result)
+	mockAttempts.AssertExpectations(suite.T())
+	mockAttempts.AssertNotCalled(suite.T(), "LockUntil", mock.Anything, "ip:127.0.0.1", mock.Anything)
+}
+
+// Test LoginUser clearing the failure count on success
+func (suite *UserServiceTestSuite) TestLoginUser_SuccessResetsAttempts() {
+	user := domain.User{
+		Username: "testuser",
+		Password: "password123",
+	}
+	mockAttempts := new(mocks.LoginAttemptRepoInterface)
+	suite.service.LoginAttempts = mockAttempts
+
+	mockAttempts.On("GetAttempt", mock.Anything, mock.AnythingOfType("string")).Return(&domain.LoginAttempt{}, nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	suite.mockPwdService.On("ComparePassword", user.Password, "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", user.Password).Return(false)
+	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+	mockAttempts.On("Reset", mock.Anything, "user:testuser").Return(nil)
+
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", result.Token)
+	mockAttempts.AssertExpectations(suite.T())
+}
+
+// Test LockoutPolicy backing off exponentially up to the maximum
+func (suite *UserServiceTestSuite) TestLockoutPolicy_LockoutFor() {
+	policy := usecases.LockoutPolicy{MaxFailures: 3, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	suite.Equal(time.Duration(0), policy.LockoutFor(2))
+	suite.Equal(time.Minute, policy.LockoutFor(3))
+	suite.Equal(4*time.Minute, policy.LockoutFor(5))
+	suite.Equal(10*time.Minute, policy.LockoutFor(10))
+}
+
+// Test UnlockUser
+// Test LoginUser when only identity provider logins are allowed
+func (suite *UserServiceTestSuite) TestLoginUser_PasswordLoginDisabled() {
+	suite.service.PasswordLoginDisabled = true
+
+	result, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "127.0.0.1")
+
+	suite.Nil(result)
+	suite.ErrorIs(err, domain.ErrPasswordLoginDisabled)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "GetUser", mock.Anything, "testuser")
+}
+
+func (suite *UserServiceTestSuite) TestUnlockUser() {
+	mockAttempts := new(mocks.LoginAttemptRepoInterface)
+	suite.service.LoginAttempts = mockAttempts
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser"}, nil)
+	mockAttempts.On("Reset", mock.Anything, "user:testuser").Return(nil)
+
+	err := suite.service.UnlockUser(context.Background(), "admin", "testuser")
+
+	suite.NoError(err)
+	mockAttempts.AssertExpectations(suite.T())
+}
+
+// Test LoginUser for a user with two-factor authentication enabled
+func (suite *UserServiceTestSuite) TestLoginUser_TwoFactorChallenge() {
+	user := domain.User{
+		Username:         "testuser",
+		Password:         "password123",
+		TwoFactorEnabled: true,
+	}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	suite.mockPwdService.On("ComparePassword", user.Password, "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", user.Password).Return(false)
+	suite.mockJwtService.On("GenerateChallengeToken", "testuser").Return("challenge.token", nil)
+
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.True(result.TwoFactorRequired)
+	suite.Equal("challenge.token", result.ChallengeToken)
+	suite.Empty(result.Token)
+	suite.mockJwtService.AssertNotCalled(suite.T(), "GenerateToken", "testuser", mock.Anything)
+}
+
+// Test LoginUser withholding admin rights from an admin without two-factor authentication
+func (suite *UserServiceTestSuite) TestLoginUser_AdminTwoFactorEnforced() {
+	user := domain.User{
+		Username: "admin",
+		Password: "password123",
+		IsAdmin:  true,
+	}
+	suite.service.RequireAdminTwoFactor = true
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "admin").Return(&user, nil)
+	suite.mockPwdService.On("ComparePassword", user.Password, "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", user.Password).Return(false)
+	suite.mockJwtService.On("GenerateToken", "admin", false).Return("valid.jwt.token", nil)
+
+	result, err := suite.service.LoginUser(context.Background(), user, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", result.Token)
+	suite.True(result.TwoFactorEnrollmentRequired)
+	suite.mockJwtService.AssertExpectations(suite.T())
+}
+
+// Test VerifyTwoFactorLogin with a valid totp code
+func (suite *UserServiceTestSuite) TestVerifyTwoFactorLogin_ValidCode() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+	user := domain.User{Username: "testuser", IsAdmin: true, TwoFactorEnabled: true, TOTPSecret: "SECRET"}
+
+	suite.mockJwtService.On("ValidateChallengeToken", "challenge.token").Return("testuser", nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	mockTotp.On("ValidateCode", "SECRET", "123456", int64(0)).Return(int64(1000), true)
+	suite.mockUserRepo.On("UseTOTPCounter", mock.Anything, "testuser", int64(1000)).Return(nil)
+	suite.mockJwtService.On("GenerateToken", "testuser", true).Return("valid.jwt.token", nil)
+
+	token, err := suite.service.VerifyTwoFactorLogin(context.Background(), domain.TwoFactorLogin{ChallengeToken: "challenge.token", Code: "123456"}, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", token)
+	mockTotp.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// Test VerifyTwoFactorLogin with a totp code another login used already
+func (suite *UserServiceTestSuite) TestVerifyTwoFactorLogin_UsedCode() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+	user := domain.User{Username: "testuser", TwoFactorEnabled: true, TOTPSecret: "SECRET", TOTPLastCounter: 999}
+
+	suite.mockJwtService.On("ValidateChallengeToken", "challenge.token").Return("testuser", nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	mockTotp.On("ValidateCode", "SECRET", "123456", int64(999)).Return(int64(1000), true)
+	suite.mockUserRepo.On("UseTOTPCounter", mock.Anything, "testuser", int64(1000)).Return(domain.ErrInvalidTwoFactorCode)
+
+	token, err := suite.service.VerifyTwoFactorLogin(context.Background(), domain.TwoFactorLogin{ChallengeToken: "challenge.token", Code: "123456"}, "127.0.0.1")
+
+	suite.ErrorIs(err, domain.ErrInvalidTwoFactorCode)
+	suite.Empty(token)
+	suite.mockJwtService.AssertNotCalled(suite.T(), "GenerateToken", "testuser", mock.Anything)
+}
+
+// Test VerifyTwoFactorLogin consuming a recovery code
+func (suite *UserServiceTestSuite) TestVerifyTwoFactorLogin_RecoveryCode() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+	user := domain.User{Username: "testuser", TwoFactorEnabled: true, TOTPSecret: "SECRET", RecoveryCodes: []string{"hash1", "hash2"}}
+
+	suite.mockJwtService.On("ValidateChallengeToken", "challenge.token").Return("testuser", nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	mockTotp.On("ValidateCode", "SECRET", "abcde-fghij", int64(0)).Return(int64(0), false)
+	suite.mockPwdService.On("ComparePassword", "hash1", "abcde-fghij").Return(true)
+	suite.mockUserRepo.On("UpdateRecoveryCodes", mock.Anything, "testuser", []string{"hash2"}).Return(nil)
+	suite.mockJwtService.On("GenerateToken", "testuser", false).Return("valid.jwt.token", nil)
+
+	token, err := suite.service.VerifyTwoFactorLogin(context.Background(), domain.TwoFactorLogin{ChallengeToken: "challenge.token", Code: "abcde-fghij"}, "127.0.0.1")
+
+	suite.NoError(err)
+	suite.Equal("valid.jwt.token", token)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// Test VerifyTwoFactorLogin with a wrong code
+func (suite *UserServiceTestSuite) TestVerifyTwoFactorLogin_InvalidCode() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+	user := domain.User{Username: "testuser", TwoFactorEnabled: true, TOTPSecret: "SECRET"}
+
+	suite.mockJwtService.On("ValidateChallengeToken", "challenge.token").Return("testuser", nil)
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	mockTotp.On("ValidateCode", "SECRET", "000000", int64(0)).Return(int64(0), false)
+
+	token, err := suite.service.VerifyTwoFactorLogin(context.Background(), domain.TwoFactorLogin{ChallengeToken: "challenge.token", Code: "000000"}, "127.0.0.1")
+
+	suite.ErrorIs(err, domain.ErrInvalidTwoFactorCode)
+	suite.Empty(token)
+	suite.mockJwtService.AssertNotCalled(suite.T(), "GenerateToken", "testuser", mock.Anything)
+}
+
+// Test that a correct password doesn't reset the failures of wrong second factors
+func (suite *UserServiceTestSuite) TestVerifyTwoFactorLogin_AlternatingWithPasswordLocksOut() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+	suite.service.LoginAttempts = repositories.NewInMemoryLoginAttemptRepository()
+	user := domain.User{Username: "testuser", Password: "hash", TwoFactorEnabled: true, TOTPSecret: "SECRET"}
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&user, nil)
+	suite.mockPwdService.On("ComparePassword", "hash", "password123").Return(true)
+	suite.mockPwdService.On("NeedsRehash", "hash").Return(false)
+	suite.mockJwtService.On("GenerateChallengeToken", "testuser").Return("challenge.token", nil)
+	suite.mockJwtService.On("ValidateChallengeToken", "challenge.token").Return("testuser", nil)
+	mockTotp.On("ValidateCode", "SECRET", "000000", int64(0)).Return(int64(0), false)
+
+	// every attempt from another ip so only the username counter can stop it
+	for i := 0; i < usecases.DefaultLockoutPolicy().MaxFailures; i++ {
+		clientIP := fmt.Sprintf("192.0.2.%d", i)
+		result, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, clientIP)
+		suite.Require().NoError(err)
+		suite.Require().True(result.TwoFactorRequired)
+
+		_, err = suite.service.VerifyTwoFactorLogin(context.Background(), domain.TwoFactorLogin{ChallengeToken: result.ChallengeToken, Code: "000000"}, clientIP)
+		suite.Require().ErrorIs(err, domain.ErrInvalidTwoFactorCode)
+	}
+
+	_, err := suite.service.LoginUser(context.Background(), domain.User{Username: "testuser", Password: "password123"}, "192.0.2.100")
+
+	var tooManyAttempts *usecases.TooManyAttemptsError
+	suite.ErrorAs(err, &tooManyAttempts)
+	suite.Equal(http.StatusTooManyRequests, infrastructure.ErrorStatus(err))
+}
+
+// Test EnrollTwoFactor storing a pending secret
+func (suite *UserServiceTestSuite) TestEnrollTwoFactor() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser"}, nil)
+	mockTotp.On("GenerateSecret").Return("SECRET", nil)
+	suite.mockUserRepo.On("UpdateTwoFactor", mock.Anything, "testuser", "SECRET", false, []string(nil)).Return(nil)
+	mockTotp.On("ProvisioningURI", "testuser", "SECRET").Return("otpauth://totp/test")
+
+	enrollment, err := suite.service.EnrollTwoFactor(context.Background(), "testuser")
+
+	suite.NoError(err)
+	suite.Equal("SECRET", enrollment.Secret)
+	suite.Equal("otpauth://totp/test", enrollment.URI)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// Test ConfirmTwoFactor enabling two-factor authentication with hashed recovery codes
+func (suite *UserServiceTestSuite) TestConfirmTwoFactor() {
+	mockTotp := new(mocks.TotpServiceInterface)
+	suite.service.TotpService = mockTotp
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", TOTPSecret: "SECRET"}, nil)
+	mockTotp.On("ValidateCode", "SECRET", "123456", int64(0)).Return(int64(1000), true)
+	mockTotp.On("GenerateRecoveryCodes", 10).Return([]string{"code1", "code2"}, nil)
+	suite.mockPwdService.On("HashPassword", "code1").Return("hash1", nil)
+	suite.mockPwdService.On("HashPassword", "code2").Return("hash2", nil)
+	suite.mockUserRepo.On("UpdateTwoFactor", mock.Anything, "testuser", "SECRET", true, []string{"hash1", "hash2"}).Return(nil)
+	suite.mockUserRepo.On("UseTOTPCounter", mock.Anything, "testuser", int64(1000)).Return(nil)
+
+	codes, err := suite.service.ConfirmTwoFactor(context.Background(), "testuser", "123456")
+
+	suite.NoError(err)
+	suite.Equal([]string{"code1", "code2"}, codes)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
 // Test PromoteUser
 func (suite *UserServiceTestSuite) TestPromoteUser() {
 	// Mocking the PromoteUser method to return nil
-	suite.mockUserRepo.On("PromoteUser", "testuser").Return(nil)
+	suite.mockUserRepo.On("PromoteUser", mock.Anything, "testuser").Return(nil)
 
-	err := suite.service.PromoteUser("testuser")
+	err := suite.service.PromoteUser(context.Background(), "admin", "testuser")
 
 	suite.NoError(err)
 
@@ -145,9 +865,9 @@ func (suite *UserServiceTestSuite) TestPromoteUser() {
 // Test PromoteUser with an error
 func (suite *UserServiceTestSuite) TestPromoteUser_Error() {
 	// Mocking the PromoteUser method to return an error
-	suite.mockUserRepo.On("PromoteUser", "testuser").Return(errors.New("promotion failed"))
+	suite.mockUserRepo.On("PromoteUser", mock.Anything, "testuser").Return(errors.New("promotion failed"))
 
-	err := suite.service.PromoteUser("testuser")
+	err := suite.service.PromoteUser(context.Background(), "admin", "testuser")
 
 	suite.EqualError(err, "promotion failed")
 
@@ -155,6 +875,104 @@ func (suite *UserServiceTestSuite) TestPromoteUser_Error() {
 }
 
 // Run the test suite
+func (suite *UserServiceTestSuite) TestGetProfile() {
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Password: "hashedpassword123", Timezone: "Europe/Berlin"}, nil)
+
+	profile, err := suite.service.GetProfile(context.Background(), "testuser")
+
+	suite.NoError(err)
+	suite.Equal(&domain.UserProfile{Username: "testuser", Timezone: "Europe/Berlin"}, profile)
+}
+
+func (suite *UserServiceTestSuite) TestUpdateProfile_UnchangedEmailKeepsVerification() {
+	email := "Jane@Example.com"
+	locale := "de-DE"
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Email: "jane@example.com", EmailVerified: true}, nil)
+	suite.mockUserRepo.On("UpdateProfile", mock.Anything, "testuser", domain.ProfileUpdate{Locale: &locale}).Return(&domain.User{Username: "testuser", Email: "jane@example.com", EmailVerified: true, Locale: locale}, nil)
+
+	profile, err := suite.service.UpdateProfile(context.Background(), "testuser", domain.ProfileUpdate{Email: &email, Locale: &locale})
+
+	suite.NoError(err)
+	suite.True(profile.EmailVerified)
+	suite.Equal("de-DE", profile.Locale)
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserServiceTestSuite) TestUpdateProfile_NewEmailSendsVerification() {
+	mockMailer := new(mocks.MailerInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	suite.service.Mailer = mockMailer
+	suite.service.TokenGenerator = mockGenerator
+	email := " New@Example.com"
+
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Email: "jane@example.com"}, nil)
+	suite.mockUserRepo.On("UpdateProfile", mock.Anything, "testuser", mock.MatchedBy(func(update domain.ProfileUpdate) bool {
+		return *update.Email == "new@example.com"
+	})).Return(&domain.User{Username: "testuser", Email: "new@example.com"}, nil)
+	mockGenerator.On("GenerateSecret", 32).Return("token", nil)
+	mockGenerator.On("HashSecret", "token").Return("hashed-token")
+	suite.mockUserRepo.On("SetEmailVerification", mock.Anything, "testuser", "", "hashed-token", mock.AnythingOfType("time.Time")).Return(nil)
+	mockMailer.On("Send", mock.Anything, "new@example.com", "Confirm your email address", mock.AnythingOfType("string")).Return(nil)
+
+	profile, err := suite.service.UpdateProfile(context.Background(), "testuser", domain.ProfileUpdate{Email: &email})
+
+	suite.NoError(err)
+	suite.False(profile.EmailVerified)
+	mockMailer.AssertExpectations(suite.T())
+}
+
+func (suite *UserServiceTestSuite) TestUpdateProfile_VerifiedEmailChangeIsPending() {
+	mockMailer := new(mocks.MailerInterface)
+	mockGenerator := new(mocks.TokenGeneratorInterface)
+	suite.service.Mailer = mockMailer
+	suite.service.TokenGenerator = mockGenerator
+	email := " New@Example.com"
+
+	user := &domain.User{Username: "testuser", Email: "jane@example.com", EmailVerified: true}
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(user, nil)
+	suite.mockUserRepo.On("GetUserByEmail", mock.Anything, "new@example.com").Return(nil, domain.ErrUserNotFound)
+	suite.mockUserRepo.On("UpdateProfile", mock.Anything, "testuser", domain.ProfileUpdate{}).Return(user, nil)
+	mockGenerator.On("GenerateSecret", 32).Return("token", nil)
+	mockGenerator.On("HashSecret", "token").Return("hashed-token")
+	suite.mockUserRepo.On("SetEmailVerification", mock.Anything, "testuser", "new@example.com", "hashed-token", mock.AnythingOfType("time.Time")).Return(nil)
+	mockMailer.On("Send", mock.Anything, "new@example.com", "Confirm your email address", mock.AnythingOfType("string")).Return(nil)
+
+	profile, err := suite.service.UpdateProfile(context.Background(), "testuser", domain.ProfileUpdate{Email: &email})
+
+	// the old email stays verified so the user keeps access until the link is used
+	suite.NoError(err)
+	suite.Equal("jane@example.com", profile.Email)
+	suite.True(profile.EmailVerified)
+	suite.Equal("new@example.com", profile.PendingEmail)
+	mockMailer.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserServiceTestSuite) TestUpdateProfile_PendingEmailTaken() {
+	suite.service.Mailer = new(mocks.MailerInterface)
+	email := "john@example.com"
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Email: "jane@example.com", EmailVerified: true}, nil)
+	suite.mockUserRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(&domain.User{Username: "john", Email: "john@example.com"}, nil)
+
+	profile, err := suite.service.UpdateProfile(context.Background(), "testuser", domain.ProfileUpdate{Email: &email})
+
+	suite.Nil(profile)
+	suite.ErrorIs(err, domain.ErrEmailExists)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "UpdateProfile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *UserServiceTestSuite) TestUpdateProfile_EmailRequired() {
+	suite.service.RequireEmailVerification = true
+	email := ""
+	suite.mockUserRepo.On("GetUser", mock.Anything, "testuser").Return(&domain.User{Username: "testuser", Email: "jane@example.com"}, nil)
+
+	profile, err := suite.service.UpdateProfile(context.Background(), "testuser", domain.ProfileUpdate{Email: &email})
+
+	suite.Nil(profile)
+	suite.ErrorIs(err, domain.ErrEmailRequired)
+	suite.mockUserRepo.AssertNotCalled(suite.T(), "UpdateProfile", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestUserServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(UserServiceTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/infrastructure"
+	"github.com/stretchr/testify/suite"
+)
+
+type WorkersSuite struct {
+	suite.Suite
+}
+
+func (suite *WorkersSuite) TestEvery_RunsUntilCanceled() {
+	var workers infrastructure.Workers
+	var runs atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+
+	workers.Every(ctx, "test job", time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("errors are only logged")
+	})
+	suite.Eventually(func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	suite.NoError(workers.Wait(context.Background()))
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	suite.Equal(stopped, runs.Load())
+}
+
+func (suite *WorkersSuite) TestWait_GivesUp() {
+	var workers infrastructure.Workers
+	release := make(chan struct{})
+	defer close(release)
+	workers.Go(context.Background(), func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	suite.ErrorIs(workers.Wait(ctx), context.DeadlineExceeded)
+}
+
+func (suite *WorkersSuite) TestCheck_ReportsStoppedJobs() {
+	var workers infrastructure.Workers
+	ctx, cancel := context.WithCancel(context.Background())
+
+	workers.Every(ctx, "test job", time.Hour, func(ctx context.Context) error {
+		return nil
+	})
+	suite.NoError(workers.Check(context.Background()))
+
+	cancel()
+	suite.NoError(workers.Wait(context.Background()))
+	suite.EqualError(workers.Check(context.Background()), "test job stopped")
+}
+
+func TestWorkersSuite(t *testing.T) {
+	suite.Run(t, new(WorkersSuite))
+}
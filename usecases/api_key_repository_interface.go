@@ -0,0 +1,17 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+type APIKeyRepoInterface interface {
+	AddAPIKey(ctx context.Context, apiKey *domain.APIKey) (*domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, username string) error
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
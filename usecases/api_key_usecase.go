@@ -0,0 +1,119 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	// every api key starts with this so leaked keys are easy to spot
+	apiKeyPrefix = "tm_"
+	// random bytes in an api key
+	apiKeySize = 32
+	// characters of the key kept in clear text to tell keys apart
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+type APIKeyServiceInterface interface {
+	CreateAPIKey(ctx context.Context, username string, apiKey domain.APIKey) (string, *domain.APIKey, error)
+	GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, username string, id uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, *domain.User, error)
+}
+
+type APIKeyService struct {
+	APIKeyRepo     APIKeyRepoInterface
+	UserRepo       UserRepoInterface
+	TokenGenerator TokenGeneratorInterface
+	// same as UserService.RequireAdminTwoFactor, api keys of such admins carry no admin rights
+	RequireAdminTwoFactor bool
+	// same as UserService.RequireEmailVerification, api keys of unverified users are rejected
+	RequireEmailVerification bool
+	// optional, created and revoked keys are recorded when set
+	Audit AuditRepoInterface
+	// audit entries that can't be stored are logged here, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// create a new api key, the returned key is not stored and can't be shown again
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, username string, apiKey domain.APIKey) (string, *domain.APIKey, error) {
+	secret, err := s.TokenGenerator.GenerateSecret(apiKeySize)
+	if err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey.ID = uuid.New()
+	apiKey.Username = username
+	apiKey.Prefix = key[:apiKeyDisplayLength]
+	apiKey.KeyHash = s.TokenGenerator.HashSecret(key)
+	apiKey.CreatedAt = time.Now().UTC()
+	apiKey.LastUsedAt = nil
+	apiKey.RevokedAt = nil
+
+	newKey, err := s.APIKeyRepo.AddAPIKey(ctx, &apiKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditAPIKeyCreated, Target: newKey.ID.String(), Detail: "scopes: " + strings.Join(newKey.Scopes, ", ")})
+
+	return key, newKey, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, username string) ([]domain.APIKey, error) {
+	return s.APIKeyRepo.GetAPIKeys(ctx, username)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, username string, id uuid.UUID) error {
+	if err := s.APIKeyRepo.RevokeAPIKey(ctx, id, username); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditAPIKeyRevoked, Target: id.String()})
+	return nil
+}
+
+// look up the key and its owner, recording when the key was last used
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, *domain.User, error) {
+	apiKey, err := s.APIKeyRepo.GetAPIKeyByHash(ctx, s.TokenGenerator.HashSecret(key))
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	// the owner's current role applies, not the one at creation time
+	user, err := s.UserRepo.GetUser(ctx, apiKey.Username)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	if err := checkEmailVerified(s.RequireEmailVerification, user); err != nil {
+		return nil, nil, err
+	}
+
+	if user.IsAdmin && s.RequireAdminTwoFactor && !user.TwoFactorEnabled {
+		user.IsAdmin = false
+	}
+
+	if err := s.APIKeyRepo.UpdateLastUsed(ctx, apiKey.ID, time.Now().UTC()); err != nil {
+		return nil, nil, err
+	}
+
+	return apiKey, user, nil
+}
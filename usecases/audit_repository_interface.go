@@ -0,0 +1,14 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+// AuditRepoInterface is append only, entries can't be changed or removed
+type AuditRepoInterface interface {
+	AddEntry(ctx context.Context, entry *domain.AuditEntry) error
+	// matching entries, newest first
+	GetEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
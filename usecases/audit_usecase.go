@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+// entries returned by GetEntries when the filter has no limit
+const defaultAuditLimit = 100
+
+type AuditServiceInterface interface {
+	GetEntries(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+type AuditService struct {
+	AuditRepo AuditRepoInterface
+}
+
+func (s *AuditService) GetEntries(ctx context.Context, filter domain.AuditFilter) (_ []domain.AuditEntry, err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetEntries")
+	defer endSpan(span, &err)
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	return s.AuditRepo.GetEntries(ctx, filter)
+}
+
+// add entry to the audit log when auditing is on. The action has happened already,
+// so an entry that can't be stored is logged to logger, slog.Default() when nil, instead of failing it
+func recordAudit(ctx context.Context, logger *slog.Logger, repo AuditRepoInterface, entry domain.AuditEntry) {
+	if repo == nil {
+		return
+	}
+
+	entry.ID = uuid.New()
+	entry.Time = time.Now().UTC()
+	// the entry is written even when the request was canceled right after the action
+	if err := repo.AddEntry(context.WithoutCancel(ctx), &entry); err != nil {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.ErrorContext(ctx, "could not record audit entry", "action", entry.Action, "actor", entry.Actor, "target", entry.Target, "error", err)
+	}
+}
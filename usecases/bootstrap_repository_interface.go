@@ -0,0 +1,8 @@
+package usecases
+
+import "context"
+
+type BootstrapRepoInterface interface {
+	// ClaimFirstAdmin returns true for exactly one caller, ever
+	ClaimFirstAdmin(ctx context.Context, username string) (bool, error)
+}
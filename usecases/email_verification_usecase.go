@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+const (
+	emailVerificationTokenSize = 32
+	// how long a verification link can be used
+	EmailVerificationExpiry = 24 * time.Hour
+)
+
+// emails are stored lowercased so the unique index can't be bypassed by case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// AccountCheckerInterface is asked on every request authenticated with a JWT
+// whether the user may still use the api
+type AccountCheckerInterface interface {
+	CheckAccount(ctx context.Context, username string) error
+}
+
+// users with an unverified email are restricted until they confirm it, whether
+// they log in with a password or OIDC or use a token or API key they already have
+func checkEmailVerified(required bool, user *domain.User) error {
+	if required && user.Email != "" && !user.EmailVerified {
+		return domain.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// reject users whose email must be verified first, a pending email change
+// keeps the verified email in use
+func (s *UserService) CheckAccount(ctx context.Context, username string) error {
+	if !s.RequireEmailVerification {
+		return nil
+	}
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrInvalidJWT
+		}
+		return err
+	}
+	return checkEmailVerified(s.RequireEmailVerification, user)
+}
+
+// store a new verification token and mail the link to the user, to the
+// pending email instead of the current one when it is set
+func (s *UserService) sendEmailVerification(ctx context.Context, user *domain.User, pendingEmail string) error {
+	token, err := s.TokenGenerator.GenerateSecret(emailVerificationTokenSize)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().UTC().Add(EmailVerificationExpiry)
+	if err := s.UserRepo.SetEmailVerification(ctx, user.Username, pendingEmail, s.TokenGenerator.HashSecret(token), expiresAt); err != nil {
+		return err
+	}
+
+	to := user.Email
+	if pendingEmail != "" {
+		to = pendingEmail
+	}
+	link := s.VerificationURL + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hi %s,\n\nplease confirm your email address by opening this link within 24 hours:\n\n%s\n\nIf you didn't create an account you can ignore this email.\n", user.Username, link)
+	return s.Mailer.Send(ctx, to, "Confirm your email address", body)
+}
+
+// confirm an email with the token from the verification link
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserService.VerifyEmail")
+	defer endSpan(span, &err)
+
+	return s.UserRepo.VerifyEmail(ctx, s.TokenGenerator.HashSecret(token), time.Now().UTC())
+}
+
+// send a new verification link, for a pending email change when there is one,
+// unknown and already verified emails are silently ignored so the response
+// doesn't reveal which emails have accounts
+func (s *UserService) ResendEmailVerification(ctx context.Context, email string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserService.ResendEmailVerification")
+	defer endSpan(span, &err)
+
+	if s.Mailer == nil {
+		return domain.ErrEmailVerificationDisabled
+	}
+
+	user, err := s.UserRepo.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.PendingEmail != "" {
+		return s.sendEmailVerification(ctx, user, user.PendingEmail)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return s.sendEmailVerification(ctx, user, "")
+}
@@ -0,0 +1,17 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+type IdempotencyRepoInterface interface {
+	// stores record unless its user already has an unexpired record for the key,
+	// that one is returned instead, nil when record was stored
+	Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error)
+	// stores the response of the record
+	Complete(ctx context.Context, record *domain.IdempotencyRecord) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+type IdempotencyServiceInterface interface {
+	Begin(ctx context.Context, username string, key string, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, record *domain.IdempotencyRecord, status int, contentType string, headers map[string]string, body []byte) error
+	Release(ctx context.Context, record *domain.IdempotencyRecord) error
+}
+
+// the first response to a request with an idempotency key is kept for Window
+// and replayed for retries of the request with the same key by the same user
+type IdempotencyService struct {
+	IdempotencyRepo IdempotencyRepoInterface
+	Window          time.Duration
+	// how long a key stays reserved for a request that is still being handled, so
+	// a request that never finishes doesn't block its retries for the whole Window
+	Lease time.Duration
+}
+
+// start a request with an idempotency key. A completed record is the response
+// to replay, otherwise the key is reserved for this request until it's completed,
+// released or its Lease runs out
+func (s *IdempotencyService) Begin(ctx context.Context, username string, key string, requestHash string) (_ *domain.IdempotencyRecord, err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Begin")
+	defer endSpan(span, &err)
+
+	now := time.Now().UTC()
+	record := &domain.IdempotencyRecord{
+		ID:          uuid.New(),
+		Username:    username,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.Lease),
+	}
+	stored, err := s.IdempotencyRepo.Reserve(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return record, nil
+	}
+
+	// the same key for another request is most likely a client bug, replaying
+	// the response of the first request would hide it
+	if stored.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if stored.Status == 0 {
+		return nil, domain.ErrIdempotencyKeyInProgress
+	}
+	return stored, nil
+}
+
+// keep the response to the request of a reserved record for its retries
+func (s *IdempotencyService) Complete(ctx context.Context, record *domain.IdempotencyRecord, status int, contentType string, headers map[string]string, body []byte) (err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Complete")
+	defer endSpan(span, &err)
+
+	record.Status = status
+	record.ContentType = contentType
+	record.Headers = headers
+	record.Body = body
+	record.ExpiresAt = record.CreatedAt.Add(s.Window)
+	return s.IdempotencyRepo.Complete(ctx, record)
+}
+
+// give up a reserved key without keeping a response, a retry is handled as a new request
+func (s *IdempotencyService) Release(ctx context.Context, record *domain.IdempotencyRecord) (err error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Release")
+	defer endSpan(span, &err)
+
+	return s.IdempotencyRepo.Delete(ctx, record.ID)
+}
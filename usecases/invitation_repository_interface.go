@@ -0,0 +1,18 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+type InvitationRepoInterface interface {
+	AddInvitation(ctx context.Context, invitation *domain.Invitation) (*domain.Invitation, error)
+	GetInvitationByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error)
+	GetInvitations(ctx context.Context) ([]domain.Invitation, error)
+	DeleteInvitation(ctx context.Context, id uuid.UUID) error
+	UseInvitation(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) error
+	ReleaseInvitation(ctx context.Context, id uuid.UUID) error
+}
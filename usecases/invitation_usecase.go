@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	invitationTokenPrefix = "inv_"
+	invitationTokenSize   = 32
+	// used when an invitation is created without expires_at
+	DefaultInvitationExpiry = 7 * 24 * time.Hour
+)
+
+type InvitationServiceInterface interface {
+	CreateInvitation(ctx context.Context, createdBy string, invitation domain.Invitation) (string, *domain.Invitation, error)
+	GetInvitations(ctx context.Context) ([]domain.Invitation, error)
+	DeleteInvitation(ctx context.Context, id uuid.UUID) error
+}
+
+type InvitationService struct {
+	InvitationRepo InvitationRepoInterface
+	TokenGenerator TokenGeneratorInterface
+	// optional, created invitations are recorded when set
+	Audit AuditRepoInterface
+	// audit entries that can't be stored are logged here, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// create a new invitation, the returned token is not stored and can't be shown again
+func (s *InvitationService) CreateInvitation(ctx context.Context, createdBy string, invitation domain.Invitation) (string, *domain.Invitation, error) {
+	now := time.Now().UTC()
+	if invitation.ExpiresAt.IsZero() {
+		invitation.ExpiresAt = now.Add(DefaultInvitationExpiry)
+	} else if !invitation.ExpiresAt.After(now) {
+		return "", nil, domain.ErrExpiryInPast
+	}
+
+	secret, err := s.TokenGenerator.GenerateSecret(invitationTokenSize)
+	if err != nil {
+		return "", nil, err
+	}
+	token := invitationTokenPrefix + secret
+
+	invitation.ID = uuid.New()
+	invitation.Email = normalizeEmail(invitation.Email)
+	invitation.TokenHash = s.TokenGenerator.HashSecret(token)
+	invitation.CreatedBy = createdBy
+	invitation.CreatedAt = now
+	invitation.UsedAt = nil
+	invitation.UsedBy = ""
+
+	newInvitation, err := s.InvitationRepo.AddInvitation(ctx, &invitation)
+	if err != nil {
+		return "", nil, err
+	}
+
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: createdBy, Action: domain.AuditInvitationCreated, Target: newInvitation.ID.String(), Detail: invitationDetail(newInvitation)})
+
+	return token, newInvitation, nil
+}
+
+// who an invitation is for, without the token
+func invitationDetail(invitation *domain.Invitation) string {
+	var details []string
+	if invitation.Email != "" {
+		details = append(details, "email: "+invitation.Email)
+	}
+	if invitation.Username != "" {
+		details = append(details, "username: "+invitation.Username)
+	}
+	if invitation.IsAdmin {
+		details = append(details, "admin")
+	}
+	return strings.Join(details, ", ")
+}
+
+func (s *InvitationService) GetInvitations(ctx context.Context) ([]domain.Invitation, error) {
+	return s.InvitationRepo.GetInvitations(ctx)
+}
+
+func (s *InvitationService) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	return s.InvitationRepo.DeleteInvitation(ctx, id)
+}
+
+// check an invitation token for a registration and mark it used, the caller
+// must release it again if the registration fails
+func redeemInvitation(ctx context.Context, repo InvitationRepoInterface, tokenGenerator TokenGeneratorInterface, token string, username string, email string) (*domain.Invitation, error) {
+	invitation, err := repo.GetInvitationByHash(ctx, tokenGenerator.HashSecret(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvitationNotFound) {
+			return nil, domain.ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	if invitation.UsedAt != nil || !time.Now().Before(invitation.ExpiresAt) {
+		return nil, domain.ErrInvalidInvitation
+	}
+	if invitation.Username != "" && invitation.Username != username {
+		return nil, domain.ErrInvitationForOtherUser
+	}
+	if invitation.Email != "" && normalizeEmail(invitation.Email) != email {
+		return nil, domain.ErrInvitationForOtherEmail
+	}
+
+	// only one of concurrent registrations can use the invitation
+	if err := repo.UseInvitation(ctx, invitation.ID, username, time.Now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrInvitationNotFound) {
+			return nil, domain.ErrInvalidInvitation
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
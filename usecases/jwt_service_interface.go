@@ -6,4 +6,6 @@ type JwtServiceInterface interface {
 	GenerateToken(username string, isAdmin bool) (string, error)
 	ValidateToken(token string) (*jwt.Token, error)
 	ValidateAdmin(token *jwt.Token) bool
+	GenerateChallengeToken(username string) (string, error)
+	ValidateChallengeToken(token string) (string, error)
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+type LoginAttemptRepoInterface interface {
+	GetAttempt(ctx context.Context, key string) (*domain.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, window time.Duration) (*domain.LoginAttempt, error)
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
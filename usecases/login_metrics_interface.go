@@ -0,0 +1,16 @@
+package usecases
+
+// results of password logins for LoginMetricsInterface
+const (
+	LoginSucceeded          = "success"
+	LoginTwoFactorRequired  = "two_factor_required"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginThrottled          = "throttled"
+	// refused for another reason, e.g. an unverified email
+	LoginRejected = "rejected"
+	LoginError    = "error"
+)
+
+type LoginMetricsInterface interface {
+	ObserveLogin(result string)
+}
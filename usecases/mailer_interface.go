@@ -0,0 +1,7 @@
+package usecases
+
+import "context"
+
+type MailerInterface interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
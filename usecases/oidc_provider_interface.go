@@ -0,0 +1,12 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+type OIDCProviderInterface interface {
+	AuthCodeURL(state string, codeVerifier string) string
+	Exchange(ctx context.Context, code string, codeVerifier string) (*domain.OIDCIdentity, error)
+}
@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+// random bytes in the oidc state and pkce code verifier
+const oidcSecretSize = 32
+
+type OIDCServiceInterface interface {
+	StartLogin() (*domain.OIDCAuthRequest, error)
+	FinishLogin(ctx context.Context, code string, codeVerifier string) (*domain.LoginResult, error)
+}
+
+type OIDCService struct {
+	Provider       OIDCProviderInterface
+	UserRepo       UserRepoInterface
+	JwtService     JwtServiceInterface
+	TokenGenerator TokenGeneratorInterface
+	// create users on their first oidc login, otherwise only linked users can sign in
+	AutoProvision bool
+	// link an identity on its first login to the user with the same verified email
+	LinkByEmail bool
+	// same as UserService.RequireAdminTwoFactor
+	RequireAdminTwoFactor bool
+	// same as UserService.RequireEmailVerification
+	RequireEmailVerification bool
+	// optional, logins and provisioned users are recorded when set
+	Audit AuditRepoInterface
+	// audit entries that can't be stored are logged here, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// build the authorization url with a fresh state and pkce code verifier
+func (s *OIDCService) StartLogin() (*domain.OIDCAuthRequest, error) {
+	state, err := s.TokenGenerator.GenerateSecret(oidcSecretSize)
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := s.TokenGenerator.GenerateSecret(oidcSecretSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.OIDCAuthRequest{
+		URL:          s.Provider.AuthCodeURL(state, codeVerifier),
+		State:        state,
+		CodeVerifier: codeVerifier,
+	}, nil
+}
+
+// exchange the authorization code and log in the linked or newly provisioned user
+func (s *OIDCService) FinishLogin(ctx context.Context, code string, codeVerifier string) (*domain.LoginResult, error) {
+	identity, err := s.Provider.Exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, domain.ErrInvalidAuthCode
+	}
+
+	user, err := s.UserRepo.GetUserByOIDCSubject(ctx, identity.Issuer, identity.Subject)
+	if errors.Is(err, domain.ErrUserNotFound) && s.LinkByEmail {
+		user, err = s.linkByEmail(ctx, identity)
+	}
+	if errors.Is(err, domain.ErrUserNotFound) {
+		if !s.AutoProvision {
+			recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: identity.PreferredUsername, Action: domain.AuditLoginFailed, Detail: "oidc: no linked user"})
+			return nil, domain.ErrNoLinkedUser
+		}
+		user, err = s.provisionUser(ctx, identity)
+		if err == nil {
+			recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditUserRegistered, Target: user.Username, Detail: "oidc"})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEmailVerified(s.RequireEmailVerification, user); err != nil {
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditLoginFailed, Detail: "oidc: email not verified"})
+		return nil, err
+	}
+
+	result, err := issueLoginResult(s.JwtService, user, s.RequireAdminTwoFactor)
+	// a login waiting for its second factor is audited by UserService.VerifyTwoFactorLogin
+	if err == nil && !result.TwoFactorRequired {
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditLoginSucceeded, Detail: "oidc"})
+	}
+	return result, err
+}
+
+// link the identity to the existing user with its email, both the provider and
+// this server must have verified the email so nobody can claim another's account
+func (s *OIDCService) linkByEmail(ctx context.Context, identity *domain.OIDCIdentity) (*domain.User, error) {
+	email := normalizeEmail(identity.Email)
+	if !identity.EmailVerified || email == "" {
+		return nil, domain.ErrUserNotFound
+	}
+
+	user, err := s.UserRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified || user.OIDCSubject != "" {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err := s.UserRepo.LinkOIDCIdentity(ctx, user.Username, identity.Issuer, identity.Subject); err != nil {
+		return nil, err
+	}
+	user.OIDCIssuer = identity.Issuer
+	user.OIDCSubject = identity.Subject
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditOIDCLinked, Target: user.Username, Detail: identity.Issuer})
+	return user, nil
+}
+
+// create a user without a password for a new oidc identity
+func (s *OIDCService) provisionUser(ctx context.Context, identity *domain.OIDCIdentity) (*domain.User, error) {
+	username := identity.PreferredUsername
+	if username == "" && identity.EmailVerified {
+		username = identity.Email
+	}
+	if username == "" {
+		return nil, domain.ErrIdentityWithoutUsername
+	}
+
+	// an existing local account with the same name is never taken over
+	user := &domain.User{
+		ID:          uuid.New(),
+		Username:    username,
+		OIDCIssuer:  identity.Issuer,
+		OIDCSubject: identity.Subject,
+	}
+	// the provider has verified the email already
+	if identity.EmailVerified {
+		user.Email = normalizeEmail(identity.Email)
+		user.EmailVerified = user.Email != ""
+	}
+	return s.UserRepo.RegisterUser(ctx, user)
+}
@@ -3,4 +3,5 @@ package usecases
 type PasswordServiceInterface interface {
 	HashPassword(password string) (string, error)
 	ComparePassword(existingPassword string, userPassword string) bool
+	NeedsRehash(existingPassword string) bool
 }
\ No newline at end of file
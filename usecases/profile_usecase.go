@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+// the profile of the logged in user
+func (s *UserService) GetProfile(ctx context.Context, username string) (_ *domain.UserProfile, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.GetProfile")
+	defer endSpan(span, &err)
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return user.Profile(), nil
+}
+
+// change profile fields, nil fields are kept and empty ones are cleared
+func (s *UserService) UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (_ *domain.UserProfile, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.UpdateProfile")
+	defer endSpan(span, &err)
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	var pendingEmail string
+	if update.Email != nil {
+		email := normalizeEmail(*update.Email)
+		if email == user.Email {
+			// unchanged, keep the verification state
+			update.Email = nil
+		} else if email == "" && s.RequireEmailVerification {
+			return nil, domain.ErrEmailRequired
+		} else if email != "" && user.EmailVerified && s.Mailer != nil {
+			// a verified email stays in use until the new one is confirmed, so a
+			// typo doesn't lock the user out of their account
+			pendingEmail = email
+			update.Email = nil
+		} else {
+			update.Email = &email
+		}
+	}
+
+	if pendingEmail != "" {
+		if _, err := s.UserRepo.GetUserByEmail(ctx, pendingEmail); err == nil {
+			return nil, domain.ErrEmailExists
+		} else if !errors.Is(err, domain.ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	updatedUser, err := s.UserRepo.UpdateProfile(ctx, username, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if pendingEmail != "" {
+		// the change only takes effect through the link, so the user has to try again
+		if err := s.sendEmailVerification(ctx, updatedUser, pendingEmail); err != nil {
+			return nil, err
+		}
+		updatedUser.PendingEmail = pendingEmail
+	} else if update.Email != nil && updatedUser.Email != "" && s.Mailer != nil {
+		// the user can ask for a new link if sending fails, the profile is already saved
+		if err := s.sendEmailVerification(ctx, updatedUser, ""); err != nil {
+			s.logger().WarnContext(ctx, "could not send verification email", "username", username, "error", err)
+		}
+	}
+
+	return updatedUser.Profile(), nil
+}
@@ -0,0 +1,244 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+// aborts the transaction of an atomic batch when one of its operations failed
+var errBulkOperationFailed = errors.New("an operation of the batch failed")
+
+// an operation of a bulk request that passed the checks
+type bulkOperation struct {
+	result *domain.BulkTaskResult
+	write  domain.TaskWrite
+	// the task before the write, zero for new tasks
+	before domain.Task
+}
+
+// apply a batch of task changes with one bulk write. Every operation is checked
+// against the tasks as they were when the batch started and fails when its task
+// is gone by the time it is written. Without Atomic the operations that pass are
+// applied and the others reported, with Atomic the batch runs in a transaction
+// and nothing is applied unless every operation is
+func (s *TaskService) ApplyBulkOperations(ctx context.Context, username string, request domain.BulkTaskRequest) (_ *domain.BulkTaskResponse, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ApplyBulkOperations")
+	defer endSpan(span, &err)
+
+	var results []domain.BulkTaskResult
+	var applied []bulkOperation
+	apply := func(ctx context.Context) error {
+		var err error
+		results, applied, err = s.applyBulkOperations(ctx, username, request)
+		return err
+	}
+
+	if request.Atomic {
+		err := s.TaskRepo.WithTransaction(ctx, apply)
+		if errors.Is(err, errBulkOperationFailed) {
+			applied = nil
+			for i := range results {
+				if results[i].Status != domain.BulkFailed {
+					results[i].Status = domain.BulkSkipped
+				}
+				// the tasks to create never were
+				if results[i].Op == domain.BulkCreate {
+					results[i].ID = uuid.Nil
+				}
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	} else if err := apply(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, operation := range applied {
+		s.recordBulkOperation(ctx, username, operation)
+	}
+
+	response := &domain.BulkTaskResponse{Atomic: request.Atomic, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case domain.BulkApplied:
+			response.Applied++
+		case domain.BulkFailed:
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// check and write the operations, the results are returned with errBulkOperationFailed
+// when an operation of an atomic batch failed
+func (s *TaskService) applyBulkOperations(ctx context.Context, username string, request domain.BulkTaskRequest) ([]domain.BulkTaskResult, []bulkOperation, error) {
+	results := make([]domain.BulkTaskResult, len(request.Operations))
+	ids := make([]uuid.UUID, 0, len(request.Operations))
+	// the index of the operation changing each existing task
+	changedBy := make(map[uuid.UUID]int)
+	for i, operation := range request.Operations {
+		results[i] = domain.BulkTaskResult{Index: i, Op: operation.Op, ID: operation.ID}
+		if operation.Op == domain.BulkCreate {
+			results[i].ID = uuid.Nil
+			continue
+		}
+		if _, ok := changedBy[operation.ID]; ok {
+			s.failBulkOperation(ctx, &results[i], domain.ErrTaskInBatchTwice)
+			continue
+		}
+		changedBy[operation.ID] = i
+		ids = append(ids, operation.ID)
+	}
+
+	tasks := make(map[uuid.UUID]domain.Task, len(ids))
+	if len(ids) > 0 {
+		found, err := s.TaskRepo.GetTasksByIDs(ctx, ids)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, task := range found {
+			tasks[task.ID] = task
+		}
+	}
+
+	now := time.Now().UTC()
+	operations := make([]bulkOperation, 0, len(request.Operations))
+	for i, operation := range request.Operations {
+		if results[i].Status == domain.BulkFailed {
+			continue
+		}
+		prepared, err := prepareBulkOperation(operation, tasks, username, now)
+		if err != nil {
+			s.failBulkOperation(ctx, &results[i], err)
+			continue
+		}
+		prepared.result = &results[i]
+		prepared.result.ID = prepared.write.Task.ID
+		operations = append(operations, prepared)
+	}
+	if request.Atomic && len(operations) < len(request.Operations) {
+		return results, nil, errBulkOperationFailed
+	}
+	if len(operations) == 0 {
+		return results, nil, nil
+	}
+
+	writes := make([]domain.TaskWrite, len(operations))
+	for i, operation := range operations {
+		writes[i] = operation.write
+	}
+	writeErrs, err := s.TaskRepo.BulkWriteTasks(ctx, writes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied := make([]bulkOperation, 0, len(operations))
+	for i, operation := range operations {
+		if writeErrs[i] != nil {
+			s.failBulkOperation(ctx, operation.result, writeErrs[i])
+			continue
+		}
+		operation.result.Status = domain.BulkApplied
+		applied = append(applied, operation)
+	}
+	if request.Atomic && len(applied) < len(operations) {
+		return results, nil, errBulkOperationFailed
+	}
+	return results, applied, nil
+}
+
+// check an operation the way the single task methods do and work out the write
+func prepareBulkOperation(operation domain.BulkTaskOperation, tasks map[uuid.UUID]domain.Task, username string, now time.Time) (bulkOperation, error) {
+	if (operation.Op == domain.BulkCreate || operation.Op == domain.BulkUpdate) && operation.Task == nil {
+		return bulkOperation{}, &domain.ValidationError{Detail: "task is required", Params: []domain.InvalidParam{{Name: "task", Reason: "is required"}}}
+	}
+
+	if operation.Op == domain.BulkCreate {
+		task := *operation.Task
+		if !domain.IsTaskStatus(task.Status) {
+			return bulkOperation{}, domain.ErrInvalidTaskStatus
+		}
+		if err := checkDueDate(task.DueDate); err != nil {
+			return bulkOperation{}, err
+		}
+		task.ID = uuid.New()
+		task.DeletedAt = nil
+		task.DeletedBy = ""
+		return bulkOperation{write: domain.TaskWrite{Op: domain.BulkCreate, Task: task}}, nil
+	}
+
+	before, ok := tasks[operation.ID]
+	if !ok {
+		return bulkOperation{}, fmt.Errorf("task %s: %w", operation.ID, domain.ErrTaskNotFound)
+	}
+	after := before
+	switch operation.Op {
+	case domain.BulkUpdate:
+		after = *operation.Task
+		after.ID = before.ID
+		after.DeletedAt = nil
+		after.DeletedBy = ""
+		if !domain.IsTaskStatus(after.Status) {
+			return bulkOperation{}, domain.ErrInvalidTaskStatus
+		}
+		// like single updates, overdue tasks can be changed as long as the due date stays
+		if !before.DueDate.Equal(after.DueDate) {
+			if err := checkDueDate(after.DueDate); err != nil {
+				return bulkOperation{}, err
+			}
+		}
+	case domain.BulkSetStatus:
+		if !domain.IsTaskStatus(operation.Status) {
+			return bulkOperation{}, domain.ErrInvalidTaskStatus
+		}
+		after.Status = operation.Status
+	case domain.BulkDelete:
+		after.DeletedAt = &now
+		after.DeletedBy = username
+	default:
+		return bulkOperation{}, fmt.Errorf("unknown bulk operation %q: %w", operation.Op, domain.ErrInvalidRequest)
+	}
+	return bulkOperation{write: domain.TaskWrite{Op: operation.Op, Task: after}, before: before}, nil
+}
+
+// describe why an operation failed the way problem responses do, only domain
+// errors are shown to the client
+func (s *TaskService) failBulkOperation(ctx context.Context, result *domain.BulkTaskResult, err error) {
+	result.Status = domain.BulkFailed
+	var validationErr *domain.ValidationError
+	var domainErr *domain.Error
+	switch {
+	case errors.As(err, &validationErr):
+		result.Error = validationErr.Error()
+		result.InvalidParams = validationErr.Params
+	case errors.As(err, &domainErr):
+		result.Error = domainErr.Message
+	default:
+		result.Error = "internal error"
+		s.logger().ErrorContext(ctx, "bulk operation failed", "index", result.Index, "op", result.Op, "error", err)
+	}
+}
+
+// keep an applied operation in the task history and audit log like single changes
+func (s *TaskService) recordBulkOperation(ctx context.Context, username string, operation bulkOperation) {
+	after := operation.write.Task
+	entry := domain.AuditEntry{Actor: username, Target: after.ID.String(), After: &after}
+	var action string
+	switch operation.write.Op {
+	case domain.BulkCreate:
+		action, entry.Action = domain.TaskCreated, domain.AuditTaskCreated
+	case domain.BulkDelete:
+		action, entry.Action = domain.TaskDeleted, domain.AuditTaskDeleted
+		entry.Before = &operation.before
+	default:
+		action, entry.Action = domain.TaskUpdated, domain.AuditTaskUpdated
+		entry.Before = &operation.before
+	}
+	s.recordHistory(ctx, username, action, operation.before, after)
+	recordAudit(ctx, s.Logger, s.Audit, entry)
+}
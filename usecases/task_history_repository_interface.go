@@ -0,0 +1,14 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+type TaskHistoryRepoInterface interface {
+	AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error
+	// the history of a task, newest first
+	GetHistory(ctx context.Context, taskID uuid.UUID) ([]domain.TaskHistoryEntry, error)
+}
@@ -0,0 +1,39 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+// the changes made to a task, newest first
+func (s *TaskService) GetTaskHistory(ctx context.Context, id uuid.UUID) (_ []domain.TaskHistoryEntry, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTaskHistory")
+	defer endSpan(span, &err)
+
+	if _, err := s.TaskRepo.GetTaskById(ctx, id); err != nil {
+		return nil, err
+	}
+	if s.History == nil {
+		return []domain.TaskHistoryEntry{}, nil
+	}
+	return s.History.GetHistory(ctx, id)
+}
+
+// add the changes of a task to its history when history is kept, best effort like recordAudit
+func (s *TaskService) recordHistory(ctx context.Context, username string, action string, before domain.Task, after domain.Task) {
+	if s.History == nil {
+		return
+	}
+
+	changes := before.Changes(after)
+	if len(changes) == 0 {
+		return
+	}
+	entry := &domain.TaskHistoryEntry{ID: uuid.New(), TaskID: after.ID, Time: time.Now().UTC(), Actor: username, Action: action, Changes: changes}
+	if err := s.History.AddEntry(context.WithoutCancel(ctx), entry); err != nil {
+		s.logger().ErrorContext(ctx, "could not record task history", "task", after.ID, "action", action, "error", err)
+	}
+}
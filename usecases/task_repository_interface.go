@@ -1,14 +1,34 @@
 package usecases
 
 import (
+	"context"
+	"time"
+
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/google/uuid"
 )
 
 type TaskRepoInterface interface {
-	GetTasks() ([]domain.Task, error)
-	GetTaskById(id uuid.UUID) (*domain.Task, error)
-	UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error
-	DeleteTask(id uuid.UUID) error
-	AddTask(task domain.Task) (*domain.Task, error)
+	GetTasks(ctx context.Context) ([]domain.Task, error)
+	GetTaskById(ctx context.Context, id uuid.UUID) (*domain.Task, error)
+	// returns the task as it was before the update
+	UpdateTaskByID(ctx context.Context, id uuid.UUID, updatedTask domain.Task) (*domain.Task, error)
+	// moves the task to the trash and returns it as it was before
+	DeleteTask(ctx context.Context, id uuid.UUID, deletedBy string, deletedAt time.Time) (*domain.Task, error)
+	AddTask(ctx context.Context, task domain.Task) (*domain.Task, error)
+	// tasks in the trash, most recently deleted first
+	GetDeletedTasks(ctx context.Context) ([]domain.Task, error)
+	// takes the task out of the trash and returns it as it was in the trash
+	RestoreTask(ctx context.Context, id uuid.UUID) (*domain.Task, error)
+	// removes the tasks deleted before deletedBefore for good
+	PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// the tasks with these ids that aren't in the trash, in no particular order
+	GetTasksByIDs(ctx context.Context, ids []uuid.UUID) ([]domain.Task, error)
+	// applies the writes in one round trip, the error of each write is at its index
+	// and nil when it was applied. Writes to tasks that are in the trash or gone
+	// fail with ErrTaskNotFound
+	BulkWriteTasks(ctx context.Context, writes []domain.TaskWrite) ([]error, error)
+	// runs fn in a transaction that is committed when fn returns nil, fn may be
+	// called again when the transaction hits a transient error
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
\ No newline at end of file
@@ -0,0 +1,57 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"github.com/google/uuid"
+)
+
+// tasks in the trash, most recently deleted first
+func (s *TaskService) GetDeletedTasks(ctx context.Context) (_ []domain.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetDeletedTasks")
+	defer endSpan(span, &err)
+
+	return s.TaskRepo.GetDeletedTasks(ctx)
+}
+
+// take a task out of the trash as it was when it was deleted
+func (s *TaskService) RestoreTask(ctx context.Context, username string, id uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.RestoreTask")
+	defer endSpan(span, &err)
+
+	deletedTask, err := s.TaskRepo.RestoreTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	task := *deletedTask
+	task.DeletedAt = nil
+	task.DeletedBy = ""
+	s.recordHistory(ctx, username, domain.TaskRestored, *deletedTask, task)
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditTaskRestored, Target: id.String(), Before: deletedTask, After: &task})
+	return nil
+}
+
+// remove the tasks that have been in the trash longer than TrashRetention, run periodically
+func (s *TaskService) PurgeDeletedTasks(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.PurgeDeletedTasks")
+	defer endSpan(span, &err)
+
+	if s.TrashRetention <= 0 {
+		return 0, nil
+	}
+
+	deletedBefore := time.Now().UTC().Add(-s.TrashRetention)
+	purged, err := s.TaskRepo.PurgeDeletedTasks(ctx, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Action: domain.AuditTaskPurged, Detail: fmt.Sprintf("%d tasks deleted before %s", purged, deletedBefore.Format(time.RFC3339))})
+	}
+	return purged, nil
+}
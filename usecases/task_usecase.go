@@ -1,69 +1,158 @@
 package usecases
 
 import (
-	"errors"
-	"strings"
+	"context"
+	"log/slog"
+	"time"
+
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/google/uuid"
 )
 
+// due dates may be this far in the past, so a date without a time set to today
+// is accepted in every time zone
+const dueDateGrace = 24 * time.Hour
+
+// reject due dates that are already over
+func checkDueDate(dueDate time.Time) error {
+	if dueDate.Before(time.Now().Add(-dueDateGrace)) {
+		return &domain.ValidationError{
+			Detail: "due date is in the past",
+			Params: []domain.InvalidParam{{Name: "due_date", Reason: "must not be in the past", Rule: domain.RuleNotPast}},
+		}
+	}
+	return nil
+}
+
 type TaskServiceInterface interface {
-	GetTasks() ([]domain.Task, error)
-	GetTaskById(id uuid.UUID) (*domain.Task, error)
-	UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error
-	DeleteTask(id uuid.UUID) error
-	AddTask(task domain.Task) (*domain.Task, error)
+	GetTasks(ctx context.Context) ([]domain.Task, error)
+	GetTaskById(ctx context.Context, id uuid.UUID) (*domain.Task, error)
+	UpdateTaskByID(ctx context.Context, username string, id uuid.UUID, updatedTask domain.Task) error
+	DeleteTask(ctx context.Context, username string, id uuid.UUID) error
+	AddTask(ctx context.Context, username string, task domain.Task) (*domain.Task, error)
+	GetTaskHistory(ctx context.Context, id uuid.UUID) ([]domain.TaskHistoryEntry, error)
+	GetDeletedTasks(ctx context.Context) ([]domain.Task, error)
+	RestoreTask(ctx context.Context, username string, id uuid.UUID) error
+	ApplyBulkOperations(ctx context.Context, username string, request domain.BulkTaskRequest) (*domain.BulkTaskResponse, error)
 }
 
+// changes to tasks are made on behalf of username, the caller they are audited under
 type TaskService struct {
 	TaskRepo TaskRepoInterface
+	// optional, changes are recorded with snapshots of the task when set
+	Audit AuditRepoInterface
+	// optional, the changed fields of every task are kept when set
+	History TaskHistoryRepoInterface
+	// how long deleted tasks stay in the trash, forever when zero
+	TrashRetention time.Duration
+	// audit and history entries that can't be stored are logged here, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+func (s *TaskService) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
 }
 
 
-func (s *TaskService) GetTasks() ([]domain.Task, error) {
-	tasks, err := s.TaskRepo.GetTasks()
+func (s *TaskService) GetTasks(ctx context.Context) (_ []domain.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTasks")
+	defer endSpan(span, &err)
+
+	tasks, err := s.TaskRepo.GetTasks(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-func (s *TaskService) GetTaskById(id uuid.UUID) (*domain.Task, error) {
-	task, err := s.TaskRepo.GetTaskById(id)
+func (s *TaskService) GetTaskById(ctx context.Context, id uuid.UUID) (_ *domain.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTaskById")
+	defer endSpan(span, &err)
+
+	task, err := s.TaskRepo.GetTaskById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return task, nil
 }
 
-func (s *TaskService) UpdateTaskByID(id uuid.UUID, updatedTask domain.Task) error {
-	if strings.ToLower(updatedTask.Status) != "in progress" && strings.ToLower(updatedTask.Status) != "completed" && strings.ToLower(updatedTask.Status) != "pending" {
-		return errors.New("status error")
+func (s *TaskService) UpdateTaskByID(ctx context.Context, username string, id uuid.UUID, updatedTask domain.Task) (err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.UpdateTaskByID")
+	defer endSpan(span, &err)
+
+	if !domain.IsTaskStatus(updatedTask.Status) {
+		return domain.ErrInvalidTaskStatus
 	}
-	
-	err := s.TaskRepo.UpdateTaskByID(id, updatedTask)
+
+	// tasks that are overdue can still be updated as long as the due date stays
+	if dueDateErr := checkDueDate(updatedTask.DueDate); dueDateErr != nil {
+		current, err := s.TaskRepo.GetTaskById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !current.DueDate.Equal(updatedTask.DueDate) {
+			return dueDateErr
+		}
+	}
+
+	// only restoring takes a task out of the trash, an update can't move it there either
+	updatedTask.DeletedAt = nil
+	updatedTask.DeletedBy = ""
+
+	// the changes are taken from the task as the update found it, not as it was read above
+	task, err := s.TaskRepo.UpdateTaskByID(ctx, id, updatedTask)
 	if err != nil {
 		return err
 	}
+
+	updatedTask.ID = id
+	s.recordHistory(ctx, username, domain.TaskUpdated, *task, updatedTask)
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditTaskUpdated, Target: id.String(), Before: task, After: &updatedTask})
 	return nil
 }
 
-func (s *TaskService) DeleteTask(id uuid.UUID) error {
-	err := s.TaskRepo.DeleteTask(id)
+func (s *TaskService) DeleteTask(ctx context.Context, username string, id uuid.UUID) (err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.DeleteTask")
+	defer endSpan(span, &err)
+
+	// tasks go to the trash, they can be restored until they are purged
+	deletedAt := time.Now().UTC()
+	task, err := s.TaskRepo.DeleteTask(ctx, id, username, deletedAt)
 	if err != nil {
 		return err
 	}
+
+	deletedTask := *task
+	deletedTask.DeletedAt = &deletedAt
+	deletedTask.DeletedBy = username
+	s.recordHistory(ctx, username, domain.TaskDeleted, *task, deletedTask)
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditTaskDeleted, Target: id.String(), Before: task, After: &deletedTask})
 	return nil
 }
 
-func (s *TaskService) AddTask(task domain.Task) (*domain.Task, error) {
-	if strings.ToLower(task.Status) != "in progress" && strings.ToLower(task.Status) != "completed" && strings.ToLower(task.Status) != "pending" {
-		return nil, errors.New("status error")
+func (s *TaskService) AddTask(ctx context.Context, username string, task domain.Task) (_ *domain.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.AddTask")
+	defer endSpan(span, &err)
+
+	if !domain.IsTaskStatus(task.Status) {
+		return nil, domain.ErrInvalidTaskStatus
+	}
+	if err := checkDueDate(task.DueDate); err != nil {
+		return nil, err
 	}
 	task.ID = uuid.New()
-	newTask, err := s.TaskRepo.AddTask(task)
+	// new tasks never start in the trash
+	task.DeletedAt = nil
+	task.DeletedBy = ""
+	newTask, err := s.TaskRepo.AddTask(ctx, task)
 	if err != nil {
 		return nil, err
 	}
+
+	s.recordHistory(ctx, username, domain.TaskCreated, domain.Task{}, *newTask)
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditTaskCreated, Target: newTask.ID.String(), After: newTask})
 	return newTask, nil
 }
\ No newline at end of file
@@ -0,0 +1,6 @@
+package usecases
+
+type TokenGeneratorInterface interface {
+	GenerateSecret(size int) (string, error)
+	HashSecret(secret string) string
+}
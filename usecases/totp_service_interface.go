@@ -0,0 +1,9 @@
+package usecases
+
+type TotpServiceInterface interface {
+	GenerateSecret() (string, error)
+	ProvisioningURI(username string, secret string) string
+	// returns the time step of a valid code, only steps after lastCounter are accepted
+	ValidateCode(secret string, code string, lastCounter int64) (int64, bool)
+	GenerateRecoveryCodes(count int) ([]string, error)
+}
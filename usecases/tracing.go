@@ -0,0 +1,25 @@
+package usecases
+
+import (
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spans of the use case methods, the repository calls they make become their children.
+// They go to the global tracer provider, nowhere until one is installed
+var tracer = otel.Tracer("github.com/abe16s/Go-Backend-Learning-path/task_manager/usecases")
+
+// end the span of a use case method with the error it returns, deferred with a pointer
+// to the named result. Every error is recorded, only internal ones mark the span as
+// failed so that rejected requests like a missing task don't look like outages
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		if domain.KindOf(*err) == domain.KindInternal {
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+	span.End()
+}
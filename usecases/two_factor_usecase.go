@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
+
+// number of recovery codes handed out when two-factor authentication is enabled
+const recoveryCodeCount = 10
+
+// second step of a login, exchanges a challenge token and a totp or recovery code for a jwt
+func (s *UserService) VerifyTwoFactorLogin(ctx context.Context, login domain.TwoFactorLogin, clientIP string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.VerifyTwoFactorLogin")
+	defer endSpan(span, &err)
+
+	username, err := s.JwtService.ValidateChallengeToken(login.ChallengeToken)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := s.verifyTwoFactorLogin(ctx, username, login.Code, clientIP)
+	if err != nil {
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditLoginFailed, ClientIP: clientIP, Detail: "second factor: " + loginResult(nil, err)})
+		return "", err
+	}
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditLoginSucceeded, ClientIP: clientIP, Detail: "second factor"})
+	return token, nil
+}
+
+func (s *UserService) verifyTwoFactorLogin(ctx context.Context, username string, code string, clientIP string) (string, error) {
+	keys := loginAttemptKeys(username, clientIP)
+	if err := s.checkLockout(ctx, keys); err != nil {
+		return "", err
+	}
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	if !user.TwoFactorEnabled {
+		return "", domain.ErrTwoFactorNotEnabled
+	}
+
+	valid, err := s.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return "", err
+	}
+	if !valid {
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return "", err
+		}
+		return "", domain.ErrInvalidTwoFactorCode
+	}
+
+	if s.LoginAttempts != nil {
+		if err := s.LoginAttempts.Reset(ctx, usernameAttemptKey(user.Username)); err != nil {
+			return "", err
+		}
+	}
+
+	jwtToken, err := s.JwtService.GenerateToken(user.Username, user.IsAdmin)
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+
+	return jwtToken, nil
+}
+
+// start enrollment by generating a new secret, not active until confirmed with a code
+func (s *UserService) EnrollTwoFactor(ctx context.Context, username string) (_ *domain.TwoFactorEnrollment, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.EnrollTwoFactor")
+	defer endSpan(span, &err)
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	secret, err := s.TotpService.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UserRepo.UpdateTwoFactor(ctx, username, secret, false, nil); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    s.TotpService.ProvisioningURI(username, secret),
+	}, nil
+}
+
+// finish enrollment, returns the recovery codes which are only shown this once
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, username string, code string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.ConfirmTwoFactor")
+	defer endSpan(span, &err)
+
+	user, err := s.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrTwoFactorNotStarted
+	}
+
+	counter, valid := s.TotpService.ValidateCode(user.TOTPSecret, code, user.TOTPLastCounter)
+	if !valid {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	recoveryCodes, err := s.TotpService.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		hashedCodes[i], err = s.PasswordService.HashPassword(recoveryCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.UserRepo.UpdateTwoFactor(ctx, username, user.TOTPSecret, true, hashedCodes); err != nil {
+		return nil, err
+	}
+	// the code confirming the enrollment can't be used to sign in
+	if err := s.UserRepo.UseTOTPCounter(ctx, username, counter); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// accept a totp code once or consume one of the recovery codes
+func (s *UserService) checkSecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	if counter, valid := s.TotpService.ValidateCode(user.TOTPSecret, code, user.TOTPLastCounter); valid {
+		err := s.UserRepo.UseTOTPCounter(ctx, user.Username, counter)
+		// another login used the code first
+		if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	for i, hashedCode := range user.RecoveryCodes {
+		if s.PasswordService.ComparePassword(hashedCode, code) {
+			remaining := append(append([]string{}, user.RecoveryCodes[:i]...), user.RecoveryCodes[i+1:]...)
+			if err := s.UserRepo.UpdateRecoveryCodes(ctx, user.Username, remaining); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -1,10 +1,30 @@
 package usecases
 
-import "github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+import (
+	"context"
+	"time"
+
+	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
+)
 
 type UserRepoInterface interface {
-	RegisterUser(user *domain.User) (*domain.User, error)
-	GetUser(username string) (*domain.User, error)
-	PromoteUser(username string) error
-	Count() (int64, error)
+	RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error)
+	GetUser(ctx context.Context, username string) (*domain.User, error)
+	GetUserByOIDCSubject(ctx context.Context, issuer string, subject string) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	LinkOIDCIdentity(ctx context.Context, username string, issuer string, subject string) error
+	PromoteUser(ctx context.Context, username string) error
+	UpdatePassword(ctx context.Context, username string, password string) error
+	Count(ctx context.Context) (int64, error)
+	UpdateTwoFactor(ctx context.Context, username string, secret string, enabled bool, recoveryCodes []string) error
+	UpdateRecoveryCodes(ctx context.Context, username string, recoveryCodes []string) error
+	// records the time step of an accepted totp code, fails with ErrInvalidTwoFactorCode
+	// when that step or a later one was used already
+	UseTOTPCounter(ctx context.Context, username string, counter int64) error
+	// stores a verification token for the current email, or for pendingEmail when
+	// it is set, which replaces the current email once it is verified
+	SetEmailVerification(ctx context.Context, username string, pendingEmail string, tokenHash string, expiresAt time.Time) error
+	// fails with ErrEmailExists when a pending email was taken in the meantime
+	VerifyEmail(ctx context.Context, tokenHash string, now time.Time) error
+	UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (*domain.User, error)
 }
\ No newline at end of file
@@ -1,36 +1,108 @@
 package usecases
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 
 	"github.com/abe16s/Go-Backend-Learning-path/task_manager/domain"
 	"github.com/google/uuid"
 )
 
 type UserServiceInterface interface {
-	RegisterUser(user *domain.User) (*domain.User, error)
-	LoginUser(user domain.User) (string, error)
-	PromoteUser(username string) error
+	RegisterUser(ctx context.Context, user *domain.User, invitationToken string) (*domain.User, error)
+	LoginUser(ctx context.Context, user domain.User, clientIP string) (*domain.LoginResult, error)
+	VerifyTwoFactorLogin(ctx context.Context, login domain.TwoFactorLogin, clientIP string) (string, error)
+	EnrollTwoFactor(ctx context.Context, username string) (*domain.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, username string, code string) ([]string, error)
+	PromoteUser(ctx context.Context, promotedBy string, username string) error
+	UnlockUser(ctx context.Context, unlockedBy string, username string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendEmailVerification(ctx context.Context, email string) error
+	GetProfile(ctx context.Context, username string) (*domain.UserProfile, error)
+	UpdateProfile(ctx context.Context, username string, update domain.ProfileUpdate) (*domain.UserProfile, error)
 }
 
+type RegistrationMode string
+
+const (
+	// anyone can register, the default
+	RegistrationOpen RegistrationMode = "open"
+	// only users with an invitation can register
+	RegistrationInvite RegistrationMode = "invite"
+	// no self registration, users come from OIDC or the initial admin
+	RegistrationClosed RegistrationMode = "closed"
+)
+
 type UserService struct {
 	UserRepo UserRepoInterface
+	Bootstrap BootstrapRepoInterface
 	PasswordService PasswordServiceInterface
 	JwtService JwtServiceInterface
+	// optional, failed logins are not throttled when nil
+	LoginAttempts LoginAttemptRepoInterface
+	Lockout LockoutPolicy
+	TotpService TotpServiceInterface
+	// withhold admin rights from admins until they enable two-factor authentication
+	RequireAdminTwoFactor bool
+	// only allow logins through an identity provider
+	PasswordLoginDisabled bool
+	// empty means RegistrationOpen
+	RegistrationMode RegistrationMode
+	// needed to register with an invitation
+	Invitations    InvitationRepoInterface
+	TokenGenerator TokenGeneratorInterface
+	// optional, verification emails are sent when set
+	Mailer MailerInterface
+	// link in verification emails, the token is added as query parameter
+	VerificationURL string
+	// require an email on registration and block logins until it is verified
+	RequireEmailVerification bool
+	// optional, counts password logins by result
+	Metrics LoginMetricsInterface
+	// lockouts, the initial admin and failed verification emails are logged here, slog.Default() when nil
+	Logger *slog.Logger
+	// optional, logins, registrations, promotions and unlocks are recorded when set
+	Audit AuditRepoInterface
+}
+
+func (s *UserService) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
 }
 
-// register new user with unique username and password
-func (s *UserService) RegisterUser(user *domain.User) (*domain.User, error) {
-	count, err := s.UserRepo.Count()
+// register new user with unique username and password, an invitation is optional unless registration is invite only
+func (s *UserService) RegisterUser(ctx context.Context, user *domain.User, invitationToken string) (_ *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.RegisterUser")
+	defer endSpan(span, &err)
+
+	if s.RegistrationMode == RegistrationClosed {
+		return nil, domain.ErrRegistrationClosed
+	}
+	if s.RegistrationMode == RegistrationInvite && invitationToken == "" {
+		return nil, domain.ErrInvitationRequired
+	}
+
+	user.Email = normalizeEmail(user.Email)
+	if s.RequireEmailVerification && user.Email == "" {
+		return nil, domain.ErrEmailRequired
+	}
+
+	// only the first registration on an empty database may become admin
+	count, err := s.UserRepo.Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	user.ID = uuid.New()
-
-	if count == 0 {
-		user.IsAdmin = true
-	}
+	// never trust roles or flags sent by the client
+	user.IsAdmin = false
+	user.TwoFactorEnabled = false
+	user.EmailVerified = false
 
 	hashedPassword, err := s.PasswordService.HashPassword(user.Password)
     if err != nil {
@@ -39,39 +111,265 @@ func (s *UserService) RegisterUser(user *domain.User) (*domain.User, error) {
 
     user.Password = hashedPassword
 
-	u, err := s.UserRepo.RegisterUser(user)
+	var invitation *domain.Invitation
+	if invitationToken != "" {
+		if s.Invitations == nil {
+			return nil, domain.ErrInvalidInvitation
+		}
+		invitation, err = redeemInvitation(ctx, s.Invitations, s.TokenGenerator, invitationToken, user.Username, user.Email)
+		if err != nil {
+			return nil, err
+		}
+		user.IsAdmin = invitation.IsAdmin
+	}
+
+	u, err := s.UserRepo.RegisterUser(ctx, user)
 
 	if err != nil {
+		// let the invitation be used for another try, the registration error is what matters to the caller.
+		// The release must also happen when the registration failed because the request was canceled
+		if invitation != nil {
+			if releaseErr := s.Invitations.ReleaseInvitation(context.WithoutCancel(ctx), invitation.ID); releaseErr != nil {
+				s.logger().ErrorContext(ctx, "could not release invitation", "invitation", invitation.ID, "error", releaseErr)
+			}
+		}
 		return nil, err
 	}
 
+	// concurrent first registrations all see an empty database, the claim lets only one of them win
+	if count == 0 && !u.IsAdmin {
+		claimed, err := s.Bootstrap.ClaimFirstAdmin(ctx, u.Username)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			if err := s.UserRepo.PromoteUser(ctx, u.Username); err != nil {
+				return nil, err
+			}
+			u.IsAdmin = true
+		}
+	}
+
+	var details []string
+	if u.IsAdmin {
+		details = append(details, "admin")
+	}
+	if invitation != nil {
+		details = append(details, "invitation "+invitation.ID.String())
+	}
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: u.Username, Action: domain.AuditUserRegistered, Target: u.Username, Detail: strings.Join(details, ", ")})
+
+	// the account exists already, a failed email can be sent again with ResendEmailVerification
+	if s.Mailer != nil && u.Email != "" {
+		if err := s.sendEmailVerification(ctx, u, ""); err != nil {
+			s.logger().WarnContext(ctx, "could not send verification email", "username", u.Username, "error", err)
+		}
+	}
+
 	return u, nil
 }
 
 
-// login user 
-func (s *UserService) LoginUser(user domain.User) (string, error) {
-	existingUser, err := s.UserRepo.GetUser(user.Username)
+// create the admin from the environment on startup, an existing admin with that name is left alone
+func (s *UserService) BootstrapAdmin(ctx context.Context, username string, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserService.BootstrapAdmin")
+	defer endSpan(span, &err)
+
+	existingUser, err := s.UserRepo.GetUser(ctx, username)
+	if err == nil {
+		if !existingUser.IsAdmin {
+			return errors.New("initial admin username is taken by a non-admin user")
+		}
+		return nil
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+
+	hashedPassword, err := s.PasswordService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// the first registration must not become a second admin
+	if _, err := s.Bootstrap.ClaimFirstAdmin(ctx, username); err != nil {
+		return err
+	}
+
+	_, err = s.UserRepo.RegisterUser(ctx, &domain.User{ID: uuid.New(), Username: username, Password: hashedPassword, IsAdmin: true})
+	// another instance bootstrapped the same admin concurrently
+	if err != nil && errors.Is(err, domain.ErrUsernameExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.logger().InfoContext(ctx, "initial admin created", "username", username)
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: username, Action: domain.AuditUserRegistered, Target: username, Detail: "initial admin"})
+	return nil
+}
+
+
+// login user, throttled per username and client ip
+func (s *UserService) LoginUser(ctx context.Context, user domain.User, clientIP string) (_ *domain.LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "UserService.LoginUser")
+	defer endSpan(span, &err)
+
+	result, err := s.loginUser(ctx, user, clientIP)
+	label := loginResult(result, err)
+	if s.Metrics != nil {
+		s.Metrics.ObserveLogin(label)
+	}
+	// a login waiting for its second factor is audited by VerifyTwoFactorLogin
+	switch label {
+	case LoginSucceeded:
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditLoginSucceeded, ClientIP: clientIP})
+	case LoginTwoFactorRequired:
+	default:
+		recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: user.Username, Action: domain.AuditLoginFailed, ClientIP: clientIP, Detail: label})
+	}
+	return result, err
+}
+
+// the label a login is counted under
+func loginResult(result *domain.LoginResult, err error) string {
+	var tooManyAttempts *TooManyAttemptsError
+	switch {
+	case err == nil && result.TwoFactorRequired:
+		return LoginTwoFactorRequired
+	case err == nil:
+		return LoginSucceeded
+	case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrInvalidTwoFactorCode):
+		return LoginInvalidCredentials
+	case errors.As(err, &tooManyAttempts):
+		return LoginThrottled
+	case domain.KindOf(err) == domain.KindInternal:
+		return LoginError
+	}
+	return LoginRejected
+}
+
+func (s *UserService) loginUser(ctx context.Context, user domain.User, clientIP string) (*domain.LoginResult, error) {
+	if s.PasswordLoginDisabled {
+		return nil, domain.ErrPasswordLoginDisabled
+	}
+
+	keys := loginAttemptKeys(user.Username, clientIP)
+	if err := s.checkLockout(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.UserRepo.GetUser(ctx, user.Username)
 	if err != nil {
-		return "", err
+		if errors.Is(err, domain.ErrUserNotFound) {
+			if err := s.recordLoginFailure(ctx, keys); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
 	}
 
 	match := s.PasswordService.ComparePassword(existingUser.Password, user.Password)
 	if !match {
-		return "", errors.New("invalid credentials")
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err := checkEmailVerified(s.RequireEmailVerification, existingUser); err != nil {
+		return nil, err
+	}
+
+	// upgrade hashes made with an old algorithm or parameters while the plain password is at hand,
+	// the old hash still works so a failed upgrade doesn't fail the login
+	if s.PasswordService.NeedsRehash(existingUser.Password) {
+		if err := s.rehashPassword(ctx, existingUser.Username, user.Password); err != nil {
+			s.logger().WarnContext(ctx, "could not upgrade password hash", "username", existingUser.Username, "error", err)
+		}
+	}
+
+	result, err := issueLoginResult(s.JwtService, existingUser, s.RequireAdminTwoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	// the failures are only forgiven once the second factor is checked too,
+	// otherwise the password alone would allow guessing codes without limit
+	if s.LoginAttempts != nil && !result.TwoFactorRequired {
+		if err := s.LoginAttempts.Reset(ctx, usernameAttemptKey(existingUser.Username)); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+
+func (s *UserService) rehashPassword(ctx context.Context, username string, password string) error {
+	hashedPassword, err := s.PasswordService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.UserRepo.UpdatePassword(ctx, username, hashedPassword)
+}
+
+
+// issue the token for an authenticated user, or a challenge token when a second factor is needed
+func issueLoginResult(jwtService JwtServiceInterface, user *domain.User, requireAdminTwoFactor bool) (*domain.LoginResult, error) {
+	// the real token is only issued after the second factor
+	if user.TwoFactorEnabled {
+		challengeToken, err := jwtService.GenerateChallengeToken(user.Username)
+		if err != nil {
+			return nil, fmt.Errorf("generate challenge token: %w", err)
+		}
+		return &domain.LoginResult{ChallengeToken: challengeToken, TwoFactorRequired: true}, nil
+	}
+
+	isAdmin := user.IsAdmin
+	enrollmentRequired := false
+	if isAdmin && requireAdminTwoFactor {
+		isAdmin = false
+		enrollmentRequired = true
 	}
 
 	// generate token
-	jwtToken, err := s.JwtService.GenerateToken(existingUser.Username, existingUser.IsAdmin)
+	jwtToken, err := jwtService.GenerateToken(user.Username, isAdmin)
 	if err != nil {
-		return "", errors.New("internal server error")
+		return nil, fmt.Errorf("generate token: %w", err)
 	}
 
-	return jwtToken, nil
+	return &domain.LoginResult{Token: jwtToken, TwoFactorEnrollmentRequired: enrollmentRequired}, nil
 }
 
 
 // promote user to admin
-func (s *UserService) PromoteUser(username string) error {
-	return s.UserRepo.PromoteUser(username)
+func (s *UserService) PromoteUser(ctx context.Context, promotedBy string, username string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserService.PromoteUser")
+	defer endSpan(span, &err)
+
+	if err := s.UserRepo.PromoteUser(ctx, username); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: promotedBy, Action: domain.AuditUserPromoted, Target: username})
+	return nil
+}
+
+
+// clear failed login attempts and any lockout for a username
+func (s *UserService) UnlockUser(ctx context.Context, unlockedBy string, username string) (err error) {
+	ctx, span := tracer.Start(ctx, "UserService.UnlockUser")
+	defer endSpan(span, &err)
+
+	if _, err := s.UserRepo.GetUser(ctx, username); err != nil {
+		return err
+	}
+
+	if s.LoginAttempts != nil {
+		if err := s.LoginAttempts.Reset(ctx, usernameAttemptKey(username)); err != nil {
+			return err
+		}
+	}
+
+	recordAudit(ctx, s.Logger, s.Audit, domain.AuditEntry{Actor: unlockedBy, Action: domain.AuditUserUnlocked, Target: username})
+	return nil
 }
\ No newline at end of file